@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// embedCheckpointFile is where embed records its progress, in the data dir
+const embedCheckpointFile = "embed-checkpoint.json"
+
+// embedCheckpoint is an embed run's progress, saved after each batch so
+// embed -resume can pick up after a crash or Ctrl-C
+type embedCheckpoint struct {
+	Model     string    `json:"model"`   // Provider model being embedded
+	NextID    string    `json:"next_id"` // First document not yet embedded, in ID order
+	Done      int       `json:"done"`    // Documents finished before NextID
+	Total     int       `json:"total"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func embedCheckpointPath() string {
+	return filepath.Join(dataDir, embedCheckpointFile)
+}
+
+// loadEmbedCheckpoint reads the saved checkpoint (nil if there is none)
+func loadEmbedCheckpoint() (*embedCheckpoint, error) {
+	data, err := os.ReadFile(embedCheckpointPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cp embedCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", embedCheckpointFile, err)
+	}
+	return &cp, nil
+}
+
+// saveEmbedCheckpoint replaces the checkpoint, writing it to a temporary file
+// first so a crash mid-write can't leave it truncated
+func saveEmbedCheckpoint(cp *embedCheckpoint) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := embedCheckpointPath()
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// clearEmbedCheckpoint removes the checkpoint once a run completes
+func clearEmbedCheckpoint() error {
+	err := os.Remove(embedCheckpointPath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/renderinc/slab-search/internal/embeddings"
+	"github.com/renderinc/slab-search/internal/search"
+	"github.com/renderinc/slab-search/internal/storage"
+)
+
+// modelRanking is one model's semantic results in compare-models
+type modelRanking struct {
+	Model   string                 `json:"model"`
+	Results []*search.SearchResult `json:"results"`
+}
+
+// compareOutput is the compare-models -json shape
+type compareOutput struct {
+	Query    string       `json:"query"`
+	Nomic    modelRanking `json:"nomic"`
+	Qwen     modelRanking `json:"qwen"`
+	Shared   int          `json:"shared"`   // Documents in both top lists
+	Overlap  float64      `json:"overlap"`  // Shared / list size
+	Spearman *float64     `json:"spearman"` // Rank correlation over shared documents, null if fewer than 2
+}
+
+// runCompareModels runs the same semantic search against the nomic and qwen
+// embeddings and prints both rankings side by side, with how much they agree
+func runCompareModels(query string, limit int, jsonOutput bool) {
+	if embeddingProvider != embeddings.ProviderOllama {
+		log.Fatalf("Error: compare-models needs the ollama embedding provider (qwen is Ollama-only)")
+	}
+
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+
+	idx, err := search.Open(indexPath)
+	if err != nil {
+		log.Fatalf("Error opening search index: %v", err)
+	}
+	defer idx.Close()
+	idx.SetDB(db)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	out := compareOutput{Query: query}
+	for _, side := range []struct {
+		name    string
+		useQwen bool
+		ranking *modelRanking
+	}{
+		{"nomic", false, &out.Nomic},
+		{"qwen", true, &out.Qwen},
+	} {
+		model := embeddings.GetModelName(embeddingProvider, side.name)
+		embedder, err := newEmbedder(model)
+		if err != nil {
+			printEmbedderHint(model)
+			log.Fatalf("Error: %s embeddings unavailable: %v", side.name, err)
+		}
+		queryEmbedding, err := embedder.Embed(ctx, query)
+		if err != nil {
+			log.Fatalf("Error generating %s query embedding: %v", side.name, err)
+		}
+
+		page, err := idx.SemanticSearch(ctx, query, queryEmbedding, side.useQwen, search.SearchOptions{Limit: limit})
+		if err != nil {
+			log.Fatalf("Error searching with %s: %v", side.name, err)
+		}
+		*side.ranking = modelRanking{Model: model, Results: page.Hits}
+	}
+
+	out.Shared, out.Spearman = compareRankings(out.Nomic.Results, out.Qwen.Results)
+	if size := max(len(out.Nomic.Results), len(out.Qwen.Results)); size > 0 {
+		out.Overlap = float64(out.Shared) / float64(size)
+	}
+
+	if jsonOutput {
+		printJSON(out)
+		return
+	}
+
+	fmt.Printf("=== %q: top %d by model ===\n\n", query, limit)
+	fmt.Printf("%-4s %-40s %6s   %-40s %6s\n", "#", out.Nomic.Model, "score", out.Qwen.Model, "score")
+	for i := range max(len(out.Nomic.Results), len(out.Qwen.Results)) {
+		left, right := compareCell(out.Nomic.Results, i), compareCell(out.Qwen.Results, i)
+		fmt.Printf("%-4d %s   %s\n", i+1, left, right)
+	}
+
+	fmt.Println()
+	fmt.Printf("Shared in top %d: %d (%.0f%% overlap)\n", limit, out.Shared, out.Overlap*100)
+	if out.Spearman != nil {
+		fmt.Printf("Rank correlation of shared documents (Spearman): %.2f\n", *out.Spearman)
+	}
+	if len(out.Nomic.Results) == 0 || len(out.Qwen.Results) == 0 {
+		fmt.Println("One model returned nothing; documents may not have embeddings for it (see stats)")
+	}
+}
+
+// compareCell formats the i-th result of a ranking as a fixed-width title
+// and score, blank past its end
+func compareCell(results []*search.SearchResult, i int) string {
+	if i >= len(results) {
+		return fmt.Sprintf("%-40s %6s", "", "")
+	}
+	return fmt.Sprintf("%-40s %6.3f", truncate(results[i].Title, 40), results[i].Score)
+}
+
+// truncate shortens s to at most n runes, marking the cut with "…"
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// compareRankings counts the documents in both rankings and, when at least
+// two are shared, the Spearman correlation of their ranks within each list
+func compareRankings(a, b []*search.SearchResult) (int, *float64) {
+	rankB := make(map[string]int, len(b))
+	for i, r := range b {
+		rankB[r.ID] = i
+	}
+
+	// Shared documents, in a's order, with their position in b
+	var posB []int
+	for _, r := range a {
+		if i, ok := rankB[r.ID]; ok {
+			posB = append(posB, i)
+		}
+	}
+	n := len(posB)
+	if n < 2 {
+		return n, nil
+	}
+
+	// Re-rank the shared documents 0..n-1 within b; a's ranks are their order
+	sumSq := 0.0
+	for rankA, p := range posB {
+		rankWithinB := 0
+		for _, q := range posB {
+			if q < p {
+				rankWithinB++
+			}
+		}
+		d := float64(rankA - rankWithinB)
+		sumSq += d * d
+	}
+	rho := 1 - 6*sumSq/float64(n*(n*n-1))
+	return n, &rho
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFileName is the config file looked up in the working directory and
+// then in $HOME/.config/slab-search/
+const configFileName = "slab-search.yaml"
+
+// config holds defaults from slab-search.yaml; command-line flags override them
+// Empty fields leave the built-in defaults in place.
+type config struct {
+	DataDir           string `yaml:"data_dir"`
+	DBPath            string `yaml:"db_path"`    // Default --db-path
+	IndexPath         string `yaml:"index_path"` // Default --index-path
+	SlabURL           string `yaml:"slab_url"`
+	EmbeddingProvider string `yaml:"embedding_provider"`
+	EmbeddingURL      string `yaml:"embedding_url"`
+
+	// EmbedTimeout is the default --embed-timeout, e.g. "2m"
+	EmbedTimeout time.Duration `yaml:"embed_timeout"`
+
+	// LogLevel and LogFormat are the defaults for --log-level and --log-format
+	LogLevel  string `yaml:"log_level"`
+	LogFormat string `yaml:"log_format"`
+
+	// Model is the default -model for search and embed
+	Model string `yaml:"model"`
+
+	// SearchMode is the default search mode: keyword, semantic or hybrid
+	SearchMode string `yaml:"search_mode"`
+	// HybridWeight is the semantic weight used when SearchMode is hybrid
+	HybridWeight float64 `yaml:"hybrid_weight"`
+
+	// TopicBoosts multiplies hybrid scores of documents in these topics
+	// (exact names), e.g. {"Engineering Runbooks": 1.5}
+	TopicBoosts map[string]float64 `yaml:"topic_boosts"`
+
+	// RerankURL and RerankModel configure search -rerank (default: Cohere)
+	RerankURL   string `yaml:"rerank_url"`
+	RerankModel string `yaml:"rerank_model"`
+
+	Server struct {
+		Host string `yaml:"host"`
+		Port string `yaml:"port"`
+	} `yaml:"server"`
+}
+
+// loadConfig reads the config file at path, or the first one found in the
+// default locations when path is empty
+// Returns an empty config (and "" for the path) if no file exists; an
+// explicit path that doesn't exist is an error.
+func loadConfig(path string) (*config, string, error) {
+	cfg := &config{}
+
+	if path == "" {
+		path = findConfig()
+		if path == "" {
+			return cfg, "", nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("read config: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true) // Catch typos like "data-dir"
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, "", fmt.Errorf("parse config %s: %w", path, err)
+	}
+
+	switch cfg.SearchMode {
+	case "", "keyword", "semantic", "hybrid":
+	default:
+		return nil, "", fmt.Errorf("config %s: unknown search_mode %q (want keyword, semantic or hybrid)", path, cfg.SearchMode)
+	}
+	if cfg.HybridWeight < 0 || cfg.HybridWeight > 1 {
+		return nil, "", fmt.Errorf("config %s: hybrid_weight must be between 0 and 1", path)
+	}
+	for topic, boost := range cfg.TopicBoosts {
+		if boost <= 0 {
+			return nil, "", fmt.Errorf("config %s: topic_boosts[%q] must be positive", path, topic)
+		}
+	}
+
+	return cfg, path, nil
+}
+
+// findConfig returns the first existing default config file, or ""
+func findConfig() string {
+	candidates := []string{configFileName}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, ".config", "slab-search", configFileName))
+	}
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// configFlag extracts --config=<path> from the global arguments, which must be
+// known before the other flags so the file can supply their defaults
+func configFlag(args []string) string {
+	for _, arg := range args {
+		for _, prefix := range []string{"--config=", "-config="} {
+			if value, ok := strings.CutPrefix(arg, prefix); ok {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// defaultHybridWeight is the default -hybrid weight: the configured weight
+// (0.3 if unset) when search_mode is hybrid, otherwise 0 (hybrid off)
+func (c *config) defaultHybridWeight() float64 {
+	if c.SearchMode != "hybrid" {
+		return 0
+	}
+	if c.HybridWeight == 0 {
+		return 0.3
+	}
+	return c.HybridWeight
+}
+
+// orDefault returns value, or fallback if value is empty
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/renderinc/slab-search/internal/search"
+	"github.com/renderinc/slab-search/internal/storage"
+)
+
+// dedupeDoc is a document in the dedupe -json output
+type dedupeDoc struct {
+	ID         string  `json:"id"`
+	Title      string  `json:"title"`
+	SlabURL    string  `json:"slab_url"`
+	Similarity float64 `json:"similarity,omitempty"` // To the canonical document
+}
+
+// dedupeCluster is one group of near-identical documents in dedupe -json
+type dedupeCluster struct {
+	Canonical  dedupeDoc   `json:"canonical"`
+	Duplicates []dedupeDoc `json:"duplicates"`
+}
+
+// dedupeOutput is the dedupe -json shape
+type dedupeOutput struct {
+	Threshold  float64         `json:"threshold"`
+	Clusters   []dedupeCluster `json:"clusters"`
+	Duplicates int             `json:"duplicates"` // Documents other than the canonical ones
+	Marked     bool            `json:"marked"`     // Whether -mark recorded them
+}
+
+// runDedupe reports clusters of documents whose embeddings are at least
+// threshold similar and, with mark, records all but each canonical document
+// as duplicates for search -hide-duplicates
+func runDedupe(threshold float64, modelName string, mark, jsonOutput bool) {
+	_, useQwen := resolveModel(modelName)
+
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+
+	docs, err := db.List(false)
+	if err != nil {
+		log.Fatalf("Error listing documents: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	clusters, err := search.FindDuplicates(ctx, docs, useQwen, threshold)
+	if err != nil {
+		log.Fatalf("Error finding duplicates: %v", err)
+	}
+
+	out := dedupeOutput{Threshold: threshold, Clusters: []dedupeCluster{}, Marked: mark}
+	dups := make(map[string]string)
+	for _, c := range clusters {
+		cluster := dedupeCluster{
+			Canonical: dedupeDoc{ID: c.Canonical.ID, Title: c.Canonical.Title, SlabURL: c.Canonical.SlabURL},
+		}
+		for _, d := range c.Duplicates {
+			cluster.Duplicates = append(cluster.Duplicates, dedupeDoc{
+				ID: d.Doc.ID, Title: d.Doc.Title, SlabURL: d.Doc.SlabURL, Similarity: d.Similarity,
+			})
+			dups[d.Doc.ID] = c.Canonical.ID
+		}
+		out.Clusters = append(out.Clusters, cluster)
+	}
+	out.Duplicates = len(dups)
+
+	if mark {
+		if err := db.MarkDuplicates(dups); err != nil {
+			log.Fatalf("Error marking duplicates: %v", err)
+		}
+	}
+
+	if jsonOutput {
+		printJSON(out)
+		return
+	}
+
+	fmt.Printf("Compared %d documents at a similarity threshold of %.2f\n\n", len(docs), threshold)
+	if len(clusters) == 0 {
+		fmt.Println("No duplicates found")
+	} else {
+		fmt.Printf("Found %d clusters with %d duplicate documents:\n", len(clusters), out.Duplicates)
+		for i, c := range out.Clusters {
+			fmt.Printf("\n%d. %s (%s)\n", i+1, c.Canonical.Title, c.Canonical.ID)
+			for _, d := range c.Duplicates {
+				fmt.Printf("   %.3f  %s (%s)\n", d.Similarity, d.Title, d.ID)
+			}
+		}
+	}
+
+	if mark {
+		fmt.Printf("\nMarked %d documents as duplicates (replacing earlier marks); search with -hide-duplicates to leave them out\n", out.Duplicates)
+	} else if len(clusters) > 0 {
+		fmt.Println("\nRun with -mark to flag the duplicates so search -hide-duplicates leaves them out")
+	}
+}
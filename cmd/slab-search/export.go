@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+
+	"github.com/renderinc/slab-search/internal/storage"
+)
+
+// maxImportLine caps the size of one JSONL record, which holds a whole
+// document and possibly its embeddings
+const maxImportLine = 256 << 20 // 256MB
+
+// runExport writes every document as one JSON object per line to output
+// ("-" for stdout), streaming rows so the corpus is never held in memory
+func runExport(output string, includeArchived, withEmbeddings bool) {
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+
+	var out io.Writer = os.Stdout
+	var file *os.File
+	if output != "-" {
+		file, err = os.Create(output)
+		if err != nil {
+			log.Fatalf("Error creating %s: %v", output, err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	w := bufio.NewWriter(out)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	count := 0
+	err = db.Each(includeArchived, func(doc *storage.Document) error {
+		count++
+		return enc.Encode(storage.NewExportedDocument(doc, withEmbeddings))
+	})
+	if err != nil {
+		log.Fatalf("Error exporting documents: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatalf("Error writing export: %v", err)
+	}
+	if file != nil {
+		if err := file.Close(); err != nil {
+			log.Fatalf("Error writing %s: %v", output, err)
+		}
+		// Keep stdout clean when it carries the export
+		fmt.Printf("Exported %d documents to %s\n", count, output)
+	}
+}
+
+// runImport upserts documents from a JSONL export in input ("-" for stdin)
+// Existing documents with the same ID are replaced.
+func runImport(input string) {
+	if err := ensureDataDirs(); err != nil {
+		log.Fatalf("Error creating data directory: %v", err)
+	}
+
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+
+	var in io.Reader = os.Stdin
+	if input != "-" {
+		file, err := os.Open(input)
+		if err != nil {
+			log.Fatalf("Error opening %s: %v", input, err)
+		}
+		defer file.Close()
+		in = file
+	}
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 1<<20), maxImportLine)
+
+	imported, failed, line := 0, 0, 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record storage.ExportedDocument
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			slog.Warn("Skipping line", "line", line, "error", err)
+			failed++
+			continue
+		}
+		doc, err := record.Document()
+		if err != nil {
+			slog.Warn("Skipping line", "line", line, "error", err)
+			failed++
+			continue
+		}
+
+		// Chunks aren't exported; drop any embedded from different content
+		previous, err := db.GetUpdatedAt(doc.ID)
+		if err != nil {
+			log.Fatalf("Error reading document %s: %v", doc.ID, err)
+		}
+		if err := db.Upsert(doc); err != nil {
+			log.Fatalf("Error importing document %s: %v", doc.ID, err)
+		}
+		if !previous.IsZero() && !previous.Equal(doc.UpdatedAt) {
+			if err := db.DeleteChunks(doc.ID); err != nil {
+				slog.Warn("Failed to delete stale chunks", "id", doc.ID, "error", err)
+			}
+		}
+
+		imported++
+		if imported%1000 == 0 {
+			fmt.Printf("Imported %d documents...\n", imported)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("Error reading line %d: %v", line+1, err)
+	}
+
+	fmt.Printf("Imported %d documents (%d skipped)\n", imported, failed)
+	if imported > 0 {
+		fmt.Println("Run 'slab-search reindex' to make them searchable")
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+)
+
+// setupLogging makes slog's default logger write to stderr at level
+// (debug, info, warn or error) in format (text or json)
+func setupLogging(level, format string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("unknown log level %q (want debug, info, warn or error)", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var handler slog.Handler
+	switch format {
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("unknown log format %q (want text or json)", format)
+	}
+	slog.SetDefault(slog.New(handler))
+
+	// SetDefault routes the log package through the handler at info level;
+	// keep log.Fatal errors plain and visible whatever the level
+	log.SetOutput(os.Stderr)
+	log.SetFlags(log.LstdFlags)
+	return nil
+}
@@ -2,15 +2,25 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"html"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	gosync "sync"
 	"time"
 
 	"github.com/renderinc/slab-search/internal/embeddings"
+	"github.com/renderinc/slab-search/internal/rerank"
 	"github.com/renderinc/slab-search/internal/search"
 	"github.com/renderinc/slab-search/internal/slab"
 	"github.com/renderinc/slab-search/internal/storage"
@@ -18,22 +28,28 @@ import (
 	"github.com/renderinc/slab-search/internal/web"
 )
 
-const (
-	ollamaURL   = "http://localhost:11434"
-	ollamaModel = "nomic-embed-text"
-)
+// maxSearchLimit caps -limit, matching the web UI
+const maxSearchLimit = 100
+
+// defaultEmbedBatchSize is how many documents embed sends per request
+const defaultEmbedBatchSize = 16
 
 var (
 	dataDir   string
 	dbPath    string
 	indexPath string
+
+	embeddingProvider string
+	embeddingURL      string
+	embedTimeout      time.Duration // Per-request embedding timeout (0 = model-based default)
+
+	slabURL string
+
+	// topicBoosts are the configured hybrid score multipliers by topic
+	topicBoosts map[string]float64
 )
 
 func main() {
-	// Parse global flags
-	globalFlags := flag.NewFlagSet("global", flag.ExitOnError)
-	dataDirFlag := globalFlags.String("data-dir", "./data", "Directory for database and index files")
-
 	// Check if we have any arguments
 	if len(os.Args) < 2 {
 		printUsage()
@@ -49,27 +65,130 @@ func main() {
 		}
 	}
 
+	// Load the config file first; its values become the flag defaults, so
+	// flags on the command line still win
+	cfg, cfgPath, err := loadConfig(configFlag(os.Args[1:commandIdx]))
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	// Parse global flags
+	globalFlags := flag.NewFlagSet("global", flag.ExitOnError)
+	globalFlags.String("config", cfgPath, "Config file (default: ./slab-search.yaml or ~/.config/slab-search/slab-search.yaml)")
+	dataDirFlag := globalFlags.String("data-dir", orDefault(cfg.DataDir, "./data"), "Directory for database and index files")
+	dbPathFlag := globalFlags.String("db-path", cfg.DBPath, "Database file (default: <data-dir>/slab.db)")
+	indexPathFlag := globalFlags.String("index-path", cfg.IndexPath, "Search index directory (default: <data-dir>/bleve)")
+	providerFlag := globalFlags.String("embedding-provider", orDefault(cfg.EmbeddingProvider, embeddings.ProviderOllama), "Embedding provider: ollama or openai")
+	embeddingURLFlag := globalFlags.String("embedding-url", cfg.EmbeddingURL, "Embedding API base URL (default depends on provider)")
+	embedTimeoutFlag := globalFlags.Duration("embed-timeout", cfg.EmbedTimeout, "Per-request embedding timeout, e.g. 2m (default: 60s, 3m for qwen)")
+	slabURLFlag := globalFlags.String("slab-url", orDefault(cfg.SlabURL, slab.DefaultBaseURL), "Slab workspace URL to sync from")
+	logLevelFlag := globalFlags.String("log-level", orDefault(cfg.LogLevel, "info"), "Log level: debug, info, warn or error")
+	logFormatFlag := globalFlags.String("log-format", orDefault(cfg.LogFormat, "text"), "Log format: text or json")
+	quietFlag := globalFlags.Bool("quiet", false, "Only log warnings and errors (same as --log-level=warn)")
+	defaultModel := orDefault(cfg.Model, "nomic")
+
 	// Parse global flags if any exist before the command
 	if commandIdx > 1 {
 		globalFlags.Parse(os.Args[1:commandIdx])
 	}
 
-	// Set paths based on data-dir flag
-	dataDir = *dataDirFlag
-	dbPath = dataDir + "/slab.db"
-	indexPath = dataDir + "/bleve"
+	logLevel := *logLevelFlag
+	if *quietFlag && !strings.EqualFold(logLevel, "error") {
+		logLevel = "warn" // --quiet never makes logging noisier
+	}
+	if err := setupLogging(logLevel, *logFormatFlag); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	// Set paths based on the data-dir, db-path and index-path flags
+	if err := resolvePaths(*dataDirFlag, *dbPathFlag, *indexPathFlag); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	slog.Debug("Data paths", "data_dir", dataDir, "db", dbPath, "index", indexPath)
+
+	topicBoosts = cfg.TopicBoosts
+
+	embeddingProvider = *providerFlag
+	embeddingURL = *embeddingURLFlag
+	if embeddingURL == "" {
+		embeddingURL = embeddings.GetDefaultURL(embeddingProvider)
+	}
+	embedTimeout = *embedTimeoutFlag
+	if embedTimeout < 0 {
+		log.Fatalf("Error: --embed-timeout must not be negative")
+	}
+
+	slabURL, err = slab.ValidateBaseURL(*slabURLFlag)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
 
 	command := os.Args[commandIdx]
 
 	switch command {
 	case "sync":
-		runSync()
+		// Parse sync flags
+		syncFlags := flag.NewFlagSet("sync", flag.ExitOnError)
+		since := syncFlags.Duration("since", 0, "Incremental sync: only posts updated after the last full (not -topic) sync, minus this slack (e.g. 1h); 0 = full sync")
+		concurrency := syncFlags.Int("concurrency", sync.DefaultConcurrency, "Number of posts to sync in parallel (minimum 1)")
+		rateLimit := syncFlags.Float64("rate-limit", 0, "Maximum Slab API requests per second (0 = unlimited)")
+		dryRun := syncFlags.Bool("dry-run", false, "Only report which posts would be added, updated or removed; write nothing")
+		withComments := syncFlags.Bool("with-comments", false, "Also fetch and index post comments (one extra API request per post)")
+		jsonOutput := syncFlags.Bool("json", false, "Print the final stats as JSON instead of a summary (logs still go to stderr)")
+		topic := syncFlags.String("topic", "", "Only sync the posts in this topic (ID or name), leaving other documents untouched")
+		noEmbeddings := syncFlags.Bool("no-embeddings", false, "Don't generate embeddings, even if the embedding provider is running")
+		reindex := syncFlags.Bool("reindex", false, "Rebuild the keyword index from the database after syncing, so the two can't drift")
+		force := syncFlags.Bool("force", false, "Sync even if the database was synced from another workspace (purges its documents)")
+
+		syncFlags.Parse(os.Args[commandIdx+1:])
+
+		if *since < 0 {
+			fmt.Println("Error: -since must not be negative")
+			os.Exit(1)
+		}
+
+		if *rateLimit < 0 {
+			fmt.Println("Error: -rate-limit must not be negative")
+			os.Exit(1)
+		}
+
+		if *reindex && *dryRun {
+			fmt.Println("Error: -reindex can't be combined with -dry-run")
+			os.Exit(1)
+		}
+
+		runSync(*since, *concurrency, *rateLimit, *topic, *dryRun, *withComments, *noEmbeddings, *reindex, *force, *jsonOutput)
 	case "search":
 		// Parse search flags
 		searchFlags := flag.NewFlagSet("search", flag.ExitOnError)
-		semantic := searchFlags.Bool("semantic", false, "Use semantic search only")
-		hybrid := searchFlags.Float64("hybrid", 0.0, "Use hybrid search (0.0-1.0, where value is semantic weight)")
-		model := searchFlags.String("model", "nomic", "Embedding model to use: nomic or qwen")
+		semantic := searchFlags.Bool("semantic", cfg.SearchMode == "semantic", "Use semantic search only")
+		hybrid := searchFlags.Float64("hybrid", cfg.defaultHybridWeight(), "Use hybrid search (0.0-1.0, where value is semantic weight)")
+		hybridMethod := searchFlags.String("hybrid-method", "linear", "How hybrid search merges rankings: linear (weighted scores) or rrf (reciprocal rank fusion)")
+		model := searchFlags.String("model", defaultModel, "Embedding model to use: nomic or qwen (ollama), or a provider model name")
+		after := searchFlags.String("after", "", "Only documents published on or after this date (YYYY-MM-DD)")
+		before := searchFlags.String("before", "", "Only documents published before this date (YYYY-MM-DD)")
+		updatedAfter := searchFlags.String("updated-after", "", "Only documents updated on or after this date (YYYY-MM-DD)")
+		updatedBefore := searchFlags.String("updated-before", "", "Only documents updated before this date (YYYY-MM-DD)")
+		author := searchFlags.String("author", "", "Only documents by this author (case-insensitive, partial names allowed)")
+		authorFuzziness := searchFlags.Int("author-fuzziness", 0, "Let -author words match names within this many typos (0-2, 0 = exact)")
+		topic := searchFlags.String("topic", "", "Only documents in these topics (comma-separated, exact names)")
+		embeddedAfter := searchFlags.String("embedded-after", "", "Semantic only: documents embedded within a duration (e.g. 24h) or since a date")
+		sameModel := searchFlags.Bool("same-model", false, "Semantic only: skip documents embedded with a different model than -model")
+		titleBoost := searchFlags.Float64("title-boost", search.DefaultTitleBoost, "Keyword/hybrid: how much more title matches score than content matches (>= 1)")
+		diversity := searchFlags.Float64("diversity", 0.0, "Semantic/hybrid only: 0.0-1.0, how strongly to demote near-duplicate results (MMR)")
+		minScore := searchFlags.Float64("min-score", 0.0, "Semantic/linear hybrid only: drop results scoring below this (e.g. 0.5 cosine similarity)")
+		rerankFlag := searchFlags.Bool("rerank", false, "Hybrid only: reorder candidates with a cross-encoder reranker (rerank_url in the config, default Cohere)")
+		sortOrder := searchFlags.String("sort", search.SortRelevance, "Result order: relevance, updated or published (newest first)")
+		field := searchFlags.String("field", "", "Keyword/hybrid: only match this field: title, content, comments or author (default: all)")
+		raw := searchFlags.Bool("raw", false, "Keyword/hybrid: use the full query syntax (+must -not field:value fuzzy~ boost^2) instead of matching the text literally")
+		includeArchived := searchFlags.Bool("include-archived", false, "Also search archived documents (marked [archived]; slower, for audits)")
+		hideDuplicates := searchFlags.Bool("hide-duplicates", false, "Leave out documents dedupe -mark flagged as copies of another")
+		explain := searchFlags.Bool("explain", false, "Show how each result's score was computed (Bleve scoring, cosine similarity or hybrid components)")
+		timeout := searchFlags.Duration("timeout", 0, "Give up on the search after this long, e.g. 10s (0 = no limit)")
+		offset := searchFlags.Int("offset", 0, "Number of results to skip (for paging)")
+		limit := searchFlags.Int("limit", 10, fmt.Sprintf("Maximum number of results (1-%d)", maxSearchLimit))
+		format := searchFlags.String("format", "list", "Output format: list or count-by-author")
+		jsonOutput := searchFlags.Bool("json", false, "Print results as a JSON array (same as -format=json)")
 
 		searchFlags.Parse(os.Args[commandIdx+1:])
 
@@ -79,37 +198,394 @@ func main() {
 			os.Exit(1)
 		}
 
+		if *jsonOutput {
+			*format = "json"
+		}
+		if *format != "list" && *format != "count-by-author" && *format != "json" {
+			fmt.Printf("Error: unknown format '%s'. Supported formats: list, count-by-author, json\n", *format)
+			os.Exit(1)
+		}
+
+		filter := &search.Filter{
+			PublishedAfter:  parseDateFlag("after", *after),
+			PublishedBefore: parseDateFlag("before", *before),
+			UpdatedAfter:    parseDateFlag("updated-after", *updatedAfter),
+			UpdatedBefore:   parseDateFlag("updated-before", *updatedBefore),
+			Author:          *author,
+			AuthorFuzziness: *authorFuzziness,
+			Topics:          splitList(*topic),
+			EmbeddedAfter:   parseSinceFlag("embedded-after", *embeddedAfter),
+		}
+		if *sameModel {
+			filter.EmbeddingModel, _ = resolveModel(*model)
+		}
+
+		if *authorFuzziness < 0 || *authorFuzziness > search.MaxAuthorFuzziness {
+			fmt.Printf("Error: -author-fuzziness must be between 0 and %d\n", search.MaxAuthorFuzziness)
+			os.Exit(1)
+		}
+
+		if *hybridMethod != "linear" && *hybridMethod != "rrf" {
+			fmt.Printf("Error: unknown hybrid method '%s'. Supported methods: linear, rrf\n", *hybridMethod)
+			os.Exit(1)
+		}
+		// RRF doesn't use a weight, so choosing it is enough to enable hybrid search
+		useHybrid := *hybrid > 0 || *hybridMethod == "rrf"
+
+		if !filter.EmbeddedAfter.IsZero() && !*semantic && !useHybrid {
+			fmt.Println("Error: -embedded-after requires -semantic or -hybrid")
+			os.Exit(1)
+		}
+		if *sameModel && !*semantic && !useHybrid {
+			fmt.Println("Error: -same-model requires -semantic or -hybrid")
+			os.Exit(1)
+		}
+
+		if *titleBoost < 1 {
+			fmt.Println("Error: -title-boost must be at least 1")
+			os.Exit(1)
+		}
+		if *diversity < 0 || *diversity > 1 {
+			fmt.Println("Error: -diversity must be between 0 and 1")
+			os.Exit(1)
+		}
+		if *diversity > 0 && !*semantic && !useHybrid {
+			fmt.Println("Error: -diversity requires -semantic or -hybrid")
+			os.Exit(1)
+		}
+		if *minScore < 0 {
+			fmt.Println("Error: -min-score must not be negative")
+			os.Exit(1)
+		}
+		if *minScore > 0 && !*semantic && !useHybrid {
+			fmt.Println("Error: -min-score requires -semantic or -hybrid")
+			os.Exit(1)
+		}
+		if *minScore > 0 && !*semantic && *hybridMethod == "rrf" {
+			fmt.Println("Error: -min-score can't be combined with -hybrid-method=rrf (fused scores are at most 2/61)")
+			os.Exit(1)
+		}
+		if *rerankFlag && (*semantic || !useHybrid) {
+			fmt.Println("Error: -rerank requires -hybrid or -hybrid-method=rrf")
+			os.Exit(1)
+		}
+		if *sortOrder != search.SortRelevance && *sortOrder != search.SortUpdated && *sortOrder != search.SortPublished {
+			fmt.Printf("Error: unknown sort '%s'. Supported orders: relevance, updated, published\n", *sortOrder)
+			os.Exit(1)
+		}
+		if err := search.CheckField(*field); err != nil {
+			fmt.Printf("Error: -field: %v\n", err)
+			os.Exit(1)
+		}
+		if *field != "" && *semantic {
+			fmt.Println("Error: -field requires keyword or hybrid search")
+			os.Exit(1)
+		}
+		if *timeout < 0 {
+			fmt.Println("Error: -timeout must not be negative")
+			os.Exit(1)
+		}
+
 		query := strings.Join(searchFlags.Args(), " ")
-		runSearch(query, *semantic, *hybrid, *model)
+		if *offset < 0 {
+			fmt.Println("Error: -offset must not be negative")
+			os.Exit(1)
+		}
+		if *limit < 1 || *limit > maxSearchLimit {
+			fmt.Printf("Error: -limit must be between 1 and %d\n", maxSearchLimit)
+			os.Exit(1)
+		}
+
+		opts := search.SearchOptions{
+			Limit:     *limit,
+			Offset:    *offset,
+			Filter:    filter,
+			Diversity: *diversity,
+			MinScore:  *minScore,
+			Sort:      *sortOrder,
+			RawQuery:  *raw,
+			Field:     *field,
+
+			IncludeArchived: *includeArchived,
+			HideDuplicates:  *hideDuplicates,
+			Explain:         *explain,
+		}
+		if *rerankFlag {
+			reranker, err := newReranker(cfg.RerankURL, cfg.RerankModel)
+			if err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+			opts.Reranker = reranker
+		}
+
+		runSearch(query, *semantic, *hybrid, *hybridMethod, *titleBoost, *model, opts, *format, *timeout)
 	case "serve":
 		// Parse serve flags
 		serveFlags := flag.NewFlagSet("serve", flag.ExitOnError)
-		port := serveFlags.String("port", "6893", "Port to listen on")
-		host := serveFlags.String("host", "localhost", "Host to bind to")
+		port := serveFlags.String("port", orDefault(cfg.Server.Port, "6893"), "Port to listen on")
+		host := serveFlags.String("host", orDefault(cfg.Server.Host, "localhost"), "Host to bind to")
+		metrics := serveFlags.Bool("metrics", false, "Expose Prometheus metrics on /metrics")
+		embeddingCache := serveFlags.Int("embedding-cache", web.DefaultEmbeddingCacheSize, "Query embeddings to cache for semantic/hybrid search (0 = off)")
+		resultCache := serveFlags.Int("result-cache", web.DefaultResultCacheSize, "Keyword result pages to cache until the index changes (0 = off)")
+		resultCacheTTL := serveFlags.Duration("result-cache-ttl", web.DefaultResultCacheTTL, "How long a cached keyword result page is reused")
+		syncInterval := serveFlags.Duration("sync-interval", 0, "Sync from Slab this often, e.g. 30m (needs a Slab token; 0 = only via POST /api/sync)")
 
 		serveFlags.Parse(os.Args[commandIdx+1:])
 
-		runServe(*host, *port)
+		if *embeddingCache < 0 {
+			fmt.Println("Error: -embedding-cache must not be negative")
+			os.Exit(1)
+		}
+		if *resultCache < 0 || *resultCacheTTL < 0 {
+			fmt.Println("Error: -result-cache and -result-cache-ttl must not be negative")
+			os.Exit(1)
+		}
+
+		if *syncInterval < 0 {
+			fmt.Println("Error: -sync-interval must not be negative")
+			os.Exit(1)
+		}
+		if *syncInterval > 0 && getToken() == "" {
+			fmt.Println("Error: -sync-interval requires SLAB_TOKEN or a ./token file")
+			os.Exit(1)
+		}
+
+		runServe(*host, *port, *metrics, *embeddingCache, *resultCache, *resultCacheTTL, *syncInterval)
 	case "embed":
 		// Parse embed flags
 		embedFlags := flag.NewFlagSet("embed", flag.ExitOnError)
 		startFrom := embedFlags.String("start-from", "", "Resume from document ID")
-		model := embedFlags.String("model", "nomic", "Embedding model to use: nomic or qwen")
+		model := embedFlags.String("model", defaultModel, "Embedding model to use: nomic or qwen (ollama), or a provider model name")
+		chunkSize := embedFlags.Int("chunk-size", 0, "Split documents into chunks of this many characters (0 = embed whole documents)")
+		chunkOverlap := embedFlags.Int("chunk-overlap", 200, "Characters shared between consecutive chunks")
+		batchSize := embedFlags.Int("batch-size", defaultEmbedBatchSize, "Documents embedded per request")
+		concurrency := embedFlags.Int("concurrency", 1, "Number of embedding requests in flight at once")
+		missing := embedFlags.Bool("missing", false, "Only embed documents without an embedding for -model's field (e.g. after syncing with the embedder down)")
+		resume := embedFlags.Bool("resume", false, "Resume from the checkpoint an interrupted run saved in the data dir")
+		since := embedFlags.Duration("since", 0, "Only embed documents updated in Slab within this window (e.g. 24h); 0 = all")
 
 		embedFlags.Parse(os.Args[commandIdx+1:])
 
-		runEmbed(*startFrom, *model)
+		if *chunkSize < 0 || *chunkOverlap < 0 || (*chunkSize > 0 && *chunkOverlap*2 > *chunkSize) {
+			fmt.Println("Error: -chunk-size and -chunk-overlap must not be negative, and overlap must be at most half the chunk size")
+			os.Exit(1)
+		}
+
+		if *batchSize < 1 || *concurrency < 1 {
+			fmt.Println("Error: -batch-size and -concurrency must be at least 1")
+			os.Exit(1)
+		}
+		if *missing && (*startFrom != "" || *resume) {
+			fmt.Println("Error: -missing can't be combined with -start-from or -resume (rerun -missing to resume)")
+			os.Exit(1)
+		}
+		if *resume && *startFrom != "" {
+			fmt.Println("Error: -resume and -start-from are mutually exclusive")
+			os.Exit(1)
+		}
+		if *since < 0 {
+			fmt.Println("Error: -since must not be negative")
+			os.Exit(1)
+		}
+		if *since > 0 && *resume {
+			fmt.Println("Error: -since can't be combined with -resume (rerun with the same -since instead)")
+			os.Exit(1)
+		}
+
+		runEmbed(*startFrom, *model, *chunkSize, *chunkOverlap, *batchSize, *concurrency, *missing, *resume, *since)
 	case "reindex":
-		runReindex()
+		// Parse reindex flags
+		reindexFlags := flag.NewFlagSet("reindex", flag.ExitOnError)
+		incremental := reindexFlags.Bool("incremental", false, "Only re-index documents changed since the last reindex, and drop deleted ones")
+
+		reindexFlags.Parse(os.Args[commandIdx+1:])
+
+		runReindex(*incremental)
 	case "stats":
 		runStats()
+	case "doctor":
+		// Parse doctor flags
+		doctorFlags := flag.NewFlagSet("doctor", flag.ExitOnError)
+		fix := doctorFlags.Bool("fix", false, "Index missing documents and delete orphaned index entries")
+
+		doctorFlags.Parse(os.Args[commandIdx+1:])
+
+		runDoctor(*fix)
+	case "version", "--version", "-version":
+		runVersion()
+	case "failures":
+		runFailures()
 	case "get-doc":
+		// Parse get-doc flags
+		getDocFlags := flag.NewFlagSet("get-doc", flag.ExitOnError)
+		jsonOutput := getDocFlags.Bool("json", false, "Print document metadata and content as JSON")
+
+		getDocFlags.Parse(os.Args[commandIdx+1:])
+
+		if getDocFlags.NArg() < 1 {
+			fmt.Println("Error: document ID required")
+			fmt.Println("Usage: slab-search [--data-dir=<dir>] get-doc [-json] <document-id>")
+			os.Exit(1)
+		}
+		runGetDoc(getDocFlags.Arg(0), *jsonOutput)
+	case "get-docs":
+		// Parse get-docs flags
+		getDocsFlags := flag.NewFlagSet("get-docs", flag.ExitOnError)
+		jsonOutput := getDocsFlags.Bool("json", false, "Print documents (and missing IDs) as JSON")
+
+		getDocsFlags.Parse(os.Args[commandIdx+1:])
+
+		ids := splitIDs(strings.Join(getDocsFlags.Args(), ","))
+		if len(ids) == 0 {
+			fmt.Println("Error: document IDs required")
+			fmt.Println("Usage: slab-search [--data-dir=<dir>] get-docs [-json] <id1,id2,...>")
+			os.Exit(1)
+		}
+		runGetDocs(ids, *jsonOutput)
+	case "search-in":
+		// Parse search-in flags
+		searchInFlags := flag.NewFlagSet("search-in", flag.ExitOnError)
+		n := searchInFlags.Int("n", 20, "Maximum passages to show (0 = all)")
+		jsonOutput := searchInFlags.Bool("json", false, "Print passages as JSON")
+
+		searchInFlags.Parse(os.Args[commandIdx+1:])
+
+		if searchInFlags.NArg() < 2 {
+			fmt.Println("Error: document ID and query required")
+			fmt.Println("Usage: slab-search [--data-dir=<dir>] search-in [-n=20] [-json] <document-id> <query>")
+			os.Exit(1)
+		}
+		if *n < 0 {
+			fmt.Println("Error: -n must not be negative")
+			os.Exit(1)
+		}
+		runSearchIn(searchInFlags.Arg(0), strings.Join(searchInFlags.Args()[1:], " "), *n, *jsonOutput)
+	case "recent":
+		// Parse recent flags
+		recentFlags := flag.NewFlagSet("recent", flag.ExitOnError)
+		n := recentFlags.Int("n", 20, fmt.Sprintf("Number of documents (1-%d)", maxSearchLimit))
+		jsonOutput := recentFlags.Bool("json", false, "Print documents as a JSON array")
+
+		recentFlags.Parse(os.Args[commandIdx+1:])
+
+		if *n < 1 || *n > maxSearchLimit {
+			fmt.Printf("Error: -n must be between 1 and %d\n", maxSearchLimit)
+			os.Exit(1)
+		}
+		runRecent(*n, *jsonOutput)
+	case "list-topic":
+		// Parse list-topic flags
+		listTopicFlags := flag.NewFlagSet("list-topic", flag.ExitOnError)
+		limit := listTopicFlags.Int("limit", 50, fmt.Sprintf("Maximum number of documents (1-%d)", maxSearchLimit))
+		offset := listTopicFlags.Int("offset", 0, "Number of documents to skip (for paging)")
+		jsonOutput := listTopicFlags.Bool("json", false, "Print documents as a JSON array")
+
+		listTopicFlags.Parse(os.Args[commandIdx+1:])
+
+		if listTopicFlags.NArg() < 1 {
+			fmt.Println("Error: topic name required")
+			fmt.Println("Usage: slab-search [--data-dir=<dir>] list-topic [-limit=n] [-offset=n] [-json] <topic>")
+			os.Exit(1)
+		}
+		if *limit < 1 || *limit > maxSearchLimit {
+			fmt.Printf("Error: -limit must be between 1 and %d\n", maxSearchLimit)
+			os.Exit(1)
+		}
+		if *offset < 0 {
+			fmt.Println("Error: -offset must not be negative")
+			os.Exit(1)
+		}
+		// Topic names may contain spaces; accept them unquoted
+		runListTopic(strings.Join(listTopicFlags.Args(), " "), *limit, *offset, *jsonOutput)
+	case "related":
+		// Parse related flags
+		relatedFlags := flag.NewFlagSet("related", flag.ExitOnError)
+		limit := relatedFlags.Int("limit", 5, fmt.Sprintf("Maximum number of documents (1-%d)", maxSearchLimit))
+		jsonOutput := relatedFlags.Bool("json", false, "Print documents as a JSON array")
+
+		relatedFlags.Parse(os.Args[commandIdx+1:])
+
+		if relatedFlags.NArg() != 1 {
+			fmt.Println("Error: document ID required")
+			fmt.Println("Usage: slab-search [--data-dir=<dir>] related [-limit=n] [-json] <document-id>")
+			os.Exit(1)
+		}
+		if *limit < 1 || *limit > maxSearchLimit {
+			fmt.Printf("Error: -limit must be between 1 and %d\n", maxSearchLimit)
+			os.Exit(1)
+		}
+		runRelated(relatedFlags.Arg(0), *limit, *jsonOutput)
+	case "compare-models":
+		// Parse compare-models flags
+		compareFlags := flag.NewFlagSet("compare-models", flag.ExitOnError)
+		limit := compareFlags.Int("limit", 10, fmt.Sprintf("Number of results per model (1-%d)", maxSearchLimit))
+		jsonOutput := compareFlags.Bool("json", false, "Print both rankings and the metrics as JSON")
+
+		compareFlags.Parse(os.Args[commandIdx+1:])
+
+		if compareFlags.NArg() < 1 {
+			fmt.Println("Error: search query required")
+			fmt.Println("Usage: slab-search [--data-dir=<dir>] compare-models [-limit=n] [-json] <query>")
+			os.Exit(1)
+		}
+		if *limit < 1 || *limit > maxSearchLimit {
+			fmt.Printf("Error: -limit must be between 1 and %d\n", maxSearchLimit)
+			os.Exit(1)
+		}
+		runCompareModels(strings.Join(compareFlags.Args(), " "), *limit, *jsonOutput)
+	case "dedupe":
+		// Parse dedupe flags
+		dedupeFlags := flag.NewFlagSet("dedupe", flag.ExitOnError)
+		threshold := dedupeFlags.Float64("threshold", search.DefaultDuplicateThreshold, "Cosine similarity at or above which documents count as duplicates (0-1)")
+		model := dedupeFlags.String("model", "nomic", "Embeddings to compare: nomic or qwen (ollama), or a provider model name")
+		mark := dedupeFlags.Bool("mark", false, "Mark all but each cluster's canonical document, replacing earlier marks, so search -hide-duplicates skips them")
+		jsonOutput := dedupeFlags.Bool("json", false, "Print the clusters as JSON")
+
+		dedupeFlags.Parse(os.Args[commandIdx+1:])
+
+		if *threshold <= 0 || *threshold > 1 {
+			fmt.Println("Error: -threshold must be greater than 0 and at most 1")
+			os.Exit(1)
+		}
+		runDedupe(*threshold, *model, *mark, *jsonOutput)
+	case "export":
+		// Parse export flags
+		exportFlags := flag.NewFlagSet("export", flag.ExitOnError)
+		output := exportFlags.String("o", "-", "File to write JSONL to (- for stdout)")
+		includeArchived := exportFlags.Bool("archived", false, "Include archived documents")
+		withEmbeddings := exportFlags.Bool("embeddings", false, "Include embeddings (base64)")
+
+		exportFlags.Parse(os.Args[commandIdx+1:])
+
+		runExport(*output, *includeArchived, *withEmbeddings)
+	case "import":
+		// Parse import flags
+		importFlags := flag.NewFlagSet("import", flag.ExitOnError)
+		input := importFlags.String("i", "-", "JSONL file written by export (- for stdin)")
+
+		importFlags.Parse(os.Args[commandIdx+1:])
+
+		runImport(*input)
+	case "backup":
+		// Parse backup flags
+		backupFlags := flag.NewFlagSet("backup", flag.ExitOnError)
+		force := backupFlags.Bool("force", false, "Replace the destination if it already exists")
+
+		backupFlags.Parse(os.Args[commandIdx+1:])
+
+		if backupFlags.NArg() < 1 {
+			fmt.Println("Error: backup destination required")
+			fmt.Println("Usage: slab-search [--data-dir=<dir>] backup [-force] <dest.db>")
+			os.Exit(1)
+		}
+		runBackup(backupFlags.Arg(0), *force)
+	case "delete-doc":
 		if len(os.Args) < commandIdx+2 {
 			fmt.Println("Error: document ID required")
-			fmt.Println("Usage: slab-search [--data-dir=<dir>] get-doc <document-id>")
+			fmt.Println("Usage: slab-search [--data-dir=<dir>] delete-doc <document-id>")
 			os.Exit(1)
 		}
-		runGetDoc(os.Args[commandIdx+1])
+		runDeleteDoc(os.Args[commandIdx+1])
 	default:
 		fmt.Printf("Unknown command: %s\n", command)
 		printUsage()
@@ -124,51 +600,176 @@ func printUsage() {
 	fmt.Println("  slab-search [global-flags] <command> [flags]")
 	fmt.Println()
 	fmt.Println("Global Flags:")
+	fmt.Println("  --config=<path>   Config file with flag defaults (default: ./slab-search.yaml, then")
+	fmt.Println("                    ~/.config/slab-search/slab-search.yaml); flags override it")
 	fmt.Println("  --data-dir=<dir>  Directory for database and index files (default: ./data)")
+	fmt.Println("  --db-path=<file>  Database file, to keep it outside the data dir (default: <data-dir>/slab.db)")
+	fmt.Println("  --index-path=<dir>  Search index directory, e.g. on a faster disk (default: <data-dir>/bleve)")
+	fmt.Println("  --embedding-provider=<name>  Embedding provider: ollama or openai (default: ollama)")
+	fmt.Println("                               openai reads its API key from OPENAI_API_KEY")
+	fmt.Println("  --embedding-url=<url>        Embedding API base URL (default depends on provider)")
+	fmt.Println("  --embed-timeout=<duration>   Per-request embedding timeout, e.g. 2m (default: 60s, 3m for qwen)")
+	fmt.Println("  --slab-url=<url>  Slab workspace to sync from (default: https://slab.render.com)")
+	fmt.Println("  --log-level=<level>  Log level: debug, info, warn or error (default: info)")
+	fmt.Println("  --log-format=<format>  Log format: text or json (default: text)")
+	fmt.Println("  --quiet           Only log warnings and errors (same as --log-level=warn)")
 	fmt.Println()
 	fmt.Println("Commands:")
-	fmt.Println("  sync                     Sync posts from Slab + generate embeddings (if Ollama running)")
+	fmt.Println("  sync                     Sync posts from Slab + generate embeddings (if provider available)")
 	fmt.Println("  search [flags] <query>   Search for documents")
 	fmt.Println("  serve [flags]            Start web server")
 	fmt.Println("  embed [flags]            Generate embeddings for all documents (expensive, ~8-12 min)")
-	fmt.Println("  reindex                  Rebuild Bleve keyword index (~10 seconds)")
+	fmt.Println("  reindex [-incremental]   Rebuild Bleve keyword index (~10 seconds; applies synonyms.json, stopwords.txt)")
+	fmt.Println("                           -incremental only re-indexes documents changed since the last reindex")
 	fmt.Println("  stats                    Show index statistics")
-	fmt.Println("  get-doc <id>             Retrieve document markdown by ID")
+	fmt.Println("  doctor [-fix]            Find documents missing from the index or orphaned in it (-fix repairs)")
+	fmt.Println("  version                  Show version, commit and build date (also --version)")
+	fmt.Println("  failures                 List posts that failed to export from Slab")
+	fmt.Println("  get-doc [-json] <id>     Retrieve document markdown (or metadata as JSON) by ID")
+	fmt.Println("  get-docs [-json] <id1,id2,...>  Retrieve several documents in one go (missing IDs are reported)")
+	fmt.Println("  search-in [flags] <id> <query>  Find the lines of one document that mention a query")
+	fmt.Println("  recent [-n=20] [-json]   List the most recently synced documents")
+	fmt.Println("  list-topic [flags] <topic>  List documents in a topic, most recently updated first")
+	fmt.Println("                           (-limit=n, default 50; -offset=n; -json)")
+	fmt.Println("  related [flags] <id>     List documents most similar to one, by embedding (-limit=n, default 5; -json)")
+	fmt.Println("  compare-models [flags] <query>  Semantic search with nomic and qwen side by side, with overlap")
+	fmt.Println("                           and rank correlation (-limit=n, default 10; -json)")
+	fmt.Println("  dedupe [flags]           Find clusters of near-identical documents by embedding similarity")
+	fmt.Println("                           (-threshold=0.97, -model=nomic; -mark flags copies; -json)")
+	fmt.Println("  delete-doc <id>          Remove a document from the database and search index")
+	fmt.Println("  export [flags]           Write all documents as JSONL (-o=<file>, -archived, -embeddings)")
+	fmt.Println("  import [-i=<file>]       Upsert documents from an export (then run reindex)")
+	fmt.Println("  backup [-force] <dest>   Snapshot the database to a single file (safe while serving)")
+	fmt.Println()
+	fmt.Println("Sync Flags:")
+	fmt.Println("  -since=<duration> Incremental sync: only posts updated after the last full sync minus duration")
+	fmt.Println("                    (e.g. 1h); unedited posts restored from archive need a full sync")
+	fmt.Println("  -concurrency=<n>  Number of posts to sync in parallel (default: 20)")
+	fmt.Println("  -rate-limit=<n>   Maximum Slab API requests per second (default: 0, unlimited)")
+	fmt.Println("  -dry-run          Report which posts would be added, updated or removed; write nothing")
+	fmt.Println("  -with-comments    Also fetch and index post comments (one extra API request per post)")
+	fmt.Println("  -topic=<topic>    Only sync the posts in this topic (ID or name); nothing is purged as deleted")
+	fmt.Println("  -no-embeddings    Don't generate embeddings, even if the embedding provider is running")
+	fmt.Println("  -reindex          Rebuild the keyword index from the database after syncing (slower, never drifts)")
+	fmt.Println("  -force            Sync even if the database was synced from another --slab-url (purges its documents)")
 	fmt.Println()
 	fmt.Println("Search Flags:")
 	fmt.Println("  -semantic         Use semantic search only (requires embeddings)")
 	fmt.Println("  -hybrid=<weight>  Use hybrid search (0.0-1.0 semantic weight, default keyword-only)")
-	fmt.Println("  -model=<model>    Embedding model to use: nomic or qwen (default: nomic)")
+	fmt.Println("  -hybrid-method=<method>  Hybrid merge: linear (weighted scores) or rrf (rank fusion, implies hybrid)")
+	fmt.Println("  -model=<model>    Embedding model: nomic or qwen (ollama), or a provider model name (default: nomic)")
+	fmt.Println("  -after=<date>     Only documents published on or after date (YYYY-MM-DD)")
+	fmt.Println("  -before=<date>    Only documents published before date (YYYY-MM-DD)")
+	fmt.Println("  -updated-after=<date>   Only documents updated on or after date")
+	fmt.Println("  -updated-before=<date>  Only documents updated before date")
+	fmt.Println("  -author=<name>    Only documents by author (case-insensitive, partial names allowed)")
+	fmt.Println("  -author-fuzziness=<n>  Let -author words match names within n typos, 0-2 (default: 0, exact)")
+	fmt.Println("  -topic=<names>    Only documents in these topics (comma-separated, exact names)")
+	fmt.Println("  -embedded-after=<when>  Semantic only: documents embedded within a duration (24h) or since a date")
+	fmt.Println("  -same-model       Semantic only: skip documents embedded with a different model than -model")
+	fmt.Println("                    (including ones embedded before models were recorded)")
+	fmt.Println("  -title-boost=<n>  Keyword/hybrid: title match weight relative to content (default: 3)")
+	fmt.Println("  -diversity=<0-1>  Semantic/hybrid only: demote near-duplicate results (MMR, default: 0)")
+	fmt.Println("  -min-score=<n>    Semantic/linear hybrid only: drop results scoring below n (default: 0, keep all)")
+	fmt.Println("  -rerank           Hybrid only: reorder candidates with a cross-encoder reranker (Cohere by")
+	fmt.Println("                    default, needs COHERE_API_KEY; or a local /rerank endpoint via rerank_url)")
+	fmt.Println("  -sort=<order>     relevance (default), updated or published (newest first; semantic/hybrid")
+	fmt.Println("                    re-sort their best 100 matches)")
+	fmt.Println("  -field=<name>     Keyword/hybrid: only match title, content, comments or author (default: all)")
+	fmt.Println("  -raw              Keyword/hybrid: full query syntax (+must -not field:value fuzzy~ boost^2)")
+	fmt.Println("                    instead of matching the text literally, apart from \"phrases\"")
+	fmt.Println("  -include-archived Also search archived documents, marked [archived] (slower)")
+	fmt.Println("  -hide-duplicates  Leave out documents marked as copies of another by dedupe -mark")
+	fmt.Println("  -explain          Show how each score was computed: Bleve's breakdown (keyword), cosine")
+	fmt.Println("                    similarity (semantic) or the weighted components (hybrid)")
+	fmt.Println("  -timeout=<duration>  Give up on the search after this long, e.g. 10s (default: no limit)")
+	fmt.Println("  -offset=<n>       Skip the first n results (for paging)")
+	fmt.Println("  -limit=<n>        Maximum number of results, 1-100 (default: 10)")
+	fmt.Println("  -format=<format>  Output format: list, count-by-author or json (default: list)")
+	fmt.Println("  -json             Print results as a JSON array (same as -format=json)")
 	fmt.Println()
 	fmt.Println("Serve Flags:")
 	fmt.Println("  -host=<host>      Host to bind to (default: localhost)")
 	fmt.Println("  -port=<port>      Port to listen on (default: 6893)")
+	fmt.Println("  -metrics          Expose Prometheus metrics on /metrics")
+	fmt.Println("  -embedding-cache=<n>  Query embeddings to cache for semantic/hybrid search (default: 256, 0 = off)")
+	fmt.Println("  -result-cache=<n>     Keyword result pages to cache until the index changes (default: 512, 0 = off)")
+	fmt.Println("  -result-cache-ttl=<duration>  How long a cached keyword result page is reused (default: 5m)")
+	fmt.Println("  -sync-interval=<duration>  Sync from Slab this often, e.g. 30m (needs a Slab token; default: off)")
 	fmt.Println()
 	fmt.Println("Embed Flags:")
+	fmt.Println("  -resume           Resume from the checkpoint an interrupted run saved in the data dir")
 	fmt.Println("  -start-from=<id>  Resume from document ID (e.g., after interruption)")
-	fmt.Println("  -model=<model>    Embedding model to use: nomic or qwen (default: nomic)")
+	fmt.Println("  -missing          Only embed documents without an embedding from -model's field")
+	fmt.Println("  -since=<duration> Only embed documents updated within the window, e.g. 24h (combines with -missing)")
+	fmt.Println("  -chunk-size=<n>   Embed documents in chunks of n characters (default: 0, whole documents)")
+	fmt.Println("  -chunk-overlap=<n>  Characters shared between consecutive chunks (default: 200)")
+	fmt.Println("  -batch-size=<n>   Documents embedded per request (default: 16)")
+	fmt.Println("  -concurrency=<n>  Embedding requests in flight at once (default: 1)")
+	fmt.Println("  -model=<model>    Embedding model: nomic or qwen (ollama), or a provider model name (default: nomic)")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  slab-search sync")
+	fmt.Println("  slab-search sync -since=1h                       # Quick top-up of recently updated posts")
+	fmt.Println("  slab-search sync -dry-run                        # Preview a sync without changing anything")
+	fmt.Println("  slab-search sync -topic=Runbooks                 # Refresh just one topic's posts")
 	fmt.Println("  slab-search search kubernetes                    # Keyword search")
 	fmt.Println("  slab-search search \"postgres config\"              # Phrase search")
-	fmt.Println("  slab-search search 'deploy~'                     # Fuzzy search")
+	fmt.Println("  slab-search search -raw 'deploy~'                # Fuzzy search")
 	fmt.Println("  slab-search search -semantic \"database scaling\"  # Semantic search only")
 	fmt.Println("  slab-search search -hybrid=0.3 kubernetes        # Hybrid (70% keyword, 30% semantic)")
+	fmt.Println("  slab-search search -hybrid-method=rrf kubernetes # Hybrid with reciprocal rank fusion")
+	fmt.Println("  slab-search search -hybrid=0.3 -rerank \"rotate db credentials\"  # Rerank hybrid candidates")
+	fmt.Println("  slab-search search -semantic -diversity=0.5 onboarding  # Fewer near-duplicate docs")
 	fmt.Println("  slab-search search -semantic -model=qwen \"k8s\"   # Semantic search with Qwen model")
+	fmt.Println("  slab-search search -updated-after=2024-01-01 runbook  # Only recently updated docs")
+	fmt.Println("  slab-search search -format=count-by-author deprecated # Who owns matching docs")
+	fmt.Println("  slab-search search -json kubernetes | jq '.[].slab_url'  # Script-friendly output")
+	fmt.Println("  slab-search search -author=\"Jane Doe\" kubernetes   # Only docs by Jane Doe")
+	fmt.Println("  slab-search search -semantic -model=qwen -embedded-after=2h \"k8s\"  # Only freshly embedded docs")
+	fmt.Println("  slab-search search -semantic -same-model \"k8s\"                 # Ignore vectors from other models")
+	fmt.Println("  slab-search search -hybrid=0.3 -explain kubernetes  # Why each result ranked where it did")
+	fmt.Println("  slab-search search-in abc123 \"failover\"           # Locate passages in a long document")
 	fmt.Println("  slab-search serve                                # Start web server on http://localhost:6893")
 	fmt.Println("  slab-search serve -port=3000                     # Start on custom port")
+	fmt.Println("  slab-search serve -metrics                       # Also expose Prometheus metrics on /metrics")
 	fmt.Println("  slab-search embed                                # Generate embeddings with nomic-embed-text")
 	fmt.Println("  slab-search embed -model=qwen                    # Generate embeddings with qwen3-embedding")
+	fmt.Println("  slab-search compare-models \"database scaling\"   # nomic vs qwen rankings side by side")
+	fmt.Println("  slab-search dedupe -threshold=0.98 -mark         # Flag copies so search -hide-duplicates skips them")
+	fmt.Println("  slab-search embed -chunk-size=1500               # Embed long documents in overlapping chunks")
+	fmt.Println("  slab-search embed -resume                        # Resume an interrupted run from its checkpoint")
+	fmt.Println("  slab-search embed -since=24h                     # Re-embed documents updated in the last day")
 	fmt.Println("  slab-search embed -start-from=abc123             # Resume from specific document ID")
 	fmt.Println("  slab-search reindex                              # Rebuild Bleve index (fast)")
+	fmt.Println("  slab-search export -embeddings -o corpus.jsonl   # Back up the corpus with embeddings")
+	fmt.Println("  slab-search import -i corpus.jsonl               # Load it on another machine")
+	fmt.Println("  slab-search backup backups/slab.db               # Consistent database snapshot")
 	fmt.Println()
 	fmt.Println("Using custom data directory:")
 	fmt.Println("  slab-search --data-dir=/path/to/data search kubernetes")
 	fmt.Println("  slab-search --data-dir=$HOME/.slab-search serve")
+	fmt.Println()
+	fmt.Println("Config file (slab-search.yaml; every key optional):")
+	fmt.Println("  data_dir: /path/to/data")
+	fmt.Println("  slab_url: https://myteam.slab.com  # Slab workspace to sync from")
+	fmt.Println("  embedding_provider: openai")
+	fmt.Println("  embedding_url: https://api.openai.com")
+	fmt.Println("  model: text-embedding-3-small     # Default -model for search and embed")
+	fmt.Println("  search_mode: hybrid               # keyword, semantic or hybrid")
+	fmt.Println("  hybrid_weight: 0.3                # Semantic weight when search_mode is hybrid")
+	fmt.Println("  rerank_url: http://localhost:7997/rerank  # search -rerank endpoint (default: Cohere)")
+	fmt.Println("  rerank_model: BAAI/bge-reranker-base")
+	fmt.Println("  server:")
+	fmt.Println("    host: 0.0.0.0")
+	fmt.Println("    port: \"6893\"")
+	fmt.Println()
+	fmt.Println("Using OpenAI embeddings:")
+	fmt.Println("  OPENAI_API_KEY=... slab-search --embedding-provider=openai embed")
+	fmt.Println("  OPENAI_API_KEY=... slab-search --embedding-provider=openai search -semantic \"k8s\"")
 }
 
-func runSync() {
+func runSync(since time.Duration, concurrency int, rateLimit float64, topic string, dryRun, withComments, noEmbeddings, reindex, force, jsonOutput bool) {
 	// Read token from file or env
 	token := getToken()
 	if token == "" {
@@ -176,12 +777,16 @@ func runSync() {
 	}
 
 	// Ensure data directory exists
-	if err := os.MkdirAll(dataDir, 0755); err != nil {
+	if err := ensureDataDirs(); err != nil {
 		log.Fatalf("Error creating data directory: %v", err)
 	}
 
 	// Initialize components
-	slabClient := slab.NewClient(token)
+	slabOpts := []slab.Option{slab.WithBaseURL(slabURL)}
+	if rateLimit > 0 {
+		slabOpts = append(slabOpts, slab.WithRateLimit(rateLimit, max(int(rateLimit), 1)))
+	}
+	slabClient := slab.NewClient(token, slabOpts...)
 
 	db, err := storage.Open(dbPath)
 	if err != nil {
@@ -189,62 +794,173 @@ func runSync() {
 	}
 	defer db.Close()
 
-	idx, err := search.Open(indexPath)
-	if err != nil {
-		log.Fatalf("Error opening search index: %v", err)
+	// A dry run writes nothing, so it only checks the workspace
+	if err := checkWorkspace(db, force, !dryRun); err != nil {
+		log.Fatalf("Error: %v", err)
 	}
-	defer idx.Close()
 
-	// Try to initialize embeddings client (optional - graceful degradation)
-	var embedder *embeddings.Client
-	embedder = embeddings.NewClient(ollamaURL, ollamaModel)
-	if err := embedder.Health(); err != nil {
-		log.Printf("Warning: Ollama not available (%v), skipping embedding generation", err)
-		log.Printf("To enable semantic search, install Ollama and run: ollama pull %s", ollamaModel)
-		embedder = nil // Disable embeddings
-	} else {
-		log.Printf("✓ Ollama available, will generate embeddings with %s", ollamaModel)
+	// A dry run only compares posts with the database, so it needs neither
+	// the index nor an embedder
+	var idx *search.Index
+	var embedder embeddings.Embedder
+	if !dryRun {
+		idx, err = search.Open(indexPath)
+		if err != nil {
+			log.Fatalf("Error opening search index: %v", err)
+		}
+		defer idx.Close()
+
+		// Try to initialize embeddings client (optional - graceful degradation),
+		// unless they're turned off, which also skips the provider check
+		modelName := embeddings.GetDefaultModel(embeddingProvider)
+		if !noEmbeddings {
+			embedder, err = newEmbedder(modelName)
+			if err != nil {
+				slog.Warn("Embeddings not available, skipping embedding generation", "error", err)
+				printEmbedderHint(modelName)
+				embedder = nil // Disable embeddings
+			} else {
+				slog.Info("Embedding provider available, will generate embeddings", "provider", embeddingProvider, "model", modelName)
+			}
+		}
 	}
 
 	// Create sync worker (0 = unlimited)
 	worker := sync.NewWorker(slabClient, db, idx, embedder, 0)
+	worker.SetConcurrency(concurrency)
+	worker.SetDryRun(dryRun)
+	worker.SetWithComments(withComments)
+	worker.SetTopic(topic)
+	if noEmbeddings {
+		worker.DisableEmbeddings()
+	}
+	worker.SetProgressFn(logSyncProgress(embedder != nil))
+
+	// Incremental sync: only posts updated since the last sync of every
+	// topic, with some slack for clock skew (topic syncs don't move the
+	// cutoff, or changes elsewhere would be skipped)
+	if since > 0 {
+		lastSync, err := db.LastFullSync()
+		if err != nil {
+			log.Fatalf("Error reading last sync time: %v", err)
+		}
+		if lastSync.IsZero() {
+			slog.Info("No previous full sync recorded, running a full sync")
+		} else {
+			cutoff := lastSync.Add(-since)
+			slog.Info("Incremental sync", "updated_since", cutoff.Format(time.RFC3339))
+			worker.SetUpdatedSince(cutoff)
+		}
+	}
 
-	// Run sync
-	ctx := context.Background()
+	// Run sync (Ctrl-C cancels in-flight requests)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 	stats, err := worker.Sync(ctx)
 	if err != nil {
 		log.Fatalf("Error syncing: %v", err)
 	}
 
+	// A full rebuild guarantees the keyword index matches the database, even
+	// if indexing some posts failed during the sync
+	if reindex {
+		slog.Info("Rebuilding keyword index")
+		start := time.Now()
+		logf := func(format string, args ...any) (int, error) {
+			slog.Info(strings.TrimSpace(fmt.Sprintf(format, args...)))
+			return 0, nil
+		}
+		if err := loadAnalyzerFiles(idx, logf); err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		if err := idx.Rebuild(db, nil); err != nil {
+			log.Fatalf("Error rebuilding index: %v", err)
+		}
+		stats.ReindexDuration = time.Since(start)
+		slog.Info("Rebuilt keyword index", "duration", stats.ReindexDuration)
+	}
+
+	if jsonOutput {
+		printJSON(stats)
+		return
+	}
+
+	if dryRun {
+		fmt.Println()
+		fmt.Println("=== Sync Dry Run (nothing written) ===")
+		if stats.Topic != "" {
+			fmt.Printf("Topic:         %s\n", stats.Topic)
+		}
+		fmt.Printf("Total posts:   %d\n", stats.TotalPosts)
+		fmt.Printf("Would add:     %d\n", stats.NewPosts)
+		fmt.Printf("Would update:  %d\n", stats.UpdatedPosts)
+		fmt.Printf("Unchanged:     %d\n", stats.SkippedPosts)
+		fmt.Printf("Archived:      %d (would be removed from search)\n", stats.ArchivedRemoved)
+		fmt.Printf("Deleted:       %d (would be purged)\n", stats.DeletedPosts)
+		fmt.Printf("Errors:        %d\n", stats.Errors)
+		fmt.Printf("Duration:      %v\n", stats.Duration)
+		return
+	}
+
 	// Print summary
 	fmt.Println()
 	fmt.Println("=== Sync Complete ===")
+	if stats.Topic != "" {
+		fmt.Printf("Topic:         %s\n", stats.Topic)
+	}
 	fmt.Printf("Total posts:   %d\n", stats.TotalPosts)
 	fmt.Printf("New:           %d\n", stats.NewPosts)
 	fmt.Printf("Updated:       %d\n", stats.UpdatedPosts)
 	fmt.Printf("Skipped:       %d\n", stats.SkippedPosts)
-	if embedder != nil {
+	fmt.Printf("Deleted:       %d\n", stats.DeletedPosts)
+	if withComments {
+		fmt.Printf("New comments:  %d unchanged posts re-indexed\n", stats.CommentsUpdated)
+	}
+	switch stats.EmbeddingsSkipped {
+	case sync.EmbeddingsDisabled:
+		fmt.Println("Embeddings:    skipped (-no-embeddings); run 'slab-search embed -missing' to catch up")
+	case sync.EmbeddingsUnavailable:
+		fmt.Println("Embeddings:    none (embedding provider unavailable)")
+	default:
 		fmt.Printf("Embeddings:    %d generated, %d failed\n", stats.EmbeddingsGen, stats.EmbeddingsFailed)
 	}
 	fmt.Printf("Errors:        %d\n", stats.Errors)
 	fmt.Printf("Duration:      %v\n", stats.Duration)
+	if reindex {
+		fmt.Printf("Reindex:       %v (keyword index rebuilt)\n", stats.ReindexDuration)
+	}
 }
 
-func runSearch(query string, semanticOnly bool, hybridWeight float64, modelName string) {
-	// Determine which model and embedding field to use
-	var ollamaModelName string
-	var useQwenField bool
-
-	switch modelName {
-	case "nomic":
-		ollamaModelName = "nomic-embed-text"
-		useQwenField = false
-	case "qwen":
-		ollamaModelName = "qwen3-embedding"
-		useQwenField = true
-	default:
-		log.Fatalf("Error: Unknown model '%s'. Supported models: nomic, qwen", modelName)
+// checkWorkspace refuses to sync a database first synced from another Slab
+// workspace than --slab-url, since a sync purges the documents missing from
+// the workspace it syncs. With force, it's synced anyway. With record, the
+// first (or forced) sync's workspace is stored for later checks.
+func checkWorkspace(db *storage.DB, force, record bool) error {
+	synced, err := db.WorkspaceURL()
+	if err != nil {
+		return fmt.Errorf("read workspace URL: %w", err)
+	}
+	if synced == slabURL {
+		return nil
 	}
+	if synced != "" && !force {
+		return fmt.Errorf("the database was synced from %s, not %s (use a separate --data-dir, or sync -force to replace its documents)", synced, slabURL)
+	}
+	if synced != "" {
+		slog.Warn("Syncing another workspace, documents from the previous one will be purged", "previous", synced, "workspace", slabURL)
+	}
+	if !record {
+		return nil
+	}
+	if err := db.SetWorkspaceURL(slabURL); err != nil {
+		return fmt.Errorf("record workspace URL: %w", err)
+	}
+	return nil
+}
+
+func runSearch(query string, semanticOnly bool, hybridWeight float64, hybridMethod string, titleBoost float64, modelName string, opts search.SearchOptions, format string, timeout time.Duration) {
+	// Determine which model and embedding field to use
+	providerModel, useQwenField := resolveModel(modelName)
 
 	// Open database
 	db, err := storage.Open(dbPath)
@@ -253,41 +969,81 @@ func runSearch(query string, semanticOnly bool, hybridWeight float64, modelName
 	}
 	defer db.Close()
 
-	// Open search index
+	keywordOnly := !semanticOnly && hybridWeight == 0 && hybridMethod != "rrf"
+
+	// Open search index; keyword searches can fall back to SQLite full-text
+	// search if it's unusable (idx stays nil)
 	idx, err := search.Open(indexPath)
 	if err != nil {
-		log.Fatalf("Error opening search index: %v", err)
+		// Full-text search only covers live documents
+		if !keywordOnly || opts.IncludeArchived || opts.HideDuplicates || opts.Explain || !db.HasFTS() {
+			log.Fatalf("Error opening search index: %v", err)
+		}
+		slog.Warn("Search index unavailable; falling back to SQLite full-text search. Run 'slab-search reindex' to rebuild it.", "error", err)
+	} else {
+		defer idx.Close()
+
+		// Set DB reference for semantic search
+		idx.SetDB(db)
+		idx.SetTitleBoost(titleBoost)
+		idx.SetTopicBoosts(topicBoosts)
 	}
-	defer idx.Close()
 
-	// Set DB reference for semantic search
-	idx.SetDB(db)
+	// JSON output must be the only thing on stdout
+	status := func(msg string, args ...any) {
+		if format != "json" {
+			fmt.Printf(msg, args...)
+		}
+	}
+
+	// Ctrl-C or -timeout stops a long search (large corpus semantic scans)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 
-	var results []*search.SearchResult
+	var page *search.SearchResults
 
 	// Determine search mode
-	if semanticOnly || hybridWeight > 0 {
+	if idx == nil {
+		status("Using SQLite full-text search (fallback)...\n")
+		page, err = search.SearchFTS(db, query, opts)
+		if err != nil {
+			log.Fatalf("Error searching: %v", err)
+		}
+	} else if !keywordOnly {
 		// Initialize embeddings client for semantic/hybrid search
-		embedder := embeddings.NewClient(ollamaURL, ollamaModelName)
-		if err := embedder.Health(); err != nil {
-			log.Fatalf("Error: Semantic search requires Ollama. Please install and run: ollama pull %s", ollamaModelName)
+		embedder, err := newEmbedder(providerModel)
+		if err != nil {
+			printEmbedderHint(providerModel)
+			log.Fatalf("Error: Semantic search requires embeddings: %v", err)
 		}
 
 		// Generate query embedding
-		queryEmbedding, err := embedder.Embed(query)
+		queryEmbedding, err := embedder.Embed(ctx, query)
 		if err != nil {
 			log.Fatalf("Error generating query embedding: %v", err)
 		}
 
 		if semanticOnly {
 			// Pure semantic search
-			fmt.Printf("Using semantic search with %s model...\n", modelName)
-			results, err = idx.SemanticSearch(queryEmbedding, 10, useQwenField)
+			status("Using semantic search with %s model...\n", providerModel)
+			page, err = idx.SemanticSearch(ctx, query, queryEmbedding, useQwenField, opts)
+		} else if hybridMethod == "rrf" {
+			// Hybrid search merged by rank
+			status("Using hybrid search (reciprocal rank fusion) with %s model...\n", providerModel)
+			page, err = idx.HybridSearchRRF(ctx, query, queryEmbedding, useQwenField, opts)
 		} else {
 			// Hybrid search
-			fmt.Printf("Using hybrid search (%.0f%% keyword, %.0f%% semantic) with %s model...\n",
-				(1-hybridWeight)*100, hybridWeight*100, modelName)
-			results, err = idx.HybridSearch(query, queryEmbedding, 10, 1-hybridWeight, useQwenField)
+			status("Using hybrid search (%.0f%% keyword, %.0f%% semantic) with %s model...\n",
+				(1-hybridWeight)*100, hybridWeight*100, providerModel)
+			page, err = idx.HybridSearch(ctx, query, queryEmbedding, 1-hybridWeight, useQwenField, opts)
+		}
+		if err == nil && opts.Reranker != nil {
+			status("Reranked candidates with a cross-encoder\n")
 		}
 
 		if err != nil {
@@ -295,37 +1051,121 @@ func runSearch(query string, semanticOnly bool, hybridWeight float64, modelName
 		}
 	} else {
 		// Pure keyword search (default)
-		fmt.Println("Using keyword search...")
-		results, err = idx.Search(query, 10)
+		status("Using keyword search...\n")
+		page, err = idx.Search(ctx, query, opts)
 		if err != nil {
 			log.Fatalf("Error searching: %v", err)
 		}
 	}
 
 	// Display results
+	results := page.Hits
+	if format == "json" {
+		if results == nil {
+			results = []*search.SearchResult{} // Print [] rather than null
+		}
+		printJSON(results)
+		return
+	}
+
+	if len(results) == 0 && opts.MinScore > 0 {
+		fmt.Printf("No sufficiently relevant results (none scored at least %g)\n", opts.MinScore)
+		return
+	}
+
 	if len(results) == 0 {
 		fmt.Println("No results found")
+		if idx == nil {
+			return
+		}
+		if suggestion, err := idx.Suggest(query); err != nil {
+			slog.Warn("Failed to build spelling suggestion", "error", err)
+		} else if suggestion != "" {
+			fmt.Printf("Did you mean: %s?\n", suggestion)
+		}
+		return
+	}
+
+	if format == "count-by-author" {
+		printAuthorCounts(results, page.TotalText())
 		return
 	}
 
-	fmt.Printf("\nFound %d results:\n\n", len(results))
+	fmt.Printf("\nShowing %d-%d of %s results:\n\n", opts.Offset+1, opts.Offset+len(results), page.TotalText())
 
 	for i, result := range results {
-		fmt.Printf("%d. %s\n", i+1, result.Title)
+		if result.Archived {
+			fmt.Printf("%d. %s [archived]\n", opts.Offset+i+1, markedTitle(result))
+		} else {
+			fmt.Printf("%d. %s\n", opts.Offset+i+1, markedTitle(result))
+		}
 		if result.Author != "" {
 			fmt.Printf("   Author: %s\n", result.Author)
 		}
+		if len(result.Topics) > 0 {
+			fmt.Printf("   Topics: %s\n", strings.Join(result.Topics, ", "))
+		}
 		fmt.Printf("   URL: %s\n", result.SlabURL)
 		fmt.Printf("   Score: %.3f\n", result.Score)
+		if result.Explanation != nil {
+			fmt.Println("   Explanation:")
+			for _, line := range strings.Split(strings.TrimSuffix(result.Explanation.String(), "\n"), "\n") {
+				fmt.Printf("     %s\n", line)
+			}
+		}
 
-		// Show content snippets if available (keyword search only)
+		// Show content snippets if available (keyword or semantic highlights)
 		if snippets, ok := result.Fragments["Content"]; ok && len(snippets) > 0 {
 			fmt.Printf("   Preview: %s\n", snippets[0])
+		} else if snippets, ok := result.Fragments["Comments"]; ok && len(snippets) > 0 {
+			fmt.Printf("   Comment: %s\n", snippets[0])
 		}
 		fmt.Println()
 	}
 }
 
+// markedTitle is a result's title with the words the query matched in
+// **bold** markdown, since the terminal can't show <mark>
+func markedTitle(result *search.SearchResult) string {
+	return terminalMarkup(result.HighlightedTitle())
+}
+
+// terminalMarkup turns HTML-escaped text with <mark> highlights into plain
+// text with the highlights in **bold** markdown
+func terminalMarkup(highlighted string) string {
+	marked := strings.NewReplacer("<mark>", "**", "</mark>", "**").Replace(highlighted)
+	return html.UnescapeString(marked)
+}
+
+// printAuthorCounts prints the result set grouped by author, most results first
+// Only the returned page is aggregated; total is shown for context
+func printAuthorCounts(results []*search.SearchResult, total string) {
+	counts := make(map[string]int)
+	for _, result := range results {
+		author := result.Author
+		if author == "" {
+			author = "(unknown)"
+		}
+		counts[author]++
+	}
+
+	authors := make([]string, 0, len(counts))
+	for author := range counts {
+		authors = append(authors, author)
+	}
+	sort.Slice(authors, func(i, j int) bool {
+		if counts[authors[i]] != counts[authors[j]] {
+			return counts[authors[i]] > counts[authors[j]]
+		}
+		return authors[i] < authors[j]
+	})
+
+	fmt.Printf("\n%d of %s results by %d authors:\n\n", len(results), total, len(authors))
+	for _, author := range authors {
+		fmt.Printf("%5d  %s\n", counts[author], author)
+	}
+}
+
 func runStats() {
 	// Open database
 	db, err := storage.Open(dbPath)
@@ -352,14 +1192,195 @@ func runStats() {
 		log.Fatalf("Error getting index count: %v", err)
 	}
 
+	embeddingStats, err := db.EmbeddingStats()
+	if err != nil {
+		log.Fatalf("Error getting embedding stats: %v", err)
+	}
+
+	contentStats, err := db.ContentStats(longDocumentChars)
+	if err != nil {
+		log.Fatalf("Error getting content stats: %v", err)
+	}
+
 	fmt.Println("=== Index Statistics ===")
+	fmt.Printf("Database path:         %s\n", dbPath)
+	fmt.Printf("Index path:            %s\n", indexPath)
 	fmt.Printf("Documents in database: %d\n", dbCount)
 	fmt.Printf("Documents in index:    %d\n", indexCount)
-}
 
-func runGetDoc(docID string) {
-	// Open database
-	db, err := storage.Open(dbPath)
+	// Mixed dimensions within a field mean part of the corpus can't be
+	// compared with queries and is skipped by semantic search
+	fmt.Println()
+	fmt.Println("=== Embeddings ===")
+	if len(embeddingStats) == 0 {
+		fmt.Println("No embeddings (run: slab-search embed)")
+	}
+	for _, st := range embeddingStats {
+		model := st.Model
+		if model == "" {
+			model = "(unknown model)"
+		}
+		fmt.Printf("%-15s %-25s %5d dims  %6d docs (%.1f%%)\n",
+			st.Field, model, st.Dimensions, st.Count, percent(st.Count, dbCount))
+	}
+
+	// Long documents are truncated by the embedding model's context window
+	// unless embedded in chunks
+	if contentStats.Documents > 0 {
+		fmt.Println()
+		fmt.Println("=== Content ===")
+		fmt.Printf("Total length: %d chars (~%d tokens)\n",
+			contentStats.TotalLength, storage.EstimateTokens(contentStats.TotalLength))
+		mean := contentStats.TotalLength / contentStats.Documents
+		fmt.Printf("Mean length:  %d chars (~%d tokens)\n", mean, storage.EstimateTokens(mean))
+		fmt.Printf("Longest:      %d chars (~%d tokens) %q\n",
+			contentStats.MaxLength, storage.EstimateTokens(contentStats.MaxLength), contentStats.Longest)
+		fmt.Printf("Over %d chars: %d docs (%.1f%%)\n",
+			longDocumentChars, contentStats.Over, percent(contentStats.Over, contentStats.Documents))
+		if contentStats.Over > 0 {
+			fmt.Println("Long documents embed best in chunks (embed -chunk-size=1500)")
+		}
+	}
+}
+
+// longDocumentChars is the content length stats flags as long: about 2,000
+// tokens, Ollama's default context window
+const longDocumentChars = 8000
+
+// percent returns n as a percentage of total (0 if total is 0)
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
+
+// runDoctor compares the documents in the database with those in the search
+// index and reports drift (e.g. after a crash mid-sync); with fix, it indexes
+// missing documents and deletes orphaned index entries. Exits 1 if unrepaired
+// drift was found, so it can run from cron or CI.
+func runDoctor(fix bool) {
+	// Open database
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+
+	// Open search index
+	idx, err := search.Open(indexPath)
+	if err != nil {
+		log.Fatalf("Error opening search index: %v", err)
+	}
+	defer idx.Close()
+
+	// The index holds only non-archived documents
+	docs, err := db.List(false)
+	if err != nil {
+		log.Fatalf("Error listing documents: %v", err)
+	}
+	indexIDs, err := idx.AllIDs()
+	if err != nil {
+		log.Fatalf("Error listing index documents: %v", err)
+	}
+
+	indexed := make(map[string]bool, len(indexIDs))
+	for _, id := range indexIDs {
+		indexed[id] = true
+	}
+	stored := make(map[string]bool, len(docs))
+	var missing []*storage.Document
+	for _, doc := range docs {
+		stored[doc.ID] = true
+		if !indexed[doc.ID] {
+			missing = append(missing, doc)
+		}
+	}
+	var orphaned []string
+	for _, id := range indexIDs {
+		if !stored[id] {
+			orphaned = append(orphaned, id)
+		}
+	}
+	sort.Slice(missing, func(a, b int) bool { return missing[a].ID < missing[b].ID })
+
+	fmt.Println("=== Index Doctor ===")
+	fmt.Printf("Documents in database: %d\n", len(docs))
+	fmt.Printf("Documents in index:    %d\n", len(indexIDs))
+
+	if len(missing) == 0 && len(orphaned) == 0 {
+		fmt.Println()
+		fmt.Println("✓ Index and database agree")
+		return
+	}
+
+	if len(missing) > 0 {
+		fmt.Printf("\nMissing from index (%d):\n", len(missing))
+		for _, doc := range missing {
+			fmt.Printf("  %s  %s\n", doc.ID, doc.Title)
+		}
+	}
+	if len(orphaned) > 0 {
+		fmt.Printf("\nOrphaned in index, not in database or archived (%d):\n", len(orphaned))
+		for _, id := range orphaned {
+			fmt.Printf("  %s\n", id)
+		}
+	}
+
+	if !fix {
+		fmt.Println()
+		fmt.Println("Run 'slab-search doctor -fix' to repair, or 'slab-search reindex' to rebuild")
+		os.Exit(1)
+	}
+
+	fmt.Println()
+	for _, doc := range missing {
+		if err := idx.IndexDocument(search.NewIndexedDocument(doc)); err != nil {
+			log.Fatalf("Error indexing %s: %v", doc.ID, err)
+		}
+	}
+	for _, id := range orphaned {
+		if err := idx.Delete(id); err != nil {
+			log.Fatalf("Error deleting %s from index: %v", id, err)
+		}
+	}
+	fmt.Printf("✓ Indexed %d missing documents, deleted %d orphaned entries\n", len(missing), len(orphaned))
+}
+
+func runFailures() {
+	// Open database
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+
+	failures, err := db.ListSyncFailures()
+	if err != nil {
+		log.Fatalf("Error listing sync failures: %v", err)
+	}
+
+	if len(failures) == 0 {
+		fmt.Println("No sync failures recorded")
+		return
+	}
+
+	fmt.Printf("=== Sync Failures (%d) ===\n\n", len(failures))
+	for _, f := range failures {
+		fmt.Printf("%s  %s\n", f.PostID, f.Title)
+		if f.StatusCode != 0 {
+			fmt.Printf("   Status:   %d\n", f.StatusCode)
+		}
+		fmt.Printf("   Error:    %s\n", f.Error)
+		fmt.Printf("   Attempts: %d (first %s, last %s)\n",
+			f.Attempts, f.FirstFailedAt.Format(time.RFC3339), f.LastFailedAt.Format(time.RFC3339))
+		fmt.Println()
+	}
+}
+
+func runGetDoc(docID string, jsonOutput bool) {
+	// Open database
+	db, err := storage.Open(dbPath)
 	if err != nil {
 		log.Fatalf("Error opening database: %v", err)
 	}
@@ -367,38 +1388,153 @@ func runGetDoc(docID string) {
 
 	// Retrieve document
 	doc, err := db.Get(docID)
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Printf("Document not found: %s\n", docID)
+		os.Exit(1)
+	}
 	if err != nil {
 		log.Fatalf("Error retrieving document: %v", err)
 	}
 
-	if doc == nil {
-		fmt.Printf("Document not found: %s\n", docID)
-		os.Exit(1)
+	if jsonOutput {
+		printJSON(newDocumentOutput(doc))
+		return
 	}
 
 	// Output markdown content
 	fmt.Println(doc.Content)
 }
 
-func runEmbed(startFrom string, modelName string) {
-	// Determine which model and embedding field to use
-	var ollamaModelName string
-	var useQwenField bool
-
-	switch modelName {
-	case "nomic":
-		ollamaModelName = "nomic-embed-text"
-		useQwenField = false
-	case "qwen":
-		ollamaModelName = "qwen3-embedding"
-		useQwenField = true
-	default:
-		log.Fatalf("Error: Unknown model '%s'. Supported models: nomic, qwen", modelName)
+// getDocsOutput is the get-docs -json shape
+type getDocsOutput struct {
+	Documents []*documentOutput `json:"documents"` // In the order requested
+	Missing   []string          `json:"missing"`
+}
+
+// runGetDocs prints the documents with the given IDs, fetched in one query
+// IDs with no document are reported; it fails only if none are found.
+func runGetDocs(ids []string, jsonOutput bool) {
+	// Open database
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+
+	docs, err := db.GetMany(ids)
+	if err != nil {
+		log.Fatalf("Error retrieving documents: %v", err)
+	}
+
+	out := getDocsOutput{Documents: []*documentOutput{}, Missing: []string{}}
+	for _, id := range ids {
+		if doc, ok := docs[id]; ok {
+			out.Documents = append(out.Documents, newDocumentOutput(doc))
+		} else {
+			out.Missing = append(out.Missing, id)
+		}
+	}
+
+	if jsonOutput {
+		printJSON(out)
+	} else {
+		for i, doc := range out.Documents {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("=== %s (%s) ===\n\n", doc.Title, doc.ID)
+			fmt.Println(doc.Content)
+		}
+		for _, id := range out.Missing {
+			fmt.Fprintf(os.Stderr, "Document not found: %s\n", id)
+		}
+	}
+
+	if len(out.Documents) == 0 {
+		os.Exit(1)
 	}
+}
 
-	fmt.Printf("Generating embeddings for all documents using %s model...\n", modelName)
+// splitIDs parses comma-separated document IDs, dropping blanks and duplicates
+func splitIDs(list string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, id := range strings.Split(list, ",") {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// searchInOutput is the search-in -json shape
+type searchInOutput struct {
+	ID       string            `json:"id"`
+	Title    string            `json:"title"`
+	Query    string            `json:"query"`
+	Passages []*search.Passage `json:"passages"`
+	Total    int               `json:"total"` // Matching lines, including any past -n
+}
+
+// runSearchIn prints the lines of a document that mention the query, with
+// their line numbers, for finding a passage in a long document
+func runSearchIn(docID, query string, limit int, jsonOutput bool) {
+	// Open database
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+
+	doc, err := db.Get(docID)
+	if errors.Is(err, storage.ErrNotFound) {
+		fmt.Printf("Document not found: %s\n", docID)
+		os.Exit(1)
+	}
+	if err != nil {
+		log.Fatalf("Error retrieving document: %v", err)
+	}
+
+	passages, total := search.FindPassages(doc.Content, query, limit)
+
+	if jsonOutput {
+		if passages == nil {
+			passages = []*search.Passage{} // Encode as [] rather than null
+		}
+		printJSON(searchInOutput{ID: doc.ID, Title: doc.Title, Query: query, Passages: passages, Total: total})
+		return
+	}
+
+	fmt.Printf("=== %q in %s ===\n\n", query, doc.Title)
+	if total == 0 {
+		fmt.Println("No matching lines")
+		return
+	}
+	for _, p := range passages {
+		fmt.Printf("%5d: %s\n", p.Line, terminalMarkup(p.Snippet))
+	}
 	fmt.Println()
+	if len(passages) < total {
+		fmt.Printf("Showing %d of %d matching lines (use -n=0 for all)\n", len(passages), total)
+	} else {
+		fmt.Printf("%d matching lines\n", total)
+	}
+}
 
+// recentOutput is one recent -json entry
+type recentOutput struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Author    string    `json:"author"`
+	SlabURL   string    `json:"slab_url"`
+	UpdatedAt time.Time `json:"updated_at"`
+	SyncedAt  time.Time `json:"synced_at"`
+}
+
+// runRecent prints the n most recently synced documents
+func runRecent(n int, jsonOutput bool) {
 	// Open database
 	db, err := storage.Open(dbPath)
 	if err != nil {
@@ -406,22 +1542,360 @@ func runEmbed(startFrom string, modelName string) {
 	}
 	defer db.Close()
 
-	// Initialize embeddings client
-	embedder := embeddings.NewClient(ollamaURL, ollamaModelName)
-	if err := embedder.Health(); err != nil {
-		log.Fatalf("Error: Ollama not available (%v)", err)
+	docs, err := db.ListRecent(n)
+	if err != nil {
+		log.Fatalf("Error listing recent documents: %v", err)
 	}
-	log.Printf("✓ Using Ollama with model: %s", ollamaModelName)
 
-	// Get all documents
+	if jsonOutput {
+		out := make([]recentOutput, 0, len(docs))
+		for _, doc := range docs {
+			out = append(out, recentOutput{
+				ID:        doc.ID,
+				Title:     doc.Title,
+				Author:    doc.AuthorName,
+				SlabURL:   doc.SlabURL,
+				UpdatedAt: doc.UpdatedAt,
+				SyncedAt:  doc.SyncedAt,
+			})
+		}
+		printJSON(out)
+		return
+	}
+
+	if len(docs) == 0 {
+		fmt.Println("No documents synced yet")
+		return
+	}
+
+	fmt.Printf("=== %d most recently synced documents ===\n\n", len(docs))
+	for i, doc := range docs {
+		fmt.Printf("%d. %s\n", i+1, doc.Title)
+		if doc.AuthorName != "" {
+			fmt.Printf("   Author:  %s\n", doc.AuthorName)
+		}
+		if !doc.UpdatedAt.IsZero() {
+			fmt.Printf("   Updated: %s\n", doc.UpdatedAt.Format("2006-01-02 15:04"))
+		}
+		fmt.Printf("   Synced:  %s\n", doc.SyncedAt.Format("2006-01-02 15:04"))
+		fmt.Printf("   URL:     %s\n", doc.SlabURL)
+		fmt.Println()
+	}
+}
+
+// runListTopic prints a page of the documents tagged with a topic
+func runListTopic(topic string, limit, offset int, jsonOutput bool) {
+	// Open search index
+	idx, err := search.Open(indexPath)
+	if err != nil {
+		log.Fatalf("Error opening search index: %v", err)
+	}
+	defer idx.Close()
+
+	docs, total, err := idx.ListTopic(topic, limit, offset)
+	if err != nil {
+		log.Fatalf("Error listing topic: %v", err)
+	}
+
+	if jsonOutput {
+		printJSON(docs)
+		return
+	}
+
+	if total == 0 {
+		fmt.Printf("No documents in topic '%s'\n", topic)
+		// Topic names are matched exactly, so point out a casing mismatch
+		if match, err := idx.MatchTopic(topic); err != nil {
+			slog.Warn("Failed to look up topics", "error", err)
+		} else if match != "" {
+			fmt.Printf("Did you mean: %s?\n", match)
+		}
+		return
+	}
+
+	if len(docs) == 0 {
+		fmt.Printf("No documents past offset %d (topic '%s' has %d)\n", offset, topic, total)
+		return
+	}
+
+	fmt.Printf("=== %s ===\n", topic)
+	fmt.Printf("Showing %d-%d of %d documents, most recently updated first:\n\n", offset+1, offset+len(docs), total)
+	for i, doc := range docs {
+		fmt.Printf("%d. %s\n", offset+i+1, doc.Title)
+		if doc.Author != "" {
+			fmt.Printf("   Author:  %s\n", doc.Author)
+		}
+		if !doc.UpdatedAt.IsZero() {
+			fmt.Printf("   Updated: %s\n", doc.UpdatedAt.Format("2006-01-02"))
+		}
+		fmt.Printf("   URL:     %s\n", doc.SlabURL)
+		fmt.Println()
+	}
+
+	if next := offset + len(docs); uint64(next) < total {
+		fmt.Printf("Next page: slab-search list-topic -offset=%d %q\n", next, topic)
+	}
+}
+
+// runRelated lists the documents most similar to docID by their stored
+// embeddings; no embedding provider is needed
+func runRelated(docID string, limit int, jsonOutput bool) {
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+
+	idx, err := search.Open(indexPath)
+	if err != nil {
+		log.Fatalf("Error opening search index: %v", err)
+	}
+	defer idx.Close()
+	idx.SetDB(db)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	related, err := idx.RelatedDocuments(ctx, docID, limit)
+	if err != nil {
+		log.Fatalf("Error finding related documents: %v", err)
+	}
+
+	if jsonOutput {
+		if related == nil {
+			related = []*search.SearchResult{} // Print [] rather than null
+		}
+		printJSON(related)
+		return
+	}
+
+	if len(related) == 0 {
+		fmt.Println("No related documents found")
+		return
+	}
+
+	fmt.Printf("Documents related to %s:\n\n", docID)
+	for i, result := range related {
+		fmt.Printf("%d. %s\n", i+1, result.Title)
+		if result.Author != "" {
+			fmt.Printf("   Author: %s\n", result.Author)
+		}
+		fmt.Printf("   URL: %s\n", result.SlabURL)
+		fmt.Printf("   Similarity: %.3f\n", result.Score)
+		fmt.Println()
+	}
+}
+
+// documentOutput is the get-doc -json shape; the leading fields match
+// search -json results so scripts can treat both alike
+type documentOutput struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Author      string     `json:"author"`
+	SlabURL     string     `json:"slab_url"`
+	AuthorEmail string     `json:"author_email,omitempty"`
+	Topics      []string   `json:"topics"`
+	PublishedAt time.Time  `json:"published_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+	SyncedAt    time.Time  `json:"synced_at"`
+	EmbeddedAt  *time.Time `json:"embedded_at,omitempty"`
+	Content     string     `json:"content"`
+}
+
+func newDocumentOutput(doc *storage.Document) *documentOutput {
+	topics := doc.TopicNames()
+	if topics == nil {
+		topics = []string{}
+	}
+
+	return &documentOutput{
+		ID:          doc.ID,
+		Title:       doc.Title,
+		Author:      doc.AuthorName,
+		SlabURL:     doc.SlabURL,
+		AuthorEmail: doc.AuthorEmail,
+		Topics:      topics,
+		PublishedAt: doc.PublishedAt,
+		UpdatedAt:   doc.UpdatedAt,
+		ArchivedAt:  doc.ArchivedAt,
+		SyncedAt:    doc.SyncedAt,
+		EmbeddedAt:  doc.EmbeddedAt,
+		Content:     doc.Content,
+	}
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false) // Keep <mark> highlights readable
+	if err := enc.Encode(v); err != nil {
+		log.Fatalf("Error encoding JSON: %v", err)
+	}
+}
+
+// runBackup snapshots the database to dest; with force, an existing dest is
+// replaced only once the new snapshot is complete
+func runBackup(dest string, force bool) {
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+
+	target := dest
+	if force {
+		target = dest + ".tmp"
+		if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+			log.Fatalf("Error removing stale %s: %v", target, err)
+		}
+	}
+
+	start := time.Now()
+	if err := db.BackupTo(target); errors.Is(err, storage.ErrBackupExists) {
+		log.Fatalf("Error: %v (use -force to replace it)", err)
+	} else if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if force {
+		if err := os.Rename(target, dest); err != nil {
+			log.Fatalf("Error replacing %s: %v", dest, err)
+		}
+	}
+
+	info, err := os.Stat(dest)
+	if err != nil {
+		log.Fatalf("Error reading backup: %v", err)
+	}
+	fmt.Printf("Backed up %s to %s (%.1f MB) in %v\n", dbPath, dest, float64(info.Size())/(1<<20), time.Since(start).Round(time.Millisecond))
+	fmt.Println("The search index isn't included; rebuild it from a restored database with 'slab-search reindex'")
+}
+
+func runDeleteDoc(docID string) {
+	// Open database
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+
+	// Open search index
+	idx, err := search.Open(indexPath)
+	if err != nil {
+		log.Fatalf("Error opening search index: %v", err)
+	}
+	defer idx.Close()
+
+	doc, err := db.Get(docID)
+	if errors.Is(err, storage.ErrNotFound) {
+		log.Fatalf("Error: Document %s not found", docID)
+	}
+	if err != nil {
+		log.Fatalf("Error retrieving document: %v", err)
+	}
+
+	if err := db.Delete(docID); err != nil {
+		log.Fatalf("Error deleting document: %v", err)
+	}
+	if err := idx.Delete(docID); err != nil {
+		log.Fatalf("Error removing document from search index: %v", err)
+	}
+
+	fmt.Printf("Deleted %s (%s)\n", doc.Title, docID)
+}
+
+func runEmbed(startFrom string, modelName string, chunkSize, chunkOverlap, batchSize, concurrency int, missing, resume bool, since time.Duration) {
+	// Determine which model and embedding field to use
+	providerModel, useQwenField := resolveModel(modelName)
+
+	if missing {
+		fmt.Printf("Generating embeddings for documents without one using %s model...\n", providerModel)
+	} else if since > 0 {
+		fmt.Printf("Generating embeddings for documents updated in the last %v using %s model...\n", since, providerModel)
+	} else {
+		fmt.Printf("Generating embeddings for all documents using %s model...\n", providerModel)
+	}
+	fmt.Println()
+
+	// Open database
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		log.Fatalf("Error opening database: %v", err)
+	}
+	defer db.Close()
+
+	// Get all documents, in ID order so -start-from resumes the same sequence
+	// even if documents were updated since the interrupted run
 	docs, err := db.List(false)
 	if err != nil {
 		log.Fatalf("Error listing documents: %v", err)
 	}
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].ID < docs[j].ID
+	})
+
+	// -since keeps documents updated in Slab within the window, e.g. after an
+	// incremental sync
+	if since > 0 {
+		total := len(docs)
+		cutoff := time.Now().Add(-since)
+		docs = slices.DeleteFunc(docs, func(doc *storage.Document) bool {
+			return doc.UpdatedAt.Before(cutoff)
+		})
+		fmt.Printf("%d of %d documents were updated in the last %v\n", len(docs), total, since)
+		if len(docs) == 0 {
+			fmt.Println("Nothing to embed")
+			return
+		}
+	}
+
+	// -missing skips documents that already have a vector in the target field
+	if missing {
+		total := len(docs)
+		docs = slices.DeleteFunc(docs, func(doc *storage.Document) bool {
+			if useQwenField {
+				return len(doc.EmbeddingQwen) > 0
+			}
+			return len(doc.Embedding) > 0
+		})
+		fmt.Printf("Skipping %d of %d documents that already have an embedding\n", total-len(docs), total)
+		if len(docs) == 0 {
+			fmt.Println("Nothing to embed")
+			return
+		}
+	}
+
+	// Initialize embeddings client
+	embedder, err := newEmbedder(providerModel)
+	if err != nil {
+		printEmbedderHint(providerModel)
+		log.Fatalf("Error: Embeddings not available (%v)", err)
+	}
+	slog.Info("Using embedding provider", "provider", embeddingProvider, "model", providerModel)
 
 	// Filter to resume point if specified
 	startIdx := 0
-	if startFrom != "" {
+	if resume {
+		cp, err := loadEmbedCheckpoint()
+		if err != nil {
+			log.Fatalf("Error reading embed checkpoint: %v", err)
+		}
+		switch {
+		case cp == nil:
+			fmt.Println("No embed checkpoint found, starting from the beginning")
+		case cp.Model != providerModel:
+			log.Fatalf("Error: the checkpoint is for model %s, not %s (pass -model to match, or rerun without -resume)", cp.Model, providerModel)
+		default:
+			// The next document may have been deleted since; IDs are sorted,
+			// so continue from the first one after it
+			startIdx, _ = slices.BinarySearchFunc(docs, cp.NextID, func(doc *storage.Document, id string) int {
+				return strings.Compare(doc.ID, id)
+			})
+			fmt.Printf("Resuming from checkpoint at document %d/%d (saved %s)\n",
+				startIdx+1, len(docs), cp.UpdatedAt.Local().Format(time.DateTime))
+		}
+	} else if startFrom != "" {
 		found := false
 		for i, doc := range docs {
 			if doc.ID == startFrom {
@@ -436,6 +1910,10 @@ func runEmbed(startFrom string, modelName string) {
 		}
 	}
 
+	// Ctrl-C cancels in-flight requests; documents already embedded are kept
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
 	fmt.Printf("Processing %d documents (starting from index %d)\n", len(docs)-startIdx, startIdx)
 	fmt.Println()
 	startTime := time.Now()
@@ -443,44 +1921,161 @@ func runEmbed(startFrom string, modelName string) {
 	embeddingsGenerated := 0
 	embeddingsFailed := 0
 
-	for i := startIdx; i < len(docs); i++ {
-		doc := docs[i]
-
-		// Show progress every 100 documents
-		if (i-startIdx) > 0 && (i-startIdx)%100 == 0 {
-			percent := float64(i-startIdx) / float64(len(docs)-startIdx) * 100
-			elapsed := time.Since(startTime)
-			docsPerSec := float64(i-startIdx) / elapsed.Seconds()
-			remaining := time.Duration(float64(len(docs)-i) / docsPerSec * float64(time.Second))
-
-			fmt.Printf("\rProgress: %d/%d (%.1f%%) - %d generated, %d failed - ETA: %v  ",
-				i-startIdx, len(docs)-startIdx, percent, embeddingsGenerated, embeddingsFailed, remaining.Round(time.Second))
-		}
-
-		// Generate embedding
-		textToEmbed := fmt.Sprintf("%s\n\n%s", doc.Title, doc.Content)
-		embedding, err := embedder.Embed(textToEmbed)
-		if err != nil {
-			log.Printf("\nWarning: Failed to generate embedding for %s (%s): %v", doc.ID, doc.Title, err)
-			embeddingsFailed++
-			continue
+	// storeEmbedding saves a document's new embedding and chunks
+	storeEmbedding := func(doc *storage.Document, embedding []float32, chunks []*storage.Chunk) error {
+		// Store unit vectors so similarity is a plain dot product
+		embedding = embeddings.NormalizeEmbedding(embedding)
+		if embedding == nil {
+			return fmt.Errorf("provider returned an empty or all-zero embedding")
 		}
 
 		// Update document with embedding in the appropriate field
 		serializedEmbedding := embeddings.SerializeEmbedding(embedding)
 		if useQwenField {
 			doc.EmbeddingQwen = serializedEmbedding
+			doc.EmbeddingQwenModel = providerModel
 		} else {
 			doc.Embedding = serializedEmbedding
+			doc.EmbeddingModel = providerModel
+		}
+		embeddedAt := time.Now()
+		doc.EmbeddedAt = &embeddedAt
+
+		// Replace chunks from earlier runs (clears them for whole-document
+		// embeddings) before bumping embedded_at: a running server caches
+		// vectors by it, so it must not see the new time with the old chunks
+		if err := db.ReplaceChunks(doc.ID, useQwenField, chunks); err != nil {
+			return fmt.Errorf("store chunks: %w", err)
 		}
 
 		if err := db.Upsert(doc); err != nil {
-			log.Printf("\nWarning: Failed to update embedding for %s: %v", doc.ID, err)
-			embeddingsFailed++
-			continue
+			return fmt.Errorf("update embedding: %w", err)
+		}
+		return nil
+	}
+
+	// Workers embed batches concurrently; results are saved here, on one
+	// goroutine, so SQLite writes are serialized
+	type embeddedBatch struct {
+		start   int
+		results []embedResult
+	}
+	jobs := make(chan int)
+	embedded := make(chan embeddedBatch)
+
+	go func() {
+		defer close(jobs)
+		for batchStart := startIdx; batchStart < len(docs); batchStart += batchSize {
+			select {
+			case jobs <- batchStart:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var wg gosync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batchStart := range jobs {
+				batch := docs[batchStart:min(batchStart+batchSize, len(docs))]
+
+				// Generate embeddings for the whole batch in as few requests as possible
+				texts := make([]string, len(batch))
+				for n, doc := range batch {
+					texts[n] = fmt.Sprintf("%s\n\n%s", doc.Title, doc.Content)
+				}
+				embedded <- embeddedBatch{
+					start:   batchStart,
+					results: embedDocuments(ctx, embedder, texts, chunkSize, chunkOverlap),
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(embedded)
+	}()
+
+	// finished marks documents that are done (saved or failed for good), so an
+	// interrupted run knows where to resume despite out-of-order batches;
+	// frontier is the first unfinished one, saved as the checkpoint
+	finished := make([]bool, len(docs))
+	frontier := startIdx
+	processed := 0
+
+	// The checkpoint is a position in the whole corpus; -missing resumes by
+	// rerunning it, and -since with -start-from, so they keep none
+	checkpointing := !missing && since == 0
+
+	for batch := range embedded {
+		for n, result := range batch.results {
+			doc := docs[batch.start+n]
+			if result.err != nil {
+				if ctx.Err() != nil {
+					continue // Interrupted; retried on resume
+				}
+				slog.Warn("Failed to generate embedding", "id", doc.ID, "title", doc.Title, "error", result.err)
+				embeddingsFailed++
+			} else if err := storeEmbedding(doc, result.embedding, result.chunks); err != nil {
+				slog.Warn("Failed to save embedding", "id", doc.ID, "error", err)
+				embeddingsFailed++
+			} else {
+				embeddingsGenerated++
+			}
+			finished[batch.start+n] = true
+			processed++
+		}
+
+		if frontier < len(docs) && finished[frontier] {
+			for frontier < len(docs) && finished[frontier] {
+				frontier++
+			}
+			if checkpointing && frontier < len(docs) {
+				cp := &embedCheckpoint{Model: providerModel, NextID: docs[frontier].ID, Done: frontier, Total: len(docs), UpdatedAt: time.Now()}
+				if err := saveEmbedCheckpoint(cp); err != nil {
+					slog.Warn("Failed to save embed checkpoint", "error", err)
+				}
+			}
+		}
+
+		// Show progress every 100 documents
+		if processed/100 > (processed-len(batch.results))/100 {
+			percent := float64(processed) / float64(len(docs)-startIdx) * 100
+			elapsed := time.Since(startTime)
+			docsPerSec := float64(processed) / elapsed.Seconds()
+			remaining := time.Duration(float64(len(docs)-startIdx-processed) / docsPerSec * float64(time.Second))
+
+			fmt.Printf("\rProgress: %d/%d (%.1f%%) - %d generated, %d failed - ETA: %v  ",
+				processed, len(docs)-startIdx, percent, embeddingsGenerated, embeddingsFailed, remaining.Round(time.Second))
+		}
+	}
+
+	if ctx.Err() != nil {
+		fmt.Println()
+		fmt.Printf("Interrupted: %d generated, %d failed\n", embeddingsGenerated, embeddingsFailed)
+		if missing {
+			fmt.Println("Resume with: slab-search embed -missing")
+			return
 		}
+		// Batches finish out of order, so resume from the first unfinished
+		// document; anything after it that already finished is redone
+		switch {
+		case frontier >= len(docs):
+		case since > 0:
+			fmt.Printf("Resume with: slab-search embed -since=%v -start-from=%s\n", since, docs[frontier].ID)
+		default:
+			fmt.Printf("Resume with: slab-search embed -resume (or -start-from=%s)\n", docs[frontier].ID)
+		}
+		return
+	}
 
-		embeddingsGenerated++
+	if checkpointing {
+		if err := clearEmbedCheckpoint(); err != nil {
+			slog.Warn("Failed to remove embed checkpoint", "error", err)
+		}
 	}
 
 	duration := time.Since(startTime)
@@ -499,10 +2094,95 @@ func runEmbed(startFrom string, modelName string) {
 	}
 }
 
-func runReindex() {
-	fmt.Println("Rebuilding Bleve keyword search index...")
-	fmt.Println()
+// embedResult is the outcome of embedding one document
+type embedResult struct {
+	embedding []float32
+	chunks    []*storage.Chunk // nil for whole-document embeddings
+	err       error
+}
+
+// embedDocuments embeds several documents with a single EmbedBatch request
+// covering every document (or every chunk of chunked documents)
+// If the batch request fails, each document is retried on its own so one bad
+// document doesn't fail the others.
+func embedDocuments(ctx context.Context, embedder embeddings.Embedder, texts []string, chunkSize, chunkOverlap int) []embedResult {
+	// Flatten documents into one list of inputs, remembering each one's span
+	var inputs []string
+	parts := make([][]string, len(texts))
+	for n, text := range texts {
+		parts[n] = embeddings.ChunkText(text, chunkSize, chunkOverlap)
+		if len(parts[n]) <= 1 {
+			parts[n] = []string{text}
+		}
+		inputs = append(inputs, parts[n]...)
+	}
+
+	results := make([]embedResult, len(texts))
+
+	vecs, err := embedder.EmbedBatch(ctx, inputs)
+	if err == nil && len(vecs) == len(inputs) {
+		offset := 0
+		for n := range texts {
+			docVecs := vecs[offset : offset+len(parts[n])]
+			offset += len(parts[n])
+			results[n].embedding, results[n].chunks = documentVectors(parts[n], docVecs)
+		}
+		return results
+	}
+
+	if ctx.Err() != nil {
+		for n := range results {
+			results[n].err = ctx.Err()
+		}
+		return results
+	}
+
+	// Fall back to one request per document
+	for n, text := range texts {
+		results[n].embedding, results[n].chunks, results[n].err = embedDocument(ctx, embedder, text, chunkSize, chunkOverlap)
+	}
+	return results
+}
+
+// embedDocument embeds text whole, or in overlapping chunks when chunkSize > 0
+// Chunked documents get the mean of their chunk vectors as the document
+// embedding; chunks is nil when the text fits in a single chunk
+func embedDocument(ctx context.Context, embedder embeddings.Embedder, text string, chunkSize, chunkOverlap int) ([]float32, []*storage.Chunk, error) {
+	parts := embeddings.ChunkText(text, chunkSize, chunkOverlap)
+	if len(parts) <= 1 {
+		embedding, err := embedder.Embed(ctx, text)
+		return embedding, nil, err
+	}
+
+	vecs, err := embedder.EmbedBatch(ctx, parts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	embedding, chunks := documentVectors(parts, vecs)
+	return embedding, chunks, nil
+}
+
+// documentVectors turns the vectors for a document's parts into its document
+// embedding and chunks (nil chunks for a single whole-document part)
+func documentVectors(parts []string, vecs [][]float32) ([]float32, []*storage.Chunk) {
+	if len(parts) == 1 {
+		return vecs[0], nil
+	}
+
+	chunks := make([]*storage.Chunk, len(parts))
+	for n, part := range parts {
+		chunks[n] = &storage.Chunk{
+			Index:     n,
+			Content:   part,
+			Embedding: embeddings.SerializeEmbedding(embeddings.NormalizeEmbedding(vecs[n])),
+		}
+	}
 
+	return embeddings.MeanEmbedding(vecs), chunks
+}
+
+func runReindex(incremental bool) {
 	// Open database
 	db, err := storage.Open(dbPath)
 	if err != nil {
@@ -510,22 +2190,40 @@ func runReindex() {
 	}
 	defer db.Close()
 
+	// Open search index
+	idx, err := search.Open(indexPath)
+	if err != nil {
+		log.Fatalf("Error opening search index: %v", err)
+	}
+	defer idx.Close()
+
+	if incremental {
+		lastIndexed, err := idx.LastIndexed()
+		if err != nil {
+			log.Fatalf("Error reading index state: %v", err)
+		}
+		if !lastIndexed.IsZero() {
+			runIncrementalReindex(db, idx, lastIndexed)
+			return
+		}
+		fmt.Println("No previous reindex recorded for this index; doing a full rebuild")
+	}
+
+	fmt.Println("Rebuilding Bleve keyword search index...")
+	fmt.Println()
+
 	// Get document count
-	docs, err := db.List(false)
+	docCount, err := db.Count()
 	if err != nil {
-		log.Fatalf("Error listing documents: %v", err)
+		log.Fatalf("Error getting database count: %v", err)
 	}
 
-	fmt.Printf("Found %d documents in database\n", len(docs))
+	fmt.Printf("Found %d documents in database\n", docCount)
 	startTime := time.Now()
 
-	// Open search index
-	fmt.Println("Opening Bleve index...")
-	idx, err := search.Open(indexPath)
-	if err != nil {
-		log.Fatalf("Error opening search index: %v", err)
+	if err := loadAnalyzerFiles(idx, fmt.Printf); err != nil {
+		log.Fatalf("Error: %v", err)
 	}
-	defer idx.Close()
 
 	// Rebuild Bleve index
 	fmt.Println("Rebuilding index...")
@@ -556,63 +2254,297 @@ func runReindex() {
 	fmt.Println("To generate embeddings, use: slab-search embed")
 }
 
-func runServe(host, port string) {
-	log.Println("DEBUG: Starting runServe...")
+// loadAnalyzerFiles sets the synonyms and stopwords from the data dir on idx
+// for its next rebuild; they're baked into the analyzer, so every rebuild
+// picks up edits. Reports the files in use with printf.
+func loadAnalyzerFiles(idx *search.Index, printf func(format string, args ...any) (int, error)) error {
+	synonymsPath := filepath.Join(dataDir, search.SynonymsFile)
+	synonyms, err := search.LoadSynonyms(synonymsPath)
+	if err != nil {
+		return fmt.Errorf("load synonyms: %w", err)
+	}
+	if len(synonyms) > 0 {
+		printf("Using %d synonym groups from %s\n", len(synonyms), synonymsPath)
+	}
+	idx.SetSynonyms(synonyms)
+
+	stopwordsPath := filepath.Join(dataDir, search.StopwordsFile)
+	stopwords, err := search.LoadStopwords(stopwordsPath)
+	if err != nil {
+		return fmt.Errorf("load stopwords: %w", err)
+	}
+	if stopwords != nil {
+		printf("Using %d stopwords from %s\n", len(stopwords), stopwordsPath)
+	}
+	idx.SetStopwords(stopwords)
+	return nil
+}
+
+// runIncrementalReindex brings the index up to date with documents changed
+// since lastIndexed, without rebuilding it
+func runIncrementalReindex(db *storage.DB, idx *search.Index, lastIndexed time.Time) {
+	fmt.Printf("Updating Bleve keyword search index (changes since %s)...\n", lastIndexed.Format(time.RFC3339))
+	startTime := time.Now()
+
+	stats, err := idx.Update(db, lastIndexed)
+	if err != nil {
+		log.Fatalf("Error updating index: %v", err)
+	}
+
+	indexCount, err := idx.Count()
+	if err != nil {
+		log.Fatalf("Error getting index count: %v", err)
+	}
+
+	fmt.Println()
+	fmt.Println("=== Reindex Complete ===")
+	fmt.Printf("Documents re-indexed: %d\n", stats.Indexed)
+	fmt.Printf("Entries removed:      %d\n", stats.Deleted)
+	fmt.Printf("Documents in index:   %d\n", indexCount)
+	fmt.Printf("Duration:             %v\n", time.Since(startTime).Round(time.Millisecond))
+	fmt.Println()
+	fmt.Println("Note: Synonym and stopword changes need a full reindex.")
+}
 
+func runServe(host, port string, metrics bool, embeddingCache, resultCache int, resultCacheTTL, syncInterval time.Duration) {
 	// Open database
-	log.Println("DEBUG: Opening database...")
+	slog.Debug("Opening database", "path", dbPath)
 	db, err := storage.Open(dbPath)
 	if err != nil {
 		log.Fatalf("Error opening database: %v", err)
 	}
 	defer db.Close()
-	log.Println("DEBUG: Database opened")
 
 	// Open search index
-	log.Println("DEBUG: Opening search index...")
+	slog.Debug("Opening search index", "path", indexPath)
 	idx, err := search.Open(indexPath)
 	if err != nil {
 		log.Fatalf("Error opening search index: %v", err)
 	}
 	defer idx.Close()
-	log.Println("DEBUG: Search index opened")
+	idx.SetTopicBoosts(topicBoosts)
 
 	// Try to initialize embeddings client (optional)
-	log.Println("DEBUG: Checking Ollama...")
-	var embedder *embeddings.Client
-	embedder = embeddings.NewClient(ollamaURL, ollamaModel)
-	if err := embedder.Health(); err != nil {
-		log.Printf("Warning: Ollama not available (%v), semantic/hybrid search disabled", err)
-		log.Printf("To enable semantic search, install Ollama and run: ollama pull %s", ollamaModel)
+	modelName := embeddings.GetDefaultModel(embeddingProvider)
+	slog.Debug("Checking embedding provider", "provider", embeddingProvider, "model", modelName)
+	embedder, err := newEmbedder(modelName)
+	if err != nil {
+		slog.Warn("Embeddings not available, semantic/hybrid search disabled", "error", err)
+		printEmbedderHint(modelName)
 		embedder = nil
 	} else {
-		log.Printf("✓ Ollama available, semantic and hybrid search enabled")
+		slog.Info("Embedding provider available, semantic and hybrid search enabled", "provider", embeddingProvider)
 	}
-	log.Println("DEBUG: Ollama check complete")
 
 	// Create server
-	log.Println("DEBUG: Creating web server...")
 	server, err := web.NewServer(db, idx, embedder)
 	if err != nil {
 		log.Fatalf("Error creating server: %v", err)
 	}
-	log.Println("DEBUG: Web server created")
+	server.SetEmbeddingCacheSize(embeddingCache)
+	server.SetResultCache(resultCache, resultCacheTTL)
+
+	// Offer qwen in the model selector when it's installed and documents have
+	// qwen embeddings (Ollama only)
+	if embedder != nil && embeddingProvider == embeddings.ProviderOllama {
+		qwenModel := embeddings.GetModelName(embeddingProvider, "qwen")
+		if qwenEmbedder, err := newEmbedder(qwenModel); err != nil {
+			slog.Debug("qwen model not available, model selector disabled", "model", qwenModel, "error", err)
+		} else if err := server.EnableQwen(qwenEmbedder); err != nil {
+			slog.Info("qwen search disabled", "reason", err)
+		} else {
+			slog.Info("qwen embeddings available, model selector enabled", "model", qwenModel)
+		}
+	}
+
+	if metrics {
+		server.EnableMetrics()
+	}
+
+	// Let the server refresh the data in place when a Slab token is set; POST
+	// /api/sync also needs a token of its own, so callers never hold the
+	// Slab credential
+	token := getToken()
+	if token != "" {
+		if err := checkWorkspace(db, false, true); err != nil {
+			slog.Warn("Sync disabled", "error", err)
+			token = ""
+		}
+	}
+	if token != "" {
+		slabClient := slab.NewClient(token, slab.WithBaseURL(slabURL))
+		apiToken := os.Getenv("SLAB_SEARCH_SYNC_TOKEN")
+		server.EnableSync(sync.NewWorker(slabClient, db, idx, embedder, 0), apiToken)
+		if apiToken != "" {
+			slog.Info("Sync enabled at POST /api/sync")
+		} else {
+			slog.Debug("No SLAB_SEARCH_SYNC_TOKEN, POST /api/sync disabled")
+		}
+		if syncInterval > 0 {
+			server.ScheduleSync(syncInterval)
+			slog.Info("Scheduled sync", "interval", syncInterval)
+		}
+	} else {
+		slog.Debug("No Slab token, /api/sync disabled")
+	}
+
+	// Build the ANN index in the background (syncs rebuild it); semantic
+	// search falls back to brute force until it's ready
+	if embedder != nil {
+		go func() {
+			start := time.Now()
+			if err := idx.BuildVectorIndex(); err != nil {
+				slog.Warn("Failed to build vector index", "error", err)
+				return
+			}
+			slog.Info("Vector index built", "duration", time.Since(start).Round(time.Millisecond))
+		}()
+	}
 
 	addr := fmt.Sprintf("%s:%s", host, port)
 
 	fmt.Println()
 	fmt.Println("=== Slab Search Web Server ===")
 	fmt.Printf("Server running at: http://%s\n", addr)
+	if metrics {
+		fmt.Printf("Metrics at:        http://%s/metrics\n", addr)
+	}
 	fmt.Println()
 	fmt.Println("Press Ctrl+C to stop")
 	fmt.Println()
 
-	log.Println("DEBUG: Starting HTTP listener...")
+	slog.Debug("Starting HTTP listener", "addr", addr)
 	if err := http.ListenAndServe(addr, server.Handler()); err != nil {
 		log.Fatalf("Error starting server: %v", err)
 	}
 }
 
+// logSyncProgress returns a sync progress function that logs each snapshot,
+// and the final stats as "Sync complete"
+func logSyncProgress(withEmbeddings bool) func(sync.Stats) {
+	return func(stats sync.Stats) {
+		if stats.Processed < stats.TotalPosts {
+			percent := float64(stats.Processed) / float64(stats.TotalPosts) * 100
+			attrs := []any{"done", stats.Processed, "total", stats.TotalPosts, "percent", fmt.Sprintf("%.1f", percent),
+				"new", stats.NewPosts, "updated", stats.UpdatedPosts, "skipped", stats.SkippedPosts, "errors", stats.Errors}
+			if withEmbeddings {
+				attrs = append(attrs, "embeddings", stats.EmbeddingsGen)
+			}
+			slog.Info("Sync progress", attrs...)
+			return
+		}
+
+		attrs := []any{"new", stats.NewPosts, "updated", stats.UpdatedPosts, "skipped", stats.SkippedPosts,
+			"archived_removed", stats.ArchivedRemoved, "deleted", stats.DeletedPosts, "errors", stats.Errors}
+		if withEmbeddings {
+			attrs = append(attrs, "embeddings", stats.EmbeddingsGen, "embeddings_failed", stats.EmbeddingsFailed)
+		}
+		attrs = append(attrs, "duration", stats.Duration)
+		slog.Info("Sync complete", attrs...)
+	}
+}
+
+// resolveModel maps the -model flag to the provider's model name and reports
+// whether vectors belong in the Qwen embedding field; exits on unknown models
+func resolveModel(modelName string) (string, bool) {
+	useQwenField := modelName == "qwen"
+
+	switch embeddingProvider {
+	case embeddings.ProviderOllama:
+		if modelName != "nomic" && modelName != "qwen" {
+			log.Fatalf("Error: Unknown model '%s'. Supported models: nomic, qwen", modelName)
+		}
+	default:
+		if useQwenField {
+			log.Fatalf("Error: Model 'qwen' requires the ollama embedding provider")
+		}
+	}
+
+	return embeddings.GetModelName(embeddingProvider, modelName), useQwenField
+}
+
+// newEmbedder creates an embedder for the configured provider and verifies
+// that it's reachable and serves the model
+func newEmbedder(model string) (embeddings.Embedder, error) {
+	embedder, err := embeddings.NewEmbedder(embeddingProvider, embeddingURL, model, embedTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if err := embedder.Health(); err != nil {
+		return nil, err
+	}
+	return embedder, nil
+}
+
+// newReranker creates a reranker for search -rerank, defaulting to Cohere's
+// API; the key comes from RERANK_API_KEY (or COHERE_API_KEY)
+func newReranker(url, model string) (*rerank.Client, error) {
+	apiKey := os.Getenv("RERANK_API_KEY")
+	if apiKey == "" {
+		apiKey = os.Getenv("COHERE_API_KEY")
+	}
+
+	if url == "" {
+		if apiKey == "" {
+			return nil, fmt.Errorf("-rerank needs COHERE_API_KEY for Cohere, or rerank_url in the config for a local reranker")
+		}
+		url = rerank.DefaultURL
+		model = orDefault(model, rerank.DefaultModel)
+	}
+	return rerank.NewClient(url, model, apiKey), nil
+}
+
+// printEmbedderHint logs how to make the configured provider available
+func printEmbedderHint(model string) {
+	switch embeddingProvider {
+	case embeddings.ProviderOpenAI:
+		slog.Info("To enable semantic search, set OPENAI_API_KEY")
+	default:
+		slog.Info("To enable semantic search, install Ollama and run: ollama pull " + model)
+	}
+}
+
+// parseDateFlag parses a date flag value (YYYY-MM-DD or RFC 3339)
+// Returns zero time for an empty value and exits on malformed input
+func parseDateFlag(name, value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		log.Fatalf("Error: invalid -%s date %q (expected YYYY-MM-DD)", name, value)
+	}
+	return t
+}
+
+// parseSinceFlag parses a flag that is either a duration relative to now
+// (e.g. 24h) or an absolute date
+func parseSinceFlag(name, value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d)
+	}
+	return parseDateFlag(name, value)
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func getToken() string {
 	// Try environment variable first
 	if token := os.Getenv("SLAB_TOKEN"); token != "" {
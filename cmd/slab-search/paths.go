@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// File names inside the data dir, unless --db-path or --index-path move them
+const (
+	dbFileName   = "slab.db"
+	indexDirName = "bleve"
+)
+
+// resolvePaths sets dataDir, dbPath and indexPath from the flags
+// dbOverride and indexOverride, when set, put the database or index
+// somewhere other than the data dir (e.g. the index on a faster disk).
+// Paths that exist but are the wrong kind (a file where a directory is
+// expected, or the reverse) are rejected up front.
+func resolvePaths(dir, dbOverride, indexOverride string) error {
+	if dir == "" {
+		return errors.New("--data-dir must not be empty")
+	}
+	dataDir = filepath.Clean(dir)
+	dbPath = filepath.Join(dataDir, dbFileName)
+	if dbOverride != "" {
+		dbPath = filepath.Clean(dbOverride)
+	}
+	indexPath = filepath.Join(dataDir, indexDirName)
+	if indexOverride != "" {
+		indexPath = filepath.Clean(indexOverride)
+	}
+
+	for _, p := range []struct {
+		flag, path string
+		dir        bool
+	}{
+		{"--data-dir", dataDir, true},
+		{"--db-path", dbPath, false},
+		{"--index-path", indexPath, true},
+	} {
+		info, err := os.Stat(p.path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // Created on first sync or import
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", p.flag, err)
+		}
+		if p.dir && !info.IsDir() {
+			return fmt.Errorf("%s: %s is a file, not a directory", p.flag, p.path)
+		}
+		if !p.dir && info.IsDir() {
+			return fmt.Errorf("%s: %s is a directory, not a database file", p.flag, p.path)
+		}
+	}
+	return nil
+}
+
+// ensureDataDirs creates the data dir and the directories the database and
+// index go in, for commands that create them
+func ensureDataDirs() error {
+	for _, dir := range []string{dataDir, filepath.Dir(dbPath), filepath.Dir(indexPath)} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("create %s: %w", dir, err)
+		}
+	}
+	return nil
+}
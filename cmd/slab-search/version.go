@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build metadata, set at build time with:
+//
+//	go build -ldflags "-X main.version=v1.2.0 -X main.commit=$(git rev-parse --short HEAD) -X main.buildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd/slab-search
+//
+// Without ldflags, commit and date fall back to the VCS info Go embeds when
+// building from a git checkout
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// runVersion prints the version, commit and build date
+func runVersion() {
+	rev, date := commit, buildDate
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if rev == "" {
+					rev = setting.Value
+					if len(rev) > 12 {
+						rev = rev[:12]
+					}
+				}
+			case "vcs.time":
+				if date == "" {
+					date = setting.Value
+				}
+			case "vcs.modified":
+				if setting.Value == "true" && commit == "" && rev != "" {
+					rev += "-dirty"
+				}
+			}
+		}
+	}
+
+	fmt.Printf("slab-search %s\n", version)
+	fmt.Printf("Commit:     %s\n", orDefault(rev, "unknown"))
+	fmt.Printf("Built:      %s\n", orDefault(date, "unknown"))
+	fmt.Printf("Go:         %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,75 @@
+package embeddings
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// ChunkText splits text into chunks of at most size characters, each
+// starting overlap characters before the previous one ended
+// Chunk boundaries prefer whitespace in the last quarter of a chunk so words
+// aren't split. Returns the whole text as one chunk if size <= 0 or it fits.
+func ChunkText(text string, size, overlap int) []string {
+	runes := []rune(text)
+	if size <= 0 || len(runes) <= size {
+		return []string{text}
+	}
+	overlap = min(max(overlap, 0), size/2) // Guarantee forward progress
+
+	var chunks []string
+	for start := 0; start < len(runes); {
+		end := min(start+size, len(runes))
+		if end < len(runes) {
+			for j := end; j > start+size*3/4; j-- {
+				if unicode.IsSpace(runes[j-1]) {
+					end = j
+					break
+				}
+			}
+		}
+
+		if chunk := strings.TrimSpace(string(runes[start:end])); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		if end == len(runes) {
+			break
+		}
+		start = end - overlap
+	}
+
+	return chunks
+}
+
+// MeanEmbedding averages L2-normalized vectors into a single document vector
+// Returns nil if vecs is empty or the dimensions differ
+func MeanEmbedding(vecs [][]float32) []float32 {
+	if len(vecs) == 0 {
+		return nil
+	}
+
+	mean := make([]float32, len(vecs[0]))
+	for _, vec := range vecs {
+		if len(vec) != len(mean) {
+			return nil
+		}
+
+		var norm float64
+		for _, v := range vec {
+			norm += float64(v) * float64(v)
+		}
+		if norm == 0 {
+			continue
+		}
+
+		inv := float32(1 / math.Sqrt(norm))
+		for i, v := range vec {
+			mean[i] += v * inv
+		}
+	}
+
+	for i := range mean {
+		mean[i] /= float32(len(vecs))
+	}
+	return mean
+}
@@ -0,0 +1,101 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Supported embedding providers
+const (
+	ProviderOllama = "ollama"
+	ProviderOpenAI = "openai"
+)
+
+// Embedder generates embeddings for text
+// Implemented by the Ollama Client and the OpenAIClient, and by FakeEmbedder
+// for tests
+type Embedder interface {
+	// Embed generates an embedding for a single text string
+	// Cancelling ctx aborts the in-flight request
+	Embed(ctx context.Context, text string) ([]float32, error)
+
+	// EmbedBatch generates embeddings for multiple texts in a single request
+	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
+
+	// Health checks that the provider is reachable and the model is available
+	Health() error
+
+	// Model returns the model name, recorded alongside generated vectors
+	Model() string
+}
+
+// NewEmbedder creates an embedder for the given provider
+// timeout bounds each request, overriding the client's model-based default
+// unless it's 0. The OpenAI provider reads its API key from the
+// OPENAI_API_KEY env var.
+func NewEmbedder(provider, baseURL, model string, timeout time.Duration) (Embedder, error) {
+	switch provider {
+	case ProviderOllama:
+		client := NewClient(baseURL, model)
+		client.SetTimeout(timeout)
+		return client, nil
+	case ProviderOpenAI:
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("OPENAI_API_KEY environment variable required for the openai provider")
+		}
+		client := NewOpenAIClient(baseURL, model, apiKey)
+		client.SetTimeout(timeout)
+		return client, nil
+	default:
+		return nil, fmt.Errorf("unknown embedding provider '%s' (supported: ollama, openai)", provider)
+	}
+}
+
+// ProviderName returns the provider an embedder talks to, "" if unknown
+func ProviderName(e Embedder) string {
+	switch e.(type) {
+	case *Client:
+		return ProviderOllama
+	case *OpenAIClient:
+		return ProviderOpenAI
+	}
+	return ""
+}
+
+// GetDefaultURL returns the default API base URL for a provider
+func GetDefaultURL(provider string) string {
+	switch provider {
+	case ProviderOpenAI:
+		return "https://api.openai.com"
+	default:
+		return "http://localhost:11434"
+	}
+}
+
+// GetDefaultModel returns the default embedding model for a provider
+func GetDefaultModel(provider string) string {
+	switch provider {
+	case ProviderOpenAI:
+		return "text-embedding-3-small"
+	default:
+		return "nomic-embed-text"
+	}
+}
+
+// GetModelName resolves a CLI model name to the provider's model name
+// "nomic" selects the provider's default model and "qwen" selects
+// qwen3-embedding on Ollama; any other name is passed through unchanged
+func GetModelName(provider, model string) string {
+	switch model {
+	case "", "nomic":
+		return GetDefaultModel(provider)
+	case "qwen":
+		if provider == ProviderOllama {
+			return "qwen3-embedding"
+		}
+	}
+	return model
+}
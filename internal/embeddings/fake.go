@@ -0,0 +1,77 @@
+package embeddings
+
+import (
+	"context"
+	"hash/fnv"
+	"strings"
+	"unicode"
+)
+
+// FakeEmbedderModel is the model name FakeEmbedder reports
+const FakeEmbedderModel = "fake-embed"
+
+// FakeEmbedder is an Embedder that needs no model server, for exercising
+// semantic and hybrid search in tests
+// Each lowercased word of a text is hashed into one of Dimensions buckets and
+// the counts normalized, so the same text always gets the same vector and
+// texts sharing words score higher against each other than unrelated ones.
+type FakeEmbedder struct {
+	Dimensions int
+	Err        error // Returned by every call when set, to test failure handling
+}
+
+// NewFakeEmbedder creates a FakeEmbedder producing vectors of dimensions floats
+func NewFakeEmbedder(dimensions int) *FakeEmbedder {
+	return &FakeEmbedder{Dimensions: dimensions}
+}
+
+// Embed returns text's hashed bag-of-words vector
+// Text with no words gets an all-zero vector.
+func (f *FakeEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if f.Err != nil {
+		return nil, f.Err
+	}
+
+	vec := make([]float32, f.Dimensions)
+	if f.Dimensions == 0 {
+		return vec, nil
+	}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		h := fnv.New32a()
+		h.Write([]byte(word))
+		vec[h.Sum32()%uint32(f.Dimensions)]++
+	}
+	if unit := NormalizeEmbedding(vec); unit != nil {
+		return unit, nil
+	}
+	return vec, nil
+}
+
+// EmbedBatch embeds each text in turn
+func (f *FakeEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	vecs := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec, err := f.Embed(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		vecs[i] = vec
+	}
+	return vecs, nil
+}
+
+// Health returns Err, so a FakeEmbedder is healthy unless set to fail
+func (f *FakeEmbedder) Health() error {
+	return f.Err
+}
+
+// Model returns FakeEmbedderModel
+func (f *FakeEmbedder) Model() string {
+	return FakeEmbedderModel
+}
@@ -2,12 +2,15 @@ package embeddings
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
 	"math"
 	"net/http"
+	"path"
+	"strings"
 	"time"
 )
 
@@ -39,6 +42,13 @@ func NewClient(baseURL, model string) *Client {
 	}
 }
 
+// SetTimeout overrides the model-based request timeout (0 keeps it)
+func (c *Client) SetTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		c.client.Timeout = timeout
+	}
+}
+
 // embedRequest is the request format for Ollama's /api/embed endpoint
 type embedRequest struct {
 	Model string   `json:"model"`
@@ -52,7 +62,7 @@ type embedResponse struct {
 }
 
 // Embed generates an embedding for a single text string
-func (c *Client) Embed(text string) ([]float32, error) {
+func (c *Client) Embed(ctx context.Context, text string) ([]float32, error) {
 	if text == "" {
 		return nil, fmt.Errorf("text cannot be empty")
 	}
@@ -69,7 +79,13 @@ func (c *Client) Embed(text string) ([]float32, error) {
 	}
 
 	// Make HTTP request
-	resp, err := c.client.Post(c.baseURL+"/api/embed", "application/json", bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("http request: %w", err)
 	}
@@ -96,7 +112,7 @@ func (c *Client) Embed(text string) ([]float32, error) {
 
 // EmbedBatch generates embeddings for multiple text strings in a single request
 // This is more efficient than calling Embed() multiple times
-func (c *Client) EmbedBatch(texts []string) ([][]float32, error) {
+func (c *Client) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
 	if len(texts) == 0 {
 		return nil, fmt.Errorf("texts cannot be empty")
 	}
@@ -113,7 +129,13 @@ func (c *Client) EmbedBatch(texts []string) ([][]float32, error) {
 	}
 
 	// Make HTTP request
-	resp, err := c.client.Post(c.baseURL+"/api/embed", "application/json", bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/embed", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("http request: %w", err)
 	}
@@ -162,8 +184,53 @@ func DeserializeEmbedding(data []byte) []float32 {
 	return vec
 }
 
+// unitTolerance is how far a squared norm may be from 1 for a vector to count
+// as already normalized (float32 rounding after normalizing stays well inside)
+const unitTolerance = 1e-4
+
+// NormalizeEmbedding scales a vector to unit length (L2 norm 1), so cosine
+// similarity against other normalized vectors is just DotProduct
+// Vectors already of unit length are returned as is; returns nil for empty
+// or all-zero vectors, which have no direction to compare
+func NormalizeEmbedding(vec []float32) []float32 {
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 || math.IsNaN(norm) || math.IsInf(norm, 0) {
+		return nil
+	}
+	if math.Abs(norm-1) < unitTolerance {
+		return vec
+	}
+
+	inv := float32(1 / math.Sqrt(norm))
+	out := make([]float32, len(vec))
+	for i, v := range vec {
+		out[i] = v * inv
+	}
+	return out
+}
+
+// DotProduct computes the dot product of two vectors, which equals their
+// cosine similarity when both are normalized (see NormalizeEmbedding)
+// Returns 0 if the dimensions differ
+func DotProduct(a, b []float32) float32 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
 // CosineSimilarity computes the cosine similarity between two vectors
 // Returns a value between -1 and 1, where 1 means identical direction
+// For vectors stored by embed or sync, which are normalized, DotProduct
+// gives the same result without computing norms
 func CosineSimilarity(a, b []float32) float32 {
 	if len(a) != len(b) {
 		return 0
@@ -206,27 +273,58 @@ func (c *Client) Health() error {
 		return fmt.Errorf("decode tags response: %w", err)
 	}
 
-	// Get base model name (strip tag suffix like :latest)
-	wantedModel := stripModelTag(c.model)
-
-	// Check if our model is available (compare base names without tags)
-	for _, model := range tagsResp.Models {
-		if stripModelTag(model.Name) == wantedModel {
-			return nil // Model is available
-		}
+	names := make([]string, len(tagsResp.Models))
+	for i, model := range tagsResp.Models {
+		names[i] = model.Name
+	}
+	if hasModel(names, c.model) {
+		return nil
 	}
 
 	return fmt.Errorf("model %s not found (run: ollama pull %s)", c.model, c.model)
 }
 
-// stripModelTag removes the tag suffix from a model name (e.g., "model:latest" -> "model")
-func stripModelTag(modelName string) string {
-	if idx := len(modelName) - 1; idx >= 0 {
-		for i := 0; i <= idx; i++ {
-			if modelName[i] == ':' {
-				return modelName[:i]
-			}
+// Model returns the Ollama model name
+func (c *Client) Model() string {
+	return c.model
+}
+
+// hasModel reports whether an installed model (as listed by /api/tags) is
+// the wanted one. Names are compared without tags and default registry
+// prefixes ("nomic-embed-text" matches "library/nomic-embed-text:latest");
+// failing that, the last path segments are compared, and finally the wanted
+// name may be a prefix of an installed one ("nomic-embed-text" matches
+// "hf.co/nomic-ai/nomic-embed-text-v1.5:q8").
+func hasModel(installed []string, wanted string) bool {
+	want := normalizeModelName(wanted)
+	wantBase := path.Base(want)
+	for _, name := range installed {
+		if normalizeModelName(name) == want {
+			return true
 		}
 	}
-	return modelName
+	for _, name := range installed {
+		if path.Base(normalizeModelName(name)) == wantBase {
+			return true
+		}
+	}
+	for _, name := range installed {
+		if strings.HasPrefix(path.Base(normalizeModelName(name)), wantBase) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeModelName lowercases a model name and strips its tag and the
+// default registry's prefixes: "registry.ollama.ai/library/qwen3-embedding:8b"
+// becomes "qwen3-embedding". A registry port ("host:5000/model") isn't a tag.
+func normalizeModelName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name = name[:i]
+	}
+	name = strings.TrimPrefix(name, "registry.ollama.ai/")
+	name = strings.TrimPrefix(name, "library/")
+	return name
 }
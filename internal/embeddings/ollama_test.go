@@ -0,0 +1,89 @@
+package embeddings
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestNormalizeModelName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"nomic-embed-text", "nomic-embed-text"},
+		{"nomic-embed-text:latest", "nomic-embed-text"},
+		{"library/nomic-embed-text:latest", "nomic-embed-text"},
+		{"registry.ollama.ai/library/qwen3-embedding:8b", "qwen3-embedding"},
+		{"  Qwen3-Embedding:8B ", "qwen3-embedding"},
+		{"hf.co/user/model:q8", "hf.co/user/model"},
+		{"localhost:5000/model", "localhost:5000/model"},
+		{"localhost:5000/team/model:v2", "localhost:5000/team/model"},
+	}
+	for _, tt := range tests {
+		if got := normalizeModelName(tt.name); got != tt.want {
+			t.Errorf("normalizeModelName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHasModel(t *testing.T) {
+	tests := []struct {
+		desc      string
+		installed []string
+		wanted    string
+		want      bool
+	}{
+		{"exact", []string{"nomic-embed-text"}, "nomic-embed-text", true},
+		{"latest tag", []string{"nomic-embed-text:latest"}, "nomic-embed-text", true},
+		{"library prefix", []string{"library/nomic-embed-text:latest"}, "nomic-embed-text:latest", true},
+		{"default registry", []string{"registry.ollama.ai/library/qwen3-embedding:8b"}, "qwen3-embedding", true},
+		{"hugging face", []string{"hf.co/user/model:q8"}, "hf.co/user/model", true},
+		{"hugging face by base name", []string{"hf.co/user/model:q8"}, "model", true},
+		{"registry port", []string{"localhost:5000/model:v1"}, "localhost:5000/model", true},
+		{"registry port by base name", []string{"localhost:5000/model"}, "model", true},
+		{"prefix fallback", []string{"mxbai-embed-large-v1:latest"}, "mxbai-embed-large", true},
+		{"missing", []string{"llama3:8b", "hf.co/user/other:q8"}, "nomic-embed-text", false},
+		{"nothing installed", nil, "nomic-embed-text", false},
+	}
+	for _, tt := range tests {
+		if got := hasModel(tt.installed, tt.wanted); got != tt.want {
+			t.Errorf("%s: hasModel(%q, %q) = %v, want %v", tt.desc, tt.installed, tt.wanted, got, tt.want)
+		}
+	}
+}
+
+func TestDotProductOfNormalizedMatchesCosine(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for n := 0; n < 100; n++ {
+		a := make([]float32, 1+rng.IntN(768))
+		b := make([]float32, len(a))
+		for i := range a {
+			a[i] = float32(rng.NormFloat64() * 10)
+			b[i] = float32(rng.NormFloat64())
+		}
+
+		cosine := CosineSimilarity(a, b)
+		dot := DotProduct(NormalizeEmbedding(a), NormalizeEmbedding(b))
+		if math.Abs(float64(dot-cosine)) > 1e-5 {
+			t.Errorf("%d dimensions: dot product of normalized vectors = %f, cosine similarity = %f", len(a), dot, cosine)
+		}
+	}
+}
+
+func TestNormalizeEmbedding(t *testing.T) {
+	if vec := NormalizeEmbedding([]float32{0, 0, 0}); vec != nil {
+		t.Errorf("all-zero vector normalized to %v, want nil", vec)
+	}
+	if vec := NormalizeEmbedding(nil); vec != nil {
+		t.Errorf("empty vector normalized to %v, want nil", vec)
+	}
+
+	vec := NormalizeEmbedding([]float32{3, 4})
+	if len(vec) != 2 || math.Abs(float64(vec[0])-0.6) > 1e-6 || math.Abs(float64(vec[1])-0.8) > 1e-6 {
+		t.Errorf("NormalizeEmbedding([3 4]) = %v, want [0.6 0.8]", vec)
+	}
+	if again := NormalizeEmbedding(vec); &again[0] != &vec[0] {
+		t.Error("a unit vector was copied rather than returned as is")
+	}
+}
@@ -0,0 +1,157 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// OpenAIClient is an embedding client for the OpenAI /v1/embeddings API
+type OpenAIClient struct {
+	baseURL string
+	model   string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewOpenAIClient creates a new OpenAI embedding client
+func NewOpenAIClient(baseURL, model, apiKey string) *OpenAIClient {
+	return &OpenAIClient{
+		baseURL: baseURL,
+		model:   model,
+		apiKey:  apiKey,
+		client: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+// SetTimeout overrides the default 60s request timeout (0 keeps it)
+func (c *OpenAIClient) SetTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		c.client.Timeout = timeout
+	}
+}
+
+// openAIEmbedRequest is the request format for /v1/embeddings
+type openAIEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// openAIEmbedResponse is the response format from /v1/embeddings
+type openAIEmbedResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed generates an embedding for a single text string
+func (c *OpenAIClient) Embed(ctx context.Context, text string) ([]float32, error) {
+	if text == "" {
+		return nil, fmt.Errorf("text cannot be empty")
+	}
+
+	vecs, err := c.EmbedBatch(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vecs[0], nil
+}
+
+// EmbedBatch generates embeddings for multiple text strings in a single request
+func (c *OpenAIClient) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, fmt.Errorf("texts cannot be empty")
+	}
+
+	body, err := json.Marshal(openAIEmbedRequest{Model: c.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/v1/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("openai error (status %d): %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var embedResp openAIEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	if len(embedResp.Data) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embedResp.Data))
+	}
+
+	// Results carry their input index; don't rely on response order
+	sort.Slice(embedResp.Data, func(i, j int) bool {
+		return embedResp.Data[i].Index < embedResp.Data[j].Index
+	})
+
+	vecs := make([][]float32, len(embedResp.Data))
+	for i, d := range embedResp.Data {
+		vecs[i] = d.Embedding
+	}
+	return vecs, nil
+}
+
+// Model returns the OpenAI model name
+func (c *OpenAIClient) Model() string {
+	return c.model
+}
+
+// Health checks that the API key is valid and the model is available
+func (c *OpenAIClient) Health() error {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/v1/models", nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("openai not available: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("openai returned status %d", resp.StatusCode)
+	}
+
+	var modelsResp struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&modelsResp); err != nil {
+		return fmt.Errorf("decode models response: %w", err)
+	}
+
+	for _, model := range modelsResp.Data {
+		if model.ID == c.model {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("model %s not available to this API key", c.model)
+}
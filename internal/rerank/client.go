@@ -0,0 +1,117 @@
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/renderinc/slab-search/internal/search"
+)
+
+// DefaultURL is Cohere's rerank endpoint
+const DefaultURL = "https://api.cohere.com/v2/rerank"
+
+// DefaultModel is the Cohere rerank model used when none is configured
+const DefaultModel = "rerank-v3.5"
+
+// Client reranks search results with a cross-encoder behind a Cohere-style
+// /rerank endpoint: Cohere itself, or local servers with the same API
+// (Infinity, llama.cpp, vLLM, ...)
+type Client struct {
+	url    string
+	model  string
+	apiKey string
+	client *http.Client
+}
+
+// NewClient creates a rerank client posting to url (the full endpoint URL)
+// apiKey is sent as a bearer token; local servers usually don't need one.
+func NewClient(url, model, apiKey string) *Client {
+	return &Client{
+		url:    url,
+		model:  model,
+		apiKey: apiKey,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// rerankRequest is the request format for /rerank
+type rerankRequest struct {
+	Model     string   `json:"model,omitempty"`
+	Query     string   `json:"query"`
+	Documents []string `json:"documents"`
+	TopN      int      `json:"top_n"`
+}
+
+// rerankResponse is the response format from /rerank
+type rerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+// Rerank sends the query and each result's title and snippets to the
+// reranker and returns the results ordered by its relevance scores
+// Results the reranker leaves out are dropped, and the request is canceled
+// once ctx is done.
+func (c *Client) Rerank(ctx context.Context, query string, docs []*search.SearchResult) ([]*search.SearchResult, error) {
+	if len(docs) == 0 {
+		return docs, nil
+	}
+
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = search.RerankText(doc)
+	}
+
+	body, err := json.Marshal(rerankRequest{Model: c.model, Query: query, Documents: texts, TopN: len(texts)})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("reranker error (status %d): %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	var rerankResp rerankResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rerankResp); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	reranked := make([]*search.SearchResult, 0, len(rerankResp.Results))
+	seen := make(map[int]bool, len(rerankResp.Results))
+	for _, r := range rerankResp.Results {
+		if r.Index < 0 || r.Index >= len(docs) || seen[r.Index] {
+			return nil, fmt.Errorf("reranker returned invalid document index %d", r.Index)
+		}
+		seen[r.Index] = true
+
+		doc := docs[r.Index]
+		doc.Score = r.RelevanceScore
+		reranked = append(reranked, doc)
+	}
+	return reranked, nil
+}
@@ -0,0 +1,35 @@
+package rerank
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/renderinc/slab-search/internal/search"
+)
+
+func TestRerankStopsWhenContextIsDone(t *testing.T) {
+	// A reranker slower than the search deadline
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	client := NewClient(srv.URL, DefaultModel, "")
+	start := time.Now()
+	_, err := client.Rerank(ctx, "postgres", []*search.SearchResult{{ID: "p1", Title: "Postgres backups"}})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Rerank returned after %v, long after the deadline", elapsed)
+	}
+}
@@ -0,0 +1,73 @@
+package search
+
+import (
+	"fmt"
+
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/token/porter"
+	"github.com/blevesearch/bleve/v2/analysis/token/stop"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/v2/analysis/tokenmap"
+	"github.com/blevesearch/bleve/v2/mapping"
+)
+
+const (
+	// textAnalyzerName is the English analyzer customized with deployment
+	// synonyms and/or stopwords, used for Title and Content when either is set
+	textAnalyzerName = "en_custom"
+
+	stopwordsMapName    = "stopwords"
+	stopwordsFilterName = "stopwords"
+)
+
+// addTextAnalyzer registers textAnalyzerName: the built-in en analyzer chain
+// with synonyms expanded after lowercasing (so they're stemmed like any other
+// word) and, if stopwords is non-nil, that list in place of the English one
+func addTextAnalyzer(indexMapping *mapping.IndexMappingImpl, synonyms [][]string, stopwords []string) error {
+	filters := []string{en.PossessiveName, lowercase.Name}
+
+	if len(synonyms) > 0 {
+		if err := indexMapping.AddCustomTokenFilter(synonymFilterName, map[string]interface{}{
+			"type":     synonymFilterType,
+			"synonyms": synonyms,
+		}); err != nil {
+			return fmt.Errorf("register synonym filter: %w", err)
+		}
+		filters = append(filters, synonymFilterName)
+	}
+
+	if stopwords == nil {
+		filters = append(filters, en.StopName)
+	} else if len(stopwords) > 0 {
+		tokens := make([]interface{}, len(stopwords))
+		for i, word := range stopwords {
+			tokens[i] = word
+		}
+		if err := indexMapping.AddCustomTokenMap(stopwordsMapName, map[string]interface{}{
+			"type":   tokenmap.Name,
+			"tokens": tokens,
+		}); err != nil {
+			return fmt.Errorf("register stopword list: %w", err)
+		}
+		if err := indexMapping.AddCustomTokenFilter(stopwordsFilterName, map[string]interface{}{
+			"type":           stop.Name,
+			"stop_token_map": stopwordsMapName,
+		}); err != nil {
+			return fmt.Errorf("register stopword filter: %w", err)
+		}
+		filters = append(filters, stopwordsFilterName)
+	}
+
+	filters = append(filters, porter.Name)
+
+	if err := indexMapping.AddCustomAnalyzer(textAnalyzerName, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     unicode.Name,
+		"token_filters": filters,
+	}); err != nil {
+		return fmt.Errorf("register text analyzer: %w", err)
+	}
+	return nil
+}
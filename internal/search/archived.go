@@ -0,0 +1,75 @@
+package search
+
+import (
+	"fmt"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+// archivedIndex is an in-memory index of the archived documents, which the
+// on-disk index leaves out; built on demand for IncludeArchived searches
+type archivedIndex struct {
+	index      bleve.Index
+	ids        map[string]bool
+	generation uint64 // Index generation it was built at
+}
+
+// withArchived returns an index covering both the live and the archived
+// documents, and the set of archived IDs for marking results
+// The archived index is rebuilt from the database once the index generation
+// moves on (a sync may have archived or restored documents).
+func (i *Index) withArchived(live bleve.Index) (bleve.Index, map[string]bool, error) {
+	if i.db == nil {
+		return nil, nil, fmt.Errorf("archived search needs the database (see SetDB)")
+	}
+
+	i.archivedMu.Lock()
+	defer i.archivedMu.Unlock()
+
+	generation := i.Generation()
+	if i.archived == nil || i.archived.generation != generation {
+		archived, err := i.buildArchivedIndex(live, generation)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i.archived != nil {
+			i.archived.index.Close()
+		}
+		i.archived = archived
+	}
+
+	return bleve.NewIndexAlias(live, i.archived.index), i.archived.ids, nil
+}
+
+// buildArchivedIndex indexes the archived documents in memory, analyzed with
+// the on-disk index's mapping so queries match them the same way
+func (i *Index) buildArchivedIndex(live bleve.Index, generation uint64) (*archivedIndex, error) {
+	docs, err := i.db.List(true)
+	if err != nil {
+		return nil, fmt.Errorf("list documents: %w", err)
+	}
+
+	index, err := bleve.NewMemOnly(live.Mapping())
+	if err != nil {
+		return nil, fmt.Errorf("create archived index: %w", err)
+	}
+
+	ids := make(map[string]bool)
+	batch := index.NewBatch()
+	for _, doc := range docs {
+		if doc.ArchivedAt == nil {
+			continue
+		}
+		ids[doc.ID] = true
+		if err := batch.Index(doc.ID, NewIndexedDocument(doc)); err != nil {
+			index.Close()
+			return nil, fmt.Errorf("batch index %s: %w", doc.ID, err)
+		}
+	}
+	if err := index.Batch(batch); err != nil {
+		index.Close()
+		return nil, fmt.Errorf("commit archived batch: %w", err)
+	}
+
+	return &archivedIndex{index: index, ids: ids, generation: generation}, nil
+}
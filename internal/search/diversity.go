@@ -0,0 +1,103 @@
+package search
+
+import "fmt"
+
+// diversify re-ranks results with Maximal Marginal Relevance so near-duplicate
+// documents don't crowd out the rest of a page
+// It runs search for a candidate pool 3x the requested depth, then greedily
+// picks the candidate maximizing (1-diversity)*relevance - diversity*redundancy,
+// where redundancy is the highest cosine similarity to an already-picked
+// document. Relevance is min-max normalized so the trade-off doesn't depend on
+// the score scale.
+func (i *Index) diversify(opts SearchOptions, useQwen bool, search func(SearchOptions) (*SearchResults, error)) (*SearchResults, error) {
+	if opts.Diversity < 0 || opts.Diversity > 1 {
+		return nil, fmt.Errorf("diversity must be between 0 and 1")
+	}
+
+	candidateOpts := SearchOptions{
+		Limit:    (opts.Offset + opts.Limit) * 3,
+		Filter:   opts.Filter,
+		MinScore: opts.MinScore,
+		Reranker: opts.Reranker,
+		RawQuery: opts.RawQuery,
+		Field:    opts.Field,
+
+		IncludeArchived: opts.IncludeArchived,
+		HideDuplicates:  opts.HideDuplicates,
+		Explain:         opts.Explain,
+	}
+	pool, err := search(candidateOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := pool.Hits
+	relevance := normalizeScores(candidates)
+	vectors := i.resultVectors(candidates, useQwen)
+	lambda := 1 - opts.Diversity
+
+	// redundancy[c] is candidate c's max similarity to the selected results
+	redundancy := make([]float32, len(candidates))
+	picked := make([]bool, len(candidates))
+	depth := min(opts.Offset+opts.Limit, len(candidates))
+	selected := make([]*SearchResult, 0, depth)
+
+	for len(selected) < depth {
+		best, bestScore := -1, 0.0
+		for c, result := range candidates {
+			if picked[c] {
+				continue
+			}
+			score := lambda*relevance[result.ID] - opts.Diversity*float64(redundancy[c])
+			if best < 0 || score > bestScore {
+				best, bestScore = c, score
+			}
+		}
+
+		picked[best] = true
+		selected = append(selected, candidates[best])
+
+		// Candidates without vectors are never considered redundant
+		if vectors[best] == nil {
+			continue
+		}
+		for c := range candidates {
+			if picked[c] || vectors[c] == nil || len(vectors[c]) != len(vectors[best]) {
+				continue
+			}
+			redundancy[c] = max(redundancy[c], dot(vectors[c], vectors[best]))
+		}
+	}
+
+	start := min(opts.Offset, len(selected))
+	return &SearchResults{Hits: selected[start:], TotalHits: pool.TotalHits, TotalCapped: pool.TotalCapped}, nil
+}
+
+// resultVectors loads the normalized document embedding for each result
+// (nil where a document has none or can't be loaded)
+func (i *Index) resultVectors(results []*SearchResult, useQwen bool) [][]float32 {
+	vectors := make([][]float32, len(results))
+	if i.db == nil {
+		return vectors
+	}
+
+	for r, result := range results {
+		doc, err := i.db.Get(result.ID)
+		if err != nil {
+			continue
+		}
+
+		embeddingData := doc.Embedding
+		if useQwen {
+			embeddingData = doc.EmbeddingQwen
+		}
+		if len(embeddingData) == 0 {
+			continue
+		}
+
+		if vec := i.vectors.get(doc.ID, useQwen, versionOf(doc), embeddingData); vec != nil {
+			vectors[r] = vec
+		}
+	}
+	return vectors
+}
@@ -0,0 +1,200 @@
+package search
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"runtime"
+	"slices"
+	"sync"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/renderinc/slab-search/internal/embeddings"
+	"github.com/renderinc/slab-search/internal/storage"
+)
+
+// DefaultDuplicateThreshold is the cosine similarity above which dedupe
+// treats two documents as copies of each other
+const DefaultDuplicateThreshold = 0.97
+
+// DuplicateCluster is a group of near-identical documents from FindDuplicates
+type DuplicateCluster struct {
+	Canonical  *storage.Document
+	Duplicates []*Duplicate // Most similar to the canonical document first
+}
+
+// Duplicate is a document in a DuplicateCluster other than the canonical one
+type Duplicate struct {
+	Doc        *storage.Document
+	Similarity float64 // Cosine similarity to the canonical document
+}
+
+// FindDuplicates groups documents whose embeddings (qwen's if useQwen) have
+// a cosine similarity of at least threshold, chaining pairs into clusters
+// The canonical document of each cluster is the one published first, since
+// copies and forks come after the original. Documents without an embedding
+// are ignored, as are pairs whose dimensions differ. Every pair is compared,
+// so this takes a while on a large corpus; it stops early once ctx is done.
+// Clusters are returned largest first.
+func FindDuplicates(ctx context.Context, docs []*storage.Document, useQwen bool, threshold float64) ([]*DuplicateCluster, error) {
+	var embedded []*storage.Document
+	var vecs [][]float32
+	for _, doc := range docs {
+		data := doc.Embedding
+		if useQwen {
+			data = doc.EmbeddingQwen
+		}
+		if vec := embeddings.NormalizeEmbedding(embeddings.DeserializeEmbedding(data)); vec != nil {
+			embedded = append(embedded, doc)
+			vecs = append(vecs, vec)
+		}
+	}
+
+	pairs, err := similarPairs(ctx, vecs, float32(threshold))
+	if err != nil {
+		return nil, err
+	}
+
+	// Union-find over the similar pairs
+	parent := make([]int, len(vecs))
+	for n := range parent {
+		parent[n] = n
+	}
+	var find func(int) int
+	find = func(n int) int {
+		if parent[n] != n {
+			parent[n] = find(parent[n])
+		}
+		return parent[n]
+	}
+	for _, p := range pairs {
+		parent[find(p[0])] = find(p[1])
+	}
+
+	members := make(map[int][]int)
+	for n := range vecs {
+		root := find(n)
+		members[root] = append(members[root], n)
+	}
+
+	var clusters []*DuplicateCluster
+	for _, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+		slices.SortFunc(group, func(a, b int) int {
+			if c := embedded[a].PublishedAt.Compare(embedded[b].PublishedAt); c != 0 {
+				return c
+			}
+			return cmp.Compare(embedded[a].ID, embedded[b].ID)
+		})
+
+		canonical := group[0]
+		cluster := &DuplicateCluster{Canonical: embedded[canonical]}
+		for _, n := range group[1:] {
+			cluster.Duplicates = append(cluster.Duplicates, &Duplicate{
+				Doc:        embedded[n],
+				Similarity: float64(dot(vecs[canonical], vecs[n])),
+			})
+		}
+		slices.SortFunc(cluster.Duplicates, func(a, b *Duplicate) int {
+			return cmp.Compare(b.Similarity, a.Similarity)
+		})
+		clusters = append(clusters, cluster)
+	}
+
+	slices.SortFunc(clusters, func(a, b *DuplicateCluster) int {
+		if c := cmp.Compare(len(b.Duplicates), len(a.Duplicates)); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Canonical.Title, b.Canonical.Title)
+	})
+	return clusters, nil
+}
+
+// similarPairs returns the index pairs of vecs (all unit length) whose dot
+// product is at least threshold, comparing rows on every CPU
+func similarPairs(ctx context.Context, vecs [][]float32, threshold float32) ([][2]int, error) {
+	rows := make(chan int)
+	found := make([][][2]int, runtime.NumCPU())
+
+	var wg sync.WaitGroup
+	for w := range found {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for a := range rows {
+				for b := a + 1; b < len(vecs); b++ {
+					if len(vecs[a]) == len(vecs[b]) && dot(vecs[a], vecs[b]) >= threshold {
+						found[w] = append(found[w], [2]int{a, b})
+					}
+				}
+			}
+		}()
+	}
+
+	var err error
+	for a := range vecs {
+		if err = searchCanceled(ctx); err != nil {
+			break
+		}
+		rows <- a
+	}
+	close(rows)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	var pairs [][2]int
+	for _, f := range found {
+		pairs = append(pairs, f...)
+	}
+	return pairs, nil
+}
+
+// duplicateIDs returns the IDs of the documents marked as duplicates
+func (i *Index) duplicateIDs() (map[string]string, error) {
+	if i.db == nil {
+		return nil, fmt.Errorf("hiding duplicates needs the database (see SetDB)")
+	}
+	dups, err := i.db.ListDuplicates()
+	if err != nil {
+		return nil, fmt.Errorf("list duplicates: %w", err)
+	}
+	return dups, nil
+}
+
+// withoutDuplicates excludes the marked duplicates from a keyword query
+func (i *Index) withoutDuplicates(q query.Query) (query.Query, error) {
+	dups, err := i.duplicateIDs()
+	if err != nil || len(dups) == 0 {
+		return q, err
+	}
+
+	ids := make([]string, 0, len(dups))
+	for id := range dups {
+		ids = append(ids, id)
+	}
+	without := bleve.NewBooleanQuery()
+	without.AddMust(q)
+	without.AddMustNot(bleve.NewDocIDQuery(ids))
+	return without, nil
+}
+
+// dropDuplicates filters the marked duplicates out of docs for semantic search
+func (i *Index) dropDuplicates(docs []*storage.Document) ([]*storage.Document, error) {
+	dups, err := i.duplicateIDs()
+	if err != nil || len(dups) == 0 {
+		return docs, err
+	}
+
+	kept := make([]*storage.Document, 0, len(docs))
+	for _, doc := range docs {
+		if _, ok := dups[doc.ID]; !ok {
+			kept = append(kept, doc)
+		}
+	}
+	return kept, nil
+}
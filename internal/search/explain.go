@@ -0,0 +1,120 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	bleveSearch "github.com/blevesearch/bleve/v2/search"
+)
+
+// Explanation breaks a result's score down into the parts that produced it
+// (see SearchOptions.Explain). Value is the score at this step; Children
+// are the inputs it was computed from.
+type Explanation struct {
+	Value    float64        `json:"value"`
+	Message  string         `json:"message"`
+	Children []*Explanation `json:"children,omitempty"`
+}
+
+// String renders the explanation as an indented tree, one step per line
+func (e *Explanation) String() string {
+	var b strings.Builder
+	e.write(&b, 0)
+	return b.String()
+}
+
+func (e *Explanation) write(b *strings.Builder, depth int) {
+	fmt.Fprintf(b, "%s%.4f  %s\n", strings.Repeat("  ", depth), e.Value, e.Message)
+	for _, child := range e.Children {
+		child.write(b, depth+1)
+	}
+}
+
+// bleveExplanation converts Bleve's scoring breakdown for a keyword hit
+// Its messages embed Bleve's binary internal document numbers, which are
+// dropped so the explanation prints cleanly.
+func bleveExplanation(expl *bleveSearch.Explanation) *Explanation {
+	if expl == nil {
+		return nil
+	}
+	message := strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, expl.Message)
+	e := &Explanation{Value: expl.Value, Message: message}
+	for _, child := range expl.Children {
+		if c := bleveExplanation(child); c != nil {
+			e.Children = append(e.Children, c)
+		}
+	}
+	return e
+}
+
+// cosineExplanation explains a semantic score
+func cosineExplanation(score float64, chunked bool) *Explanation {
+	msg := "cosine similarity of the query and document embeddings"
+	if chunked {
+		msg = "cosine similarity of the query embedding and the best-matching chunk"
+	}
+	return &Explanation{Value: score, Message: msg}
+}
+
+// explainStep records that a result's score was changed from its explained
+// value, keeping the earlier breakdown as the step's input
+// Does nothing for results searched without SearchOptions.Explain.
+func explainStep(result *SearchResult, message string) {
+	if result.Explanation == nil {
+		return
+	}
+	result.Explanation = &Explanation{
+		Value:    result.Score,
+		Message:  message,
+		Children: []*Explanation{result.Explanation},
+	}
+}
+
+// weightedExplanation explains a result's part of a linear hybrid score: its
+// min-max normalized score in one ranking times that ranking's weight
+// Returns nil unless the result was searched with SearchOptions.Explain.
+func weightedExplanation(ranking string, result *SearchResult, normalized, weight float64) *Explanation {
+	if result.Explanation == nil {
+		return nil
+	}
+	return &Explanation{
+		Value:    normalized * weight,
+		Message:  fmt.Sprintf("%s: normalized score %.4f × weight %.2f", ranking, normalized, weight),
+		Children: []*Explanation{result.Explanation},
+	}
+}
+
+// rankExplanation explains a result's reciprocal rank fusion contribution
+// from one ranking (rank counts from 0)
+// Returns nil unless the result was searched with SearchOptions.Explain.
+func rankExplanation(ranking string, result *SearchResult, rank int, contribution float64) *Explanation {
+	if result.Explanation == nil {
+		return nil
+	}
+	return &Explanation{
+		Value:    contribution,
+		Message:  fmt.Sprintf("%s: rank %d, 1/(%d+%d)", ranking, rank+1, rrfK, rank+1),
+		Children: []*Explanation{result.Explanation},
+	}
+}
+
+// hybridExplanation adds a component to a hybrid score's explanation,
+// starting one if sum is nil
+func hybridExplanation(sum, component *Explanation) *Explanation {
+	if component == nil {
+		return sum
+	}
+	if sum == nil {
+		sum = &Explanation{Message: "sum of:"}
+	}
+	sum.Value += component.Value
+	sum.Children = append(sum.Children, component)
+	return sum
+}
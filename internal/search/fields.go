@@ -0,0 +1,43 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// SearchFields are the names SearchOptions.Field accepts
+var SearchFields = []string{"title", "content", "comments", "author"}
+
+// searchFieldPaths maps each of SearchFields to its field in the index mapping
+var searchFieldPaths = map[string]string{
+	"title":    "Title",
+	"content":  "Content",
+	"comments": "Comments",
+	"author":   "Author",
+}
+
+// highlightFields are the fields keyword search returns highlighted
+// fragments for; Title lets results show why a title matched
+var highlightFields = []string{"Title", "Content", "Comments", "Author"}
+
+// CheckField validates a SearchOptions.Field value ("" searches all fields)
+func CheckField(field string) error {
+	if _, ok := searchFieldPaths[field]; ok || field == "" {
+		return nil
+	}
+	return fmt.Errorf("unknown field %q (want %s)", field, strings.Join(SearchFields, ", "))
+}
+
+// fieldQuery matches queryStr against a single field, analyzed the way the
+// field is indexed
+func fieldQuery(field, queryStr string) (query.Query, error) {
+	if err := CheckField(field); err != nil {
+		return nil, err
+	}
+	q := bleve.NewMatchQuery(queryStr)
+	q.SetField(searchFieldPaths[field])
+	return q, nil
+}
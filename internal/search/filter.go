@@ -0,0 +1,222 @@
+package search
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/blevesearch/bleve/v2"
+	bleveSearch "github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+
+	"github.com/renderinc/slab-search/internal/storage"
+)
+
+// Filter restricts search results by document metadata
+// Zero-valued bounds are ignored, so a nil or empty Filter matches everything
+type Filter struct {
+	PublishedAfter  time.Time
+	PublishedBefore time.Time
+	UpdatedAfter    time.Time
+	UpdatedBefore   time.Time
+
+	// Author matches case-insensitively; each word may be a prefix of a word
+	// in the author's name, so "doe" and "jane d" both match "Jane Doe"
+	Author string
+
+	// AuthorFuzziness (0-MaxAuthorFuzziness) also lets each Author word of 3+
+	// letters match a name word within this many edits, so "jnae" finds
+	// "Jane"; words of 4 letters or fewer allow at most 1. 0 disables it.
+	AuthorFuzziness int
+
+	// Topics requires documents to be tagged with every listed topic (exact names)
+	Topics []string
+
+	// EmbeddedAfter limits semantic candidates to documents embedded at or
+	// after this time (e.g., to validate a model migration); keyword search
+	// ignores it since embeddings aren't indexed in Bleve
+	EmbeddedAfter time.Time
+
+	// EmbeddingModel limits semantic candidates to documents whose vector in
+	// the searched field was produced by this provider model, so vectors from
+	// different models are never compared. Documents embedded before models
+	// were recorded don't match. Keyword search ignores it.
+	EmbeddingModel string
+}
+
+// MaxAuthorFuzziness is the largest Filter.AuthorFuzziness (Bleve's limit
+// for fuzzy queries)
+const MaxAuthorFuzziness = 2
+
+// empty reports whether the filter has no constraints
+func (f *Filter) empty() bool {
+	return f == nil || (f.PublishedAfter.IsZero() && f.PublishedBefore.IsZero() &&
+		f.UpdatedAfter.IsZero() && f.UpdatedBefore.IsZero() &&
+		f.Author == "" && len(f.Topics) == 0 && f.EmbeddedAfter.IsZero() &&
+		f.EmbeddingModel == "")
+}
+
+// query builds a Bleve query for the filter constraints
+// Returns nil if the filter has no constraints
+func (f *Filter) query() query.Query {
+	if f == nil {
+		return nil
+	}
+
+	var constraints []query.Query
+
+	if !f.PublishedAfter.IsZero() || !f.PublishedBefore.IsZero() {
+		q := bleve.NewDateRangeQuery(f.PublishedAfter, f.PublishedBefore)
+		q.SetField("PublishedAt")
+		constraints = append(constraints, q)
+	}
+
+	if !f.UpdatedAfter.IsZero() || !f.UpdatedBefore.IsZero() {
+		q := bleve.NewDateRangeQuery(f.UpdatedAfter, f.UpdatedBefore)
+		q.SetField("UpdatedAt")
+		constraints = append(constraints, q)
+	}
+
+	if terms := nameTerms(f.Author); len(terms) > 0 {
+		// Author uses the standard analyzer (lowercased words), so prefix
+		// queries on lowercased terms give case-insensitive partial matching
+		authorQueries := make([]query.Query, 0, len(terms))
+		for _, term := range terms {
+			prefix := bleve.NewPrefixQuery(term)
+			prefix.SetField("Author")
+
+			edits := authorEdits(term, f.AuthorFuzziness)
+			if edits == 0 {
+				authorQueries = append(authorQueries, prefix)
+				continue
+			}
+			fuzzy := bleve.NewFuzzyQuery(term)
+			fuzzy.SetField("Author")
+			fuzzy.SetFuzziness(edits)
+			authorQueries = append(authorQueries, bleve.NewDisjunctionQuery(prefix, fuzzy))
+		}
+		constraints = append(constraints, bleve.NewConjunctionQuery(authorQueries...))
+	}
+
+	for _, topic := range f.Topics {
+		q := bleve.NewTermQuery(topic)
+		q.SetField("Topics")
+		constraints = append(constraints, q)
+	}
+
+	if len(constraints) == 0 {
+		return nil
+	}
+
+	return bleve.NewConjunctionQuery(constraints...)
+}
+
+// matches reports whether a stored document satisfies the filter
+// Used by semantic search to drop candidates before scoring
+func (f *Filter) matches(doc *storage.Document) bool {
+	if f == nil {
+		return true
+	}
+
+	if !inRange(doc.PublishedAt, f.PublishedAfter, f.PublishedBefore) {
+		return false
+	}
+	if !inRange(doc.UpdatedAt, f.UpdatedAfter, f.UpdatedBefore) {
+		return false
+	}
+	if !authorMatches(doc.AuthorName, f.Author, f.AuthorFuzziness) {
+		return false
+	}
+	if len(f.Topics) > 0 && !hasTopics(doc.TopicNames(), f.Topics) {
+		return false
+	}
+	if !f.EmbeddedAfter.IsZero() && (doc.EmbeddedAt == nil || doc.EmbeddedAt.Before(f.EmbeddedAfter)) {
+		return false
+	}
+
+	return true
+}
+
+// embeddedWith reports whether a document's vector in the selected embedding
+// field satisfies the EmbeddingModel constraint
+func (f *Filter) embeddedWith(doc *storage.Document, useQwen bool) bool {
+	if f == nil || f.EmbeddingModel == "" {
+		return true
+	}
+	if useQwen {
+		return doc.EmbeddingQwenModel == f.EmbeddingModel
+	}
+	return doc.EmbeddingModel == f.EmbeddingModel
+}
+
+// authorMatches reports whether every word of the author filter is a prefix
+// of some word in name, or within its allowed edits (see authorEdits),
+// mirroring the keyword prefix and fuzzy queries
+func authorMatches(name, author string, fuzziness int) bool {
+	nameWords := nameTerms(name)
+	for _, term := range nameTerms(author) {
+		edits := authorEdits(term, fuzziness)
+		found := false
+		for _, word := range nameWords {
+			if strings.HasPrefix(word, term) || withinEdits(term, word, edits) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// authorEdits is the edit distance allowed for an author filter word:
+// fuzziness capped by maxEdits, and none for initials and 2-letter words,
+// which prefix matching already covers
+func authorEdits(term string, fuzziness int) int {
+	if fuzziness <= 0 || utf8.RuneCountInString(term) < 3 {
+		return 0
+	}
+	return min(fuzziness, maxEdits(term), MaxAuthorFuzziness)
+}
+
+// withinEdits reports whether a and b differ by at most edits (edits > 0)
+// insertions, deletions or substitutions, measured like spelling suggestions
+// (see closestTerm)
+func withinEdits(a, b string, edits int) bool {
+	if edits <= 0 {
+		return false
+	}
+	distance, tooFar := bleveSearch.LevenshteinDistanceMax(a, b, edits)
+	return !tooFar && distance <= edits
+}
+
+// hasTopics reports whether all wanted topics appear in topics
+func hasTopics(topics, wanted []string) bool {
+	for _, w := range wanted {
+		if !slices.Contains(topics, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// nameTerms splits a name into lowercased words
+func nameTerms(name string) []string {
+	return strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// inRange checks t against optional [after, before) bounds
+func inRange(t, after, before time.Time) bool {
+	if !after.IsZero() && t.Before(after) {
+		return false
+	}
+	if !before.IsZero() && !t.Before(before) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package search
+
+import (
+	"errors"
+
+	"github.com/renderinc/slab-search/internal/storage"
+)
+
+// SearchFTS runs a keyword search with the database's SQLite full-text index
+// instead of Bleve, for when the Bleve index can't be opened. Filters aren't
+// supported, and query syntax is limited to words, "phrases" and prefix*.
+func SearchFTS(db *storage.DB, query string, opts SearchOptions) (*SearchResults, error) {
+	if !opts.Filter.empty() {
+		return nil, errors.New("filters require the search index (run: slab-search reindex)")
+	}
+
+	hits, err := db.FTSSearch(query, opts.Offset+opts.Limit)
+	if err != nil {
+		return nil, err
+	}
+	total, err := db.FTSCount(query)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*SearchResult, 0, opts.Limit)
+	for i := opts.Offset; i < len(hits); i++ {
+		h := hits[i]
+		results = append(results, &SearchResult{
+			ID:        h.ID,
+			Title:     h.Title,
+			Author:    h.Author,
+			SlabURL:   h.SlabURL,
+			Topics:    h.Topics,
+			Score:     h.Score,
+			Fragments: h.Fragments,
+		})
+	}
+
+	return &SearchResults{Hits: results, TotalHits: uint64(total)}, nil
+}
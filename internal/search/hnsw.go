@@ -0,0 +1,233 @@
+package search
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// HNSW tuning parameters
+const (
+	hnswM              = 16  // Max neighbors per node on upper layers (2x on layer 0)
+	hnswEfConstruction = 100 // Candidate list size while building
+	hnswEfSearch       = 64  // Minimum candidate list size while searching
+)
+
+// hnswNode is a vector in the graph with its neighbor lists per layer
+type hnswNode struct {
+	id        string
+	vec       []float32 // L2-normalized
+	neighbors [][]int32
+}
+
+// hnsw is an in-memory Hierarchical Navigable Small World graph for
+// approximate nearest-neighbor search over L2-normalized vectors
+// Similarity is the dot product, which equals cosine similarity for unit vectors
+// Not safe for concurrent inserts; searches are read-only once built
+type hnsw struct {
+	dim       int
+	nodes     []*hnswNode
+	entry     int32 // Entry point (-1 when empty)
+	maxLevel  int
+	levelMult float64
+	rng       *rand.Rand
+}
+
+func newHNSW(dim int) *hnsw {
+	return &hnsw{
+		dim:       dim,
+		entry:     -1,
+		levelMult: 1 / math.Log(hnswM),
+		rng:       rand.New(rand.NewSource(1)), // Deterministic graph for a given insert order
+	}
+}
+
+// annHit is a search hit from the graph
+type annHit struct {
+	id    string
+	score float32
+}
+
+// insert adds a normalized vector to the graph
+func (h *hnsw) insert(id string, vec []float32) {
+	level := int(-math.Log(1-h.rng.Float64()) * h.levelMult)
+	node := &hnswNode{id: id, vec: vec, neighbors: make([][]int32, level+1)}
+	nodeIdx := int32(len(h.nodes))
+	h.nodes = append(h.nodes, node)
+
+	if h.entry < 0 {
+		h.entry = nodeIdx
+		h.maxLevel = level
+		return
+	}
+
+	// Greedily descend the layers above the node's level
+	cur := h.entry
+	curSim := dot(vec, h.nodes[cur].vec)
+	for l := h.maxLevel; l > level; l-- {
+		cur, curSim = h.greedy(vec, cur, curSim, l)
+	}
+
+	// Connect to the closest candidates on each layer the node lives on
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		candidates := h.searchLayer(vec, cur, hnswEfConstruction, l)
+		maxConn := maxConnections(l)
+
+		for _, c := range candidates[:min(len(candidates), hnswM)] {
+			node.neighbors[l] = append(node.neighbors[l], c.node)
+			h.link(c.node, nodeIdx, l, maxConn)
+		}
+
+		cur = candidates[0].node
+	}
+
+	if level > h.maxLevel {
+		h.maxLevel = level
+		h.entry = nodeIdx
+	}
+}
+
+// search returns up to k approximate nearest neighbors, most similar first
+func (h *hnsw) search(query []float32, k, ef int) []annHit {
+	if h.entry < 0 || k <= 0 {
+		return nil
+	}
+
+	cur := h.entry
+	curSim := dot(query, h.nodes[cur].vec)
+	for l := h.maxLevel; l > 0; l-- {
+		cur, curSim = h.greedy(query, cur, curSim, l)
+	}
+
+	candidates := h.searchLayer(query, cur, max(ef, k), 0)
+	hits := make([]annHit, 0, min(k, len(candidates)))
+	for _, c := range candidates[:min(k, len(candidates))] {
+		hits = append(hits, annHit{id: h.nodes[c.node].id, score: c.sim})
+	}
+	return hits
+}
+
+// greedy walks a single layer towards the query until no neighbor is closer
+func (h *hnsw) greedy(query []float32, cur int32, curSim float32, layer int) (int32, float32) {
+	for changed := true; changed; {
+		changed = false
+		for _, nb := range h.nodes[cur].neighbors[layer] {
+			if sim := dot(query, h.nodes[nb].vec); sim > curSim {
+				cur, curSim = nb, sim
+				changed = true
+			}
+		}
+	}
+	return cur, curSim
+}
+
+// searchLayer performs a best-first search on one layer
+// Returns up to ef candidates sorted by similarity (highest first)
+func (h *hnsw) searchLayer(query []float32, entry int32, ef int, layer int) []candidate {
+	visited := make([]bool, len(h.nodes))
+	visited[entry] = true
+
+	start := candidate{node: entry, sim: dot(query, h.nodes[entry].vec)}
+	frontier := &maxCandidateHeap{start}
+	results := &minCandidateHeap{start}
+
+	for frontier.Len() > 0 {
+		c := heap.Pop(frontier).(candidate)
+		if results.Len() >= ef && c.sim < (*results)[0].sim {
+			break // Closest unexplored candidate is worse than every result
+		}
+
+		for _, nb := range h.nodes[c.node].neighbors[layer] {
+			if visited[nb] {
+				continue
+			}
+			visited[nb] = true
+
+			sim := dot(query, h.nodes[nb].vec)
+			if results.Len() < ef || sim > (*results)[0].sim {
+				heap.Push(frontier, candidate{node: nb, sim: sim})
+				heap.Push(results, candidate{node: nb, sim: sim})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	sorted := make([]candidate, results.Len())
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(results).(candidate)
+	}
+	return sorted
+}
+
+// link adds a directed edge and prunes the neighbor list to the closest maxConn
+func (h *hnsw) link(from, to int32, layer, maxConn int) {
+	node := h.nodes[from]
+	node.neighbors[layer] = append(node.neighbors[layer], to)
+	if len(node.neighbors[layer]) <= maxConn {
+		return
+	}
+
+	neighbors := node.neighbors[layer]
+	sims := make(map[int32]float32, len(neighbors))
+	for _, nb := range neighbors {
+		sims[nb] = dot(node.vec, h.nodes[nb].vec)
+	}
+	sort.Slice(neighbors, func(i, j int) bool {
+		return sims[neighbors[i]] > sims[neighbors[j]]
+	})
+	node.neighbors[layer] = neighbors[:maxConn]
+}
+
+// maxConnections returns the neighbor limit for a layer
+func maxConnections(layer int) int {
+	if layer == 0 {
+		return 2 * hnswM
+	}
+	return hnswM
+}
+
+// candidate is a graph node with its similarity to the query
+type candidate struct {
+	node int32
+	sim  float32
+}
+
+// maxCandidateHeap pops the most similar candidate first
+type maxCandidateHeap []candidate
+
+func (h maxCandidateHeap) Len() int           { return len(h) }
+func (h maxCandidateHeap) Less(i, j int) bool { return h[i].sim > h[j].sim }
+func (h maxCandidateHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *maxCandidateHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *maxCandidateHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// minCandidateHeap pops the least similar candidate first
+type minCandidateHeap []candidate
+
+func (h minCandidateHeap) Len() int           { return len(h) }
+func (h minCandidateHeap) Less(i, j int) bool { return h[i].sim < h[j].sim }
+func (h minCandidateHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *minCandidateHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *minCandidateHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// dot computes the dot product of two equal-length vectors
+func dot(a, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
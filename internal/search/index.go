@@ -1,26 +1,64 @@
 package search
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/custom"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
+	"github.com/blevesearch/bleve/v2/analysis/token/edgengram"
+	"github.com/blevesearch/bleve/v2/analysis/token/lowercase"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
 	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
 
 	"github.com/renderinc/slab-search/internal/storage"
 )
 
 // Index wraps a Bleve search index
 type Index struct {
-	index bleve.Index
-	db    *storage.DB // For semantic search access to embeddings
+	indexMu sync.RWMutex // Held for reading while the index is in use, see acquire
+	index   bleve.Index
+	path    string        // On-disk location, used to recreate the index on rebuild
+	db      storage.Store // For semantic search access to embeddings
+
+	annMu sync.RWMutex
+	ann   *vectorIndex // Optional ANN accelerator for semantic search (nil until built)
+
+	vectors vectorCache // Decoded document vectors for brute-force semantic search
+
+	archivedMu sync.Mutex
+	archived   *archivedIndex // Archived documents for IncludeArchived searches (nil until one runs)
+
+	titleBoost  float64            // Score multiplier for keyword matches in the title
+	topicBoosts map[string]float64 // Hybrid score multipliers by topic name, see SetTopicBoosts
+
+	generation atomic.Uint64 // Bumped whenever indexed documents change, see Generation
+
+	mismatchWarned atomic.Uint64 // Generation+1 that the dimension mismatch warning was last logged at
+
+	// Analyzer customizations applied when the index is rebuilt
+	synonyms  [][]string
+	stopwords []string // nil uses the built-in English list
 }
 
+// DefaultTitleBoost makes keyword matches in a title count 3x a content match,
+// so a doc titled "Postgres Configuration" outranks passing mentions
+const DefaultTitleBoost = 3.0
+
 // IndexedDocument represents a document in the search index
 type IndexedDocument struct {
 	ID          string
 	Title       string
 	Content     string
+	Comments    string
 	Author      string
 	Topics      []string
 	PublishedAt time.Time
@@ -30,12 +68,108 @@ type IndexedDocument struct {
 
 // SearchResult represents a search result
 type SearchResult struct {
-	ID        string
-	Title     string
-	Author    string
-	SlabURL   string
-	Score     float64
-	Fragments map[string][]string // Highlighted snippets
+	ID        string              `json:"id"`
+	Title     string              `json:"title"`
+	Author    string              `json:"author"`
+	SlabURL   string              `json:"slab_url"`
+	Topics    []string            `json:"topics,omitempty"`
+	Archived  bool                `json:"archived,omitempty"` // Only with SearchOptions.IncludeArchived
+	Score     float64             `json:"score"`
+	Fragments map[string][]string `json:"fragments,omitempty"` // Highlighted snippets
+	Excerpt   string              `json:"excerpt,omitempty"`   // Start of the content, when the API's excerpt_len asks for it
+
+	Explanation *Explanation `json:"explanation,omitempty"` // How Score was computed, with SearchOptions.Explain
+}
+
+// SearchOptions controls paging and filtering for all search modes
+type SearchOptions struct {
+	Limit  int     // Maximum number of hits to return
+	Offset int     // Number of hits to skip (for pagination)
+	Filter *Filter // Optional metadata filter (nil matches everything)
+
+	// Diversity (0.0-1.0) re-ranks semantic and hybrid results with Maximal
+	// Marginal Relevance, trading relevance for less redundant results;
+	// 0 disables it. Keyword search ignores it.
+	Diversity float64
+
+	// MinScore drops semantic and hybrid results scoring below it before
+	// paging; 0 keeps everything. Scores are cosine similarity for semantic
+	// search and the merged 0-1 score for linear hybrid search; RRF scores
+	// are much smaller (at most 2/61), so HybridSearchRRF rejects it.
+	// Keyword search ignores it.
+	MinScore float64
+
+	// Reranker, if set, reorders the merged hybrid candidates before paging,
+	// replacing their scores with its own. Other modes ignore it.
+	Reranker Reranker
+
+	// Field restricts keyword matching to one of SearchFields ("" matches
+	// the title and all fields). Semantic search ignores it.
+	Field string
+
+	// RawQuery parses keyword queries with Bleve's query-string syntax
+	// (+must, -not, field:value, fuzzy~, boosts^); otherwise the text is
+	// matched literally apart from "quoted phrases". Invalid raw queries fail
+	// with ErrQuerySyntax.
+	RawQuery bool
+
+	// IncludeArchived also searches archived documents, which are otherwise
+	// left out of every mode; their results have Archived set. Semantic
+	// searches including them can't use the ANN index.
+	IncludeArchived bool
+
+	// HideDuplicates leaves out documents marked as near-duplicates of
+	// another (see FindDuplicates and dedupe -mark), so copies and forks
+	// don't crowd out other results. Semantic searches hiding them can't
+	// use the ANN index.
+	HideDuplicates bool
+
+	// Explain sets each result's Explanation: Bleve's scoring breakdown for
+	// keyword search, the cosine similarity for semantic search, and the
+	// weighted components (plus any topic boost or reranking) for hybrid.
+	// Bleve explanations make keyword searches slower.
+	Explain bool
+
+	// Sort orders results by relevance ("" or SortRelevance), or newest first
+	// by SortUpdated or SortPublished. Semantic and hybrid searches re-sort
+	// their best matches (see sortByDate).
+	Sort string
+}
+
+// SearchResults is one page of hits plus the total number of matches
+type SearchResults struct {
+	Hits      []*SearchResult
+	TotalHits uint64 // Matches across all pages, not just this one
+
+	// TotalCapped is set when TotalHits only counts a pool of the best
+	// candidates (hybrid merging, date sorting) and more documents matched,
+	// making it a lower bound
+	TotalCapped bool
+}
+
+// TotalText formats TotalHits for display, as "N+" when it's only a lower
+// bound
+func (r *SearchResults) TotalText() string {
+	if r.TotalCapped {
+		return fmt.Sprintf("%d+", r.TotalHits)
+	}
+	return fmt.Sprintf("%d", r.TotalHits)
+}
+
+// ErrSearchTimeout is returned when a search's context deadline passes
+// before it finishes
+var ErrSearchTimeout = errors.New("search timed out")
+
+// searchCanceled returns a descriptive error once ctx is done, nil otherwise
+func searchCanceled(ctx context.Context) error {
+	err := ctx.Err()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrSearchTimeout, err)
+	}
+	if err != nil {
+		return fmt.Errorf("search canceled: %w", err)
+	}
+	return nil
 }
 
 // Open opens or creates a Bleve index
@@ -47,7 +181,10 @@ func Open(path string) (*Index, error) {
 	idx, err = bleve.Open(path)
 	if err == bleve.ErrorIndexPathDoesNotExist {
 		// Create new index with custom mapping
-		indexMapping := buildIndexMapping()
+		indexMapping, err := buildIndexMapping(nil, nil)
+		if err != nil {
+			return nil, err
+		}
 		idx, err = bleve.New(path, indexMapping)
 		if err != nil {
 			return nil, fmt.Errorf("create index: %w", err)
@@ -56,81 +193,236 @@ func Open(path string) (*Index, error) {
 		return nil, fmt.Errorf("open index: %w", err)
 	}
 
-	return &Index{index: idx}, nil
+	return &Index{index: idx, path: path, titleBoost: DefaultTitleBoost}, nil
 }
 
 // buildIndexMapping creates a custom index mapping with improved analyzers
-func buildIndexMapping() mapping.IndexMapping {
+// synonyms: optional groups of interchangeable words expanded in Title and Content
+// stopwords: replaces the English stopword list for Title and Content (nil keeps it)
+func buildIndexMapping(synonyms [][]string, stopwords []string) (mapping.IndexMapping, error) {
+	customText := len(synonyms) > 0 || stopwords != nil
+	textAnalyzer := "en"
+	if customText {
+		textAnalyzer = textAnalyzerName
+	}
+
 	// Content field - use English analyzer for better stemming and stopword removal
 	contentFieldMapping := bleve.NewTextFieldMapping()
-	contentFieldMapping.Analyzer = "en"
+	contentFieldMapping.Analyzer = textAnalyzer
 
-	// Title field - use English analyzer (boost applied at query time)
+	// Title field - use English analyzer (boost applied at query time, see Search)
 	titleFieldMapping := bleve.NewTextFieldMapping()
-	titleFieldMapping.Analyzer = "en"
-
-	// Author field - keep default analyzer (good for names, no stemming)
+	titleFieldMapping.Analyzer = textAnalyzer
+
+	// TitlePrefix field - the title split into lowercased word prefixes
+	// (edge n-grams) so SuggestTitles can match partial words with term queries
+	titlePrefixFieldMapping := bleve.NewTextFieldMapping()
+	titlePrefixFieldMapping.Name = titlePrefixField
+	titlePrefixFieldMapping.Analyzer = titlePrefixAnalyzer
+	titlePrefixFieldMapping.Store = false
+	titlePrefixFieldMapping.IncludeInAll = false
+	titlePrefixFieldMapping.IncludeTermVectors = false
+
+	// Author field - standard analyzer (good for names, no stemming)
 	authorFieldMapping := bleve.NewTextFieldMapping()
+	authorFieldMapping.Analyzer = standard.Name
+
+	// Topics field - keyword analyzer (exact topic names, no stemming) for filtering and facets
+	topicsFieldMapping := bleve.NewKeywordFieldMapping()
+
+	// Date fields - indexed as datetimes so they can be range filtered
+	publishedFieldMapping := bleve.NewDateTimeFieldMapping()
+	updatedFieldMapping := bleve.NewDateTimeFieldMapping()
 
 	// Create document mapping
 	docMapping := bleve.NewDocumentMapping()
-	docMapping.AddFieldMappingsAt("ID", bleve.NewTextFieldMapping())
-	docMapping.AddFieldMappingsAt("Title", titleFieldMapping)
+	docMapping.AddFieldMappingsAt("ID", standardTextField())
+	docMapping.AddFieldMappingsAt("Title", titleFieldMapping, titlePrefixFieldMapping)
 	docMapping.AddFieldMappingsAt("Content", contentFieldMapping)
+	docMapping.AddFieldMappingsAt("Comments", contentFieldMapping) // Analyzed like Content
 	docMapping.AddFieldMappingsAt("Author", authorFieldMapping)
-	docMapping.AddFieldMappingsAt("SlabURL", bleve.NewTextFieldMapping())
+	docMapping.AddFieldMappingsAt("Topics", topicsFieldMapping)
+	docMapping.AddFieldMappingsAt("SlabURL", standardTextField())
+	docMapping.AddFieldMappingsAt("PublishedAt", publishedFieldMapping)
+	docMapping.AddFieldMappingsAt("UpdatedAt", updatedFieldMapping)
 
 	// Create index mapping
 	indexMapping := bleve.NewIndexMapping()
 	indexMapping.AddDocumentMapping("_default", docMapping)
 
-	return indexMapping
+	// Query strings search the composite _all field, which is analyzed with the
+	// default analyzer at query time; match Title/Content so stemmed and
+	// synonym-expanded terms line up ("postgres" finds indexed "postgr")
+	indexMapping.DefaultAnalyzer = textAnalyzer
+
+	// Analyzer for TitlePrefix: "Postgres Config" -> p, po, ..., postgres, c, co, ...
+	if err := indexMapping.AddCustomTokenFilter("title_edge_ngram", map[string]interface{}{
+		"type": edgengram.Name,
+		"back": false,
+		"min":  1.0,
+		"max":  float64(maxTitlePrefixLength),
+	}); err != nil {
+		return nil, fmt.Errorf("register title edge n-gram filter: %w", err)
+	}
+	if err := indexMapping.AddCustomAnalyzer(titlePrefixAnalyzer, map[string]interface{}{
+		"type":          custom.Name,
+		"tokenizer":     unicode.Name,
+		"token_filters": []string{lowercase.Name, "title_edge_ngram"},
+	}); err != nil {
+		return nil, fmt.Errorf("register title prefix analyzer: %w", err)
+	}
+
+	if customText {
+		if err := addTextAnalyzer(indexMapping, synonyms, stopwords); err != nil {
+			return nil, err
+		}
+	}
+
+	return indexMapping, nil
+}
+
+// standardTextField maps a text field with the standard analyzer (lowercased
+// words, no stemming), independent of the index default
+func standardTextField() *mapping.FieldMapping {
+	fm := bleve.NewTextFieldMapping()
+	fm.Analyzer = standard.Name
+	return fm
 }
 
 // Close closes the index
 func (i *Index) Close() error {
+	i.archivedMu.Lock()
+	if i.archived != nil {
+		i.archived.index.Close()
+		i.archived = nil
+	}
+	i.archivedMu.Unlock()
+
+	i.indexMu.Lock()
+	defer i.indexMu.Unlock()
 	return i.index.Close()
 }
 
+// acquire returns the Bleve index, which Rebuild won't close or replace
+// until release is called
+// Call it once per operation: a nested acquire can deadlock with a waiting
+// Rebuild, so internal helpers take the acquired index as a parameter.
+func (i *Index) acquire() (index bleve.Index, release func()) {
+	i.indexMu.RLock()
+	return i.index, i.indexMu.RUnlock
+}
+
 // SetDB sets the database reference (needed for semantic search)
-func (i *Index) SetDB(db *storage.DB) {
+func (i *Index) SetDB(db storage.Store) {
 	i.db = db
 }
 
+// SetSynonyms sets the synonym groups (see LoadSynonyms) used by the next
+// Rebuild; the analyzer is part of the index mapping, so changes only take
+// effect when the index is rebuilt
+func (i *Index) SetSynonyms(groups [][]string) {
+	i.synonyms = groups
+}
+
+// SetStopwords replaces the English stopword list (see LoadStopwords) for the
+// next Rebuild; nil restores the built-in list
+func (i *Index) SetStopwords(words []string) {
+	i.stopwords = words
+}
+
+// SetTitleBoost sets how much more a title match scores than a content match
+// in keyword search (1 disables boosting; values below 1 are ignored)
+func (i *Index) SetTitleBoost(boost float64) {
+	if boost >= 1 {
+		i.titleBoost = boost
+	}
+}
+
 // Index adds or updates a document in the index
 func (i *Index) IndexDocument(doc *IndexedDocument) error {
-	return i.index.Index(doc.ID, doc)
+	index, release := i.acquire()
+	defer release()
+
+	i.vectors.invalidate(doc.ID)
+	defer i.generation.Add(1)
+	return index.Index(doc.ID, doc)
 }
 
 // Delete removes a document from the index
 func (i *Index) Delete(id string) error {
-	return i.index.Delete(id)
+	index, release := i.acquire()
+	defer release()
+
+	i.vectors.invalidate(id)
+	defer i.generation.Add(1)
+	return index.Delete(id)
 }
 
-// Search performs a search query with title boosting
-func (i *Index) Search(queryStr string, limit int) ([]*SearchResult, error) {
-	// Boost title matches 3x higher than content matches
-	// This ensures documents with query terms in the title rank higher
+// Generation changes whenever documents are indexed or removed through this
+// Index, so callers can tell when keyword results cached earlier are stale
+func (i *Index) Generation() uint64 {
+	return i.generation.Load()
+}
 
-	// Title query: MatchQuery with boost
-	titleQuery := bleve.NewMatchQuery(queryStr)
-	titleQuery.SetField("Title")
-	titleQuery.SetBoost(3.0)
+// Search performs a search query with title boosting, or against just
+// opts.Field when set
+// The search stops early with an error once ctx is canceled or its deadline passes.
+func (i *Index) Search(ctx context.Context, queryStr string, opts SearchOptions) (*SearchResults, error) {
+	var query query.Query
+	var err error
+	if opts.Field != "" {
+		query, err = fieldQuery(opts.Field, queryStr)
+	} else {
+		query, err = i.allFieldsQuery(queryStr, opts.RawQuery)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	// Content query: QueryStringQuery (supports fuzzy, phrases, boolean ops)
-	contentQuery := bleve.NewQueryStringQuery(queryStr)
+	// Restrict to documents matching the filter (AND)
+	if filterQuery := opts.Filter.query(); filterQuery != nil {
+		query = bleve.NewConjunctionQuery(query, filterQuery)
+	}
 
-	// Combine with OR (disjunction) - matches in either title or content
-	query := bleve.NewDisjunctionQuery(titleQuery, contentQuery)
+	// Exclude marked duplicates in the query, so paging and totals stay right
+	if opts.HideDuplicates {
+		query, err = i.withoutDuplicates(query)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkSort(opts.Sort); err != nil {
+		return nil, err
+	}
 
 	// Create search request with highlighting
-	search := bleve.NewSearchRequestOptions(query, limit, 0, false)
+	search := bleve.NewSearchRequestOptions(query, opts.Limit, opts.Offset, false)
 	search.Highlight = bleve.NewHighlightWithStyle("html")
-	search.Fields = []string{"Title", "Author", "SlabURL"}
+	search.Highlight.Fields = highlightFields
+	search.Fields = []string{"Title", "Author", "SlabURL", "Topics"}
+	search.Explain = opts.Explain
+	if sortBy := bleveSort(opts.Sort); sortBy != nil {
+		search.SortBy(sortBy)
+	}
+
+	// Archived documents live in a separate in-memory index
+	index, release := i.acquire()
+	defer release()
+	var archivedIDs map[string]bool
+	if opts.IncludeArchived {
+		index, archivedIDs, err = i.withArchived(index)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// Execute search
-	results, err := i.index.Search(search)
+	results, err := index.SearchInContext(ctx, search)
 	if err != nil {
+		if ctxErr := searchCanceled(ctx); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, fmt.Errorf("search: %w", err)
 	}
 
@@ -142,6 +434,9 @@ func (i *Index) Search(queryStr string, limit int) ([]*SearchResult, error) {
 			Score:     hit.Score,
 			Fragments: hit.Fragments,
 		}
+		if opts.Explain {
+			result.Explanation = bleveExplanation(hit.Expl)
+		}
 
 		// Extract fields
 		if title, ok := hit.Fields["Title"].(string); ok {
@@ -153,38 +448,78 @@ func (i *Index) Search(queryStr string, limit int) ([]*SearchResult, error) {
 		if url, ok := hit.Fields["SlabURL"].(string); ok {
 			result.SlabURL = url
 		}
+		result.Topics = storedTopics(hit.Fields["Topics"])
+		result.Archived = archivedIDs[hit.ID]
 
 		searchResults = append(searchResults, result)
 	}
 
-	return searchResults, nil
+	return &SearchResults{Hits: searchResults, TotalHits: results.Total}, nil
+}
+
+// allFieldsQuery builds the default keyword query, matching the title and
+// the query string against all fields
+func (i *Index) allFieldsQuery(queryStr string, raw bool) (query.Query, error) {
+	// Boost title matches (3x by default, see SetTitleBoost) above content
+	// matches so documents with query terms in the title rank higher
+
+	// Title query: MatchQuery with boost
+	titleQuery := bleve.NewMatchQuery(queryStr)
+	titleQuery.SetField("Title")
+	titleQuery.SetBoost(i.titleBoost)
+
+	// Content query: QueryStringQuery. Raw queries use its full syntax (fuzzy,
+	// boolean ops, fields); others are escaped to match literally, keeping
+	// quoted phrases
+	contentStr := escapeQuery(queryStr)
+	if raw {
+		if err := ValidateQuery(queryStr); err != nil {
+			return nil, err
+		}
+		contentStr = queryStr
+	}
+	contentQuery := bleve.NewQueryStringQuery(contentStr)
+
+	// Combine with OR (disjunction) - matches in either title or content
+	return bleve.NewDisjunctionQuery(titleQuery, contentQuery), nil
+}
+
+// NewIndexedDocument converts a stored document into its index representation
+func NewIndexedDocument(doc *storage.Document) *IndexedDocument {
+	return &IndexedDocument{
+		ID:          doc.ID,
+		Title:       doc.Title,
+		Content:     doc.Content,
+		Comments:    doc.Comments,
+		Author:      doc.AuthorName,
+		Topics:      doc.TopicNames(),
+		PublishedAt: doc.PublishedAt,
+		UpdatedAt:   doc.UpdatedAt,
+		SlabURL:     doc.SlabURL,
+	}
 }
 
 // IndexFromStorage indexes all documents from storage
-func (i *Index) IndexFromStorage(db *storage.DB) error {
+func (i *Index) IndexFromStorage(db storage.Store) error {
 	docs, err := db.List(false) // Don't include archived
 	if err != nil {
 		return fmt.Errorf("list documents: %w", err)
 	}
 
-	batch := i.index.NewBatch()
+	index, release := i.acquire()
+	defer release()
+
+	defer i.generation.Add(1)
+	batch := index.NewBatch()
 	for _, doc := range docs {
-		indexDoc := &IndexedDocument{
-			ID:          doc.ID,
-			Title:       doc.Title,
-			Content:     doc.Content,
-			Author:      doc.AuthorName,
-			PublishedAt: doc.PublishedAt,
-			UpdatedAt:   doc.UpdatedAt,
-			SlabURL:     doc.SlabURL,
-		}
+		indexDoc := NewIndexedDocument(doc)
 
 		if err := batch.Index(indexDoc.ID, indexDoc); err != nil {
 			return fmt.Errorf("batch index %s: %w", doc.ID, err)
 		}
 	}
 
-	if err := i.index.Batch(batch); err != nil {
+	if err := index.Batch(batch); err != nil {
 		return fmt.Errorf("commit batch: %w", err)
 	}
 
@@ -193,12 +528,54 @@ func (i *Index) IndexFromStorage(db *storage.DB) error {
 
 // Count returns the number of documents in the index
 func (i *Index) Count() (uint64, error) {
-	return i.index.DocCount()
+	index, release := i.acquire()
+	defer release()
+	return index.DocCount()
+}
+
+// allIDsPageSize is how many IDs AllIDs fetches per search request
+const allIDsPageSize = 10000
+
+// AllIDs returns the ID of every document in the index, in ID order
+func (i *Index) AllIDs() ([]string, error) {
+	index, release := i.acquire()
+	defer release()
+	return allIDs(index)
+}
+
+// allIDs lists the document IDs of an acquired index
+func allIDs(index bleve.Index) ([]string, error) {
+	var ids []string
+	var after []string
+	for {
+		search := bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), allIDsPageSize, 0, false)
+		search.SortBy([]string{"_id"})
+		if after != nil {
+			search.SetSearchAfter(after)
+		}
+
+		results, err := index.Search(search)
+		if err != nil {
+			return nil, fmt.Errorf("list IDs: %w", err)
+		}
+		for _, hit := range results.Hits {
+			ids = append(ids, hit.ID)
+		}
+		if len(results.Hits) < allIDsPageSize {
+			return ids, nil
+		}
+		after = []string{ids[len(ids)-1]}
+	}
 }
 
 // Rebuild completely rebuilds the index from storage with progress callback
 // This is useful when changing index configuration or fixing corruption
-func (i *Index) Rebuild(db *storage.DB, progressFn func(current, total int)) error {
+// The new index is built beside the live one, which keeps serving searches
+// until it's swapped in; a failed build leaves the live index untouched.
+func (i *Index) Rebuild(db storage.Store, progressFn func(current, total int)) error {
+	// Recorded as LastIndexed so Update picks up anything written meanwhile
+	started := time.Now()
+
 	// Get all documents first
 	docs, err := db.List(false) // Don't include archived
 	if err != nil {
@@ -207,32 +584,24 @@ func (i *Index) Rebuild(db *storage.DB, progressFn func(current, total int)) err
 
 	totalDocs := len(docs)
 
-	// Delete all documents from index
-	docCount, err := i.index.DocCount()
+	// Create a new index from scratch so mapping changes (analyzers, new
+	// fields) take effect - deleting documents alone keeps the old mapping
+	indexMapping, err := buildIndexMapping(i.synonyms, i.stopwords)
 	if err != nil {
-		return fmt.Errorf("get doc count: %w", err)
+		return err
 	}
-
-	if docCount > 0 {
-		// Query all document IDs
-		query := bleve.NewMatchAllQuery()
-		search := bleve.NewSearchRequest(query)
-		search.Size = int(docCount)
-		search.Fields = []string{} // Only need IDs
-
-		results, err := i.index.Search(search)
-		if err != nil {
-			return fmt.Errorf("query existing docs: %w", err)
-		}
-
-		// Delete all existing documents
-		batch := i.index.NewBatch()
-		for _, hit := range results.Hits {
-			batch.Delete(hit.ID)
-		}
-		if err := i.index.Batch(batch); err != nil {
-			return fmt.Errorf("delete existing docs: %w", err)
-		}
+	buildPath := i.path + ".rebuild"
+	if err := os.RemoveAll(buildPath); err != nil { // Left by an interrupted rebuild
+		return fmt.Errorf("remove old rebuild: %w", err)
+	}
+	idx, err := bleve.New(buildPath, indexMapping)
+	if err != nil {
+		return fmt.Errorf("create index: %w", err)
+	}
+	discard := func(err error) error {
+		idx.Close()
+		os.RemoveAll(buildPath)
+		return err
 	}
 
 	// Index all documents from storage with progress reporting
@@ -240,25 +609,17 @@ func (i *Index) Rebuild(db *storage.DB, progressFn func(current, total int)) err
 	for start := 0; start < totalDocs; start += batchSize {
 		end := min(start + batchSize, totalDocs)
 
-		batch := i.index.NewBatch()
+		batch := idx.NewBatch()
 		for _, doc := range docs[start:end] {
-			indexDoc := &IndexedDocument{
-				ID:          doc.ID,
-				Title:       doc.Title,
-				Content:     doc.Content,
-				Author:      doc.AuthorName,
-				PublishedAt: doc.PublishedAt,
-				UpdatedAt:   doc.UpdatedAt,
-				SlabURL:     doc.SlabURL,
-			}
+			indexDoc := NewIndexedDocument(doc)
 
 			if err := batch.Index(indexDoc.ID, indexDoc); err != nil {
-				return fmt.Errorf("batch index %s: %w", doc.ID, err)
+				return discard(fmt.Errorf("batch index %s: %w", doc.ID, err))
 			}
 		}
 
-		if err := i.index.Batch(batch); err != nil {
-			return fmt.Errorf("commit batch: %w", err)
+		if err := idx.Batch(batch); err != nil {
+			return discard(fmt.Errorf("commit batch: %w", err))
 		}
 
 		// Report progress
@@ -267,5 +628,107 @@ func (i *Index) Rebuild(db *storage.DB, progressFn func(current, total int)) err
 		}
 	}
 
+	if err := setLastIndexed(idx, started); err != nil {
+		return discard(err)
+	}
+	if err := idx.Close(); err != nil {
+		return discard(fmt.Errorf("close new index: %w", err))
+	}
+	return i.swapIn(buildPath)
+}
+
+// swapIn replaces the live index with the closed one at buildPath, waiting
+// for operations on the live one to finish
+// If the new index can't be moved into place or opened, only the moves made
+// here are undone and the old index is reopened, so i.index is never left
+// closed unless reopening it fails too.
+func (i *Index) swapIn(buildPath string) error {
+	i.indexMu.Lock()
+	defer i.indexMu.Unlock()
+
+	// Clear any index left by an interrupted swap while the live one is still
+	// open, so failing here leaves it untouched
+	oldPath := i.path + ".old"
+	if err := os.RemoveAll(oldPath); err != nil {
+		return fmt.Errorf("remove old index: %w", err)
+	}
+
+	var movedAside, movedIn bool
+	restore := func(err error) error {
+		if movedIn {
+			if renameErr := os.Rename(i.path, buildPath); renameErr != nil {
+				return fmt.Errorf("%w (moving the new index back also failed: %v)", err, renameErr)
+			}
+		}
+		if movedAside {
+			if renameErr := os.Rename(oldPath, i.path); renameErr != nil {
+				return fmt.Errorf("%w (restoring the old index from %s also failed: %v)", err, oldPath, renameErr)
+			}
+		}
+		old, openErr := bleve.Open(i.path)
+		if openErr != nil {
+			return fmt.Errorf("%w (reopening the old index also failed: %v)", err, openErr)
+		}
+		i.index = old
+		return err
+	}
+
+	if err := i.index.Close(); err != nil {
+		return restore(fmt.Errorf("close index: %w", err))
+	}
+	if err := os.Rename(i.path, oldPath); err != nil {
+		return restore(fmt.Errorf("move old index aside: %w", err))
+	}
+	movedAside = true
+	if err := os.Rename(buildPath, i.path); err != nil {
+		return restore(fmt.Errorf("move new index into place: %w", err))
+	}
+	movedIn = true
+	idx, err := bleve.Open(i.path)
+	if err != nil {
+		return restore(fmt.Errorf("open new index: %w", err))
+	}
+
+	i.index = idx
+	i.generation.Add(1)
+	if err := os.RemoveAll(oldPath); err != nil {
+		slog.Warn("Failed to remove the replaced index", "path", oldPath, "error", err)
+	}
 	return nil
 }
+
+// searchIndex runs a single search request against the index
+func (i *Index) searchIndex(req *bleve.SearchRequest) (*bleve.SearchResult, error) {
+	index, release := i.acquire()
+	defer release()
+	return index.Search(req)
+}
+
+// TopicCount is the number of indexed documents in a topic
+type TopicCount struct {
+	Name  string
+	Count int
+}
+
+// TopicCounts returns the most common topics across the index using a facet request
+// limit caps the number of topics returned
+func (i *Index) TopicCounts(limit int) ([]TopicCount, error) {
+	search := bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), 0, 0, false)
+	search.AddFacet("topics", bleve.NewFacetRequest("Topics", limit))
+
+	results, err := i.searchIndex(search)
+	if err != nil {
+		return nil, fmt.Errorf("topic facets: %w", err)
+	}
+
+	facet, ok := results.Facets["topics"]
+	if !ok || facet.Terms == nil {
+		return nil, nil
+	}
+
+	var counts []TopicCount
+	for _, term := range facet.Terms.Terms() {
+		counts = append(counts, TopicCount{Name: term.Term, Count: term.Count})
+	}
+	return counts, nil
+}
@@ -0,0 +1,46 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFailedSwapKeepsLiveIndex(t *testing.T) {
+	idx, _ := newTestIndex(t, testDocs)
+	ctx := context.Background()
+
+	// A stale index left by an interrupted swap
+	oldPath := idx.path + ".old"
+	if err := os.MkdirAll(filepath.Join(oldPath, "store"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	generation := idx.Generation()
+
+	if err := idx.swapIn(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("swapping in a missing index succeeded")
+	}
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Errorf("stale %s is still there (stat error %v)", oldPath, err)
+	}
+	if got := idx.Generation(); got != generation {
+		t.Errorf("generation moved from %d to %d without a new index", generation, got)
+	}
+
+	results, err := idx.Search(ctx, "postgres", SearchOptions{Limit: 10})
+	if err != nil {
+		t.Fatalf("search after a failed swap: %v", err)
+	}
+	if len(results.Hits) != 3 {
+		t.Errorf("search after a failed swap found %v, want p1, p2 and p3", ids(results.Hits))
+	}
+
+	// The live index still rebuilds and swaps normally
+	if err := idx.Rebuild(idx.db, nil); err != nil {
+		t.Fatal(err)
+	}
+	if results, err = idx.Search(ctx, "postgres", SearchOptions{Limit: 10}); err != nil || len(results.Hits) != 3 {
+		t.Errorf("search after rebuilding = %v, %v, want p1, p2 and p3", results, err)
+	}
+}
@@ -0,0 +1,57 @@
+package search
+
+import "strings"
+
+// Passage is a line of a document that mentions the query, from FindPassages
+type Passage struct {
+	Line    int    `json:"line"`    // 1-based line number in the document
+	Offset  int    `json:"offset"`  // Byte offset of the line's start in the content
+	Terms   int    `json:"terms"`   // Distinct query terms on the line
+	Snippet string `json:"snippet"` // The line (or the best part of a long one), HTML-escaped with matches in <mark>
+}
+
+// FindPassages locates the lines of content that mention query terms, in
+// document order, so a long document can be searched without re-reading it
+// Terms match whole words case-insensitively, skipping stop words, like
+// semantic result snippets; at most limit passages are returned (all if
+// limit <= 0), along with the number of matching lines.
+func FindPassages(content, query string, limit int) ([]*Passage, int) {
+	terms := make(map[string]bool)
+	for _, t := range nameTerms(query) {
+		if !stopWords[t] {
+			terms[t] = true
+		}
+	}
+	if len(terms) == 0 {
+		return nil, 0
+	}
+
+	var passages []*Passage
+	total, offset := 0, 0
+	for n, line := range strings.Split(content, "\n") {
+		lineOffset := offset
+		offset += len(line) + 1
+
+		seen := make(map[string]bool)
+		for _, word := range nameTerms(line) {
+			if terms[word] {
+				seen[word] = true
+			}
+		}
+		if len(seen) == 0 {
+			continue
+		}
+
+		total++
+		if limit > 0 && len(passages) >= limit {
+			continue // Keep counting
+		}
+		passages = append(passages, &Passage{
+			Line:    n + 1,
+			Offset:  lineOffset,
+			Terms:   len(seen),
+			Snippet: contentSnippet(line, query),
+		})
+	}
+	return passages, total
+}
@@ -0,0 +1,70 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// ErrQuerySyntax is returned by Search when a raw query (SearchOptions.RawQuery)
+// isn't valid query-string syntax; the wrapping error says what's wrong
+var ErrQuerySyntax = errors.New("invalid query")
+
+// queryReservedChars are the characters Bleve's query-string syntax treats
+// specially outside quoted phrases
+const queryReservedChars = `+-=&|><!(){}[]^"~*?:\/`
+
+// escapeQuery turns free text into a query string matching it literally:
+// operators and punctuation are escaped, while balanced double quotes still
+// mark phrases. An unmatched quote is searched as an ordinary character.
+func escapeQuery(text string) string {
+	// A quote only opens a phrase if another one closes it
+	quotes := strings.Count(text, `"`)
+
+	var b strings.Builder
+	inPhrase := false
+	for _, r := range text {
+		switch {
+		case r == '"' && (inPhrase || quotes > 1):
+			inPhrase = !inPhrase
+			quotes--
+			b.WriteRune(r)
+		case inPhrase:
+			// Inside a phrase only backslashes need escaping
+			if r == '\\' {
+				b.WriteRune('\\')
+			}
+			b.WriteRune(r)
+		default:
+			if strings.ContainsRune(queryReservedChars, r) {
+				b.WriteRune('\\')
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ValidateQuery checks raw query-string syntax, explaining common mistakes
+// (unmatched quotes, stray operators) in an error wrapping ErrQuerySyntax
+func ValidateQuery(raw string) error {
+	_, err := query.NewQueryStringQuery(raw).Parse()
+	if err == nil {
+		return nil
+	}
+
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "unterminated quote"):
+		msg = `your query has an unmatched quote (")`
+	case strings.Contains(msg, "invalid fuzziness"):
+		msg = "~ must be followed by an edit distance, e.g. deploy~1, or nothing"
+	case strings.Contains(msg, "invalid boost"):
+		msg = "^ must be followed by a number, e.g. postgres^2"
+	case strings.Contains(msg, "syntax error"):
+		msg = `your query has a misplaced operator (+ - : ~ > < = or \); escape it with \ to search for it`
+	}
+	return fmt.Errorf("%w: %s", ErrQuerySyntax, msg)
+}
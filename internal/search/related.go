@@ -0,0 +1,54 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/renderinc/slab-search/internal/embeddings"
+	"github.com/renderinc/slab-search/internal/storage"
+)
+
+// ErrDocumentNotFound is returned by RelatedDocuments for an unknown document ID
+var ErrDocumentNotFound = errors.New("document not found")
+
+// ErrNoEmbedding is returned by RelatedDocuments when the document hasn't
+// been embedded yet
+var ErrNoEmbedding = errors.New("document has no embedding (run 'slab-search embed')")
+
+// RelatedDocuments returns up to limit documents most similar to docID, by
+// semantic similarity of their stored embeddings (the default Embedding
+// field), excluding the document itself
+// Snippets are chosen against the document's title.
+func (i *Index) RelatedDocuments(ctx context.Context, docID string, limit int) ([]*SearchResult, error) {
+	doc, err := i.db.Get(docID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, fmt.Errorf("%s: %w", docID, ErrDocumentNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get document: %w", err)
+	}
+	if len(doc.Embedding) == 0 {
+		return nil, fmt.Errorf("%s: %w", docID, ErrNoEmbedding)
+	}
+
+	// Chunked documents store the mean of their chunk vectors here
+	vec := embeddings.NormalizeEmbedding(embeddings.DeserializeEmbedding(doc.Embedding))
+	if vec == nil {
+		return nil, fmt.Errorf("%s: %w", docID, ErrNoEmbedding)
+	}
+
+	// One extra, since the document is its own best match
+	results, err := i.SemanticSearch(ctx, doc.Title, vec, false, SearchOptions{Limit: limit + 1})
+	if err != nil {
+		return nil, err
+	}
+
+	related := make([]*SearchResult, 0, limit)
+	for _, result := range results.Hits {
+		if result.ID != docID && len(related) < limit {
+			related = append(related, result)
+		}
+	}
+	return related, nil
+}
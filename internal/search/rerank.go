@@ -0,0 +1,58 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Reranker reorders search results by relevance to a query, typically with a
+// cross-encoder model that reads the query and each document together
+// Implemented by rerank.Client
+type Reranker interface {
+	// Rerank returns docs ordered by descending relevance, with Score set to
+	// the reranker's relevance score, giving up once ctx is done
+	Rerank(ctx context.Context, query string, docs []*SearchResult) ([]*SearchResult, error)
+}
+
+// rerank reorders merged hybrid candidates with opts.Reranker, if set
+// Runs before paging so documents ranked low by both retrievers can still
+// reach the first page.
+func rerank(ctx context.Context, query string, candidates []*SearchResult, opts SearchOptions) ([]*SearchResult, error) {
+	if opts.Reranker == nil || len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	reranked, err := opts.Reranker.Rerank(ctx, query, candidates)
+	if err != nil {
+		return nil, fmt.Errorf("rerank: %w", err)
+	}
+	for _, result := range reranked {
+		explainStep(result, "reranker relevance score (replaces the score below)")
+	}
+	sort.SliceStable(reranked, func(i, j int) bool {
+		return reranked[i].Score > reranked[j].Score
+	})
+	return reranked, nil
+}
+
+// RerankText returns the text a reranker should judge a result by: its title
+// and highlighted snippets as plain text
+func RerankText(result *SearchResult) string {
+	fields := make([]string, 0, len(result.Fragments))
+	for field := range result.Fragments {
+		if field != "Title" { // Already included whole
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields) // Stable text for the same result
+
+	parts := []string{result.Title}
+	for _, field := range fields {
+		for _, fragment := range result.Fragments[field] {
+			parts = append(parts, PlainFragment(fragment))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -1,95 +1,308 @@
 package search
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"runtime"
 	"sort"
+	"sync"
 
 	"github.com/renderinc/slab-search/internal/embeddings"
 	"github.com/renderinc/slab-search/internal/storage"
 )
 
+// ErrDimensionMismatch is returned by semantic searches when no stored
+// embedding has the query embedding's dimension, i.e. the query was embedded
+// with a different model than the corpus
+var ErrDimensionMismatch = errors.New("query embedding dimension doesn't match the stored embeddings")
+
 // SemanticSearch performs semantic similarity search using embeddings
 // Returns results sorted by cosine similarity (highest first)
+// query: the text queryEmbedding was generated from, used to pick snippets
 // useQwen: if true, uses EmbeddingQwen field; otherwise uses Embedding field
-func (i *Index) SemanticSearch(queryEmbedding []float32, limit int, useQwen bool) ([]*SearchResult, error) {
-	// 1. Get all documents from database (with embeddings)
-	docs, err := i.db.List(false) // Don't include archived
+// opts.Filter: candidates not matching it are dropped before scoring
+// (Filter.EmbeddingModel restricts them to vectors from one model)
+// opts.Diversity: re-ranks results to reduce near-duplicates (see diversify)
+// opts.MinScore: documents less similar than this are dropped
+// opts.Sort: the best matches are re-sorted by date (see sortByDate)
+// Documents whose embedding dimension differs from the query's (embedded with
+// another model) are skipped with a warning rather than silently scored 0;
+// if that leaves none, returns ErrDimensionMismatch
+// Scoring stops early with an error once ctx is canceled or its deadline passes.
+func (i *Index) SemanticSearch(ctx context.Context, query string, queryEmbedding []float32, useQwen bool, opts SearchOptions) (*SearchResults, error) {
+	if sortsByDate(opts.Sort) {
+		return i.sortByDate(opts, func(o SearchOptions) (*SearchResults, error) {
+			return i.SemanticSearch(ctx, query, queryEmbedding, useQwen, o)
+		})
+	}
+	if opts.Diversity != 0 {
+		return i.diversify(opts, useQwen, func(o SearchOptions) (*SearchResults, error) {
+			return i.SemanticSearch(ctx, query, queryEmbedding, useQwen, o)
+		})
+	}
+
+	queryVec := embeddings.NormalizeEmbedding(queryEmbedding)
+	if queryVec == nil {
+		return nil, fmt.Errorf("query embedding is empty or all zeros")
+	}
+
+	// Use the ANN index when it's built; it can't apply metadata filters,
+	// so filtered searches always take the exact brute-force path below
+	if opts.Filter.empty() && !opts.IncludeArchived && !opts.HideDuplicates {
+		if results, ok := i.annSearch(query, queryEmbedding, useQwen, opts); ok {
+			return results, nil
+		}
+	}
+
+	// 1. Get all documents from database (embeddings come from the cache)
+	docs, err := i.db.ListWithoutEmbeddings(opts.IncludeArchived)
 	if err != nil {
 		return nil, fmt.Errorf("list documents: %w", err)
 	}
+	if opts.HideDuplicates {
+		if docs, err = i.dropDuplicates(docs); err != nil {
+			return nil, err
+		}
+	}
+
+	// Documents embedded in chunks are scored by their best-matching chunk
+	chunks, err := i.db.ListChunks(useQwen)
+	if err != nil {
+		return nil, fmt.Errorf("list chunks: %w", err)
+	}
+	if err := i.loadVectors(docs, chunks, useQwen); err != nil {
+		return nil, err
+	}
 
 	// 2. Compute cosine similarity for each document
-	type scoredDoc struct {
-		doc   *storage.Document
-		score float32
+	scores, mismatched, err := i.scoreDocuments(ctx, docs, chunks, queryVec, useQwen, opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+	if mismatched > 0 && len(scores) == 0 {
+		return nil, fmt.Errorf("%w: the query has %d dimensions and none of the %d embedded documents do",
+			ErrDimensionMismatch, len(queryVec), mismatched)
+	}
+	// Every query skips the same documents until they're re-embedded, so
+	// warn once per index generation rather than on each search
+	if warned := i.Generation() + 1; mismatched > 0 && i.mismatchWarned.Swap(warned) != warned {
+		slog.Warn("Skipped documents whose embeddings don't match the query's dimension (re-embed them with the query's model; see stats)",
+			"documents", mismatched, "dimension", len(queryVec))
+	}
+
+	// 3. Sort by score (descending), dropping documents below the threshold
+	sort.Slice(scores, func(i, j int) bool {
+		return scores[i].score > scores[j].score
+	})
+	if opts.MinScore > 0 {
+		cut := sort.Search(len(scores), func(i int) bool {
+			return float64(scores[i].score) < opts.MinScore
+		})
+		scores = scores[:cut]
+	}
+
+	// 4. Convert the requested page to SearchResult
+	// Snippets come from the best-matching chunk, or the whole document
+	results := make([]*SearchResult, 0, opts.Limit)
+	for i := opts.Offset; i < len(scores) && i < opts.Offset+opts.Limit; i++ {
+		doc := scores[i].doc
+		snippetSource := scores[i].chunk
+		if snippetSource == "" {
+			snippetSource = doc.Content
+		}
+		result := &SearchResult{
+			ID:        doc.ID,
+			Title:     doc.Title,
+			Author:    doc.AuthorName,
+			SlabURL:   doc.SlabURL,
+			Topics:    doc.TopicNames(),
+			Archived:  doc.ArchivedAt != nil,
+			Score:     float64(scores[i].score),
+			Fragments: snippetFragments(snippetSource, query),
+		}
+		if opts.Explain {
+			result.Explanation = cosineExplanation(result.Score, scores[i].chunk != "")
+		}
+		results = append(results, result)
+	}
+
+	// Total counts documents that had an embedding and scored above zero
+	var total uint64
+	for _, s := range scores {
+		if s.score > 0 {
+			total++
+		}
+	}
+
+	return &SearchResults{Hits: results, TotalHits: total}, nil
+}
+
+// parallelScoreThreshold is the corpus size above which scoring is sharded
+// across CPU cores; below it goroutine overhead outweighs the speedup
+const parallelScoreThreshold = 2000
+
+// scoreWorkers is how many goroutines score a large corpus
+var scoreWorkers = runtime.NumCPU()
+
+// scoreCheckInterval is how many documents are scored between checks for a
+// canceled search
+const scoreCheckInterval = 256
+
+// scoredDoc is a document with its similarity to the query
+type scoredDoc struct {
+	doc   *storage.Document
+	score float32
+	chunk string // Best-matching chunk text (empty for whole-document vectors)
+}
+
+// scoreDocuments scores every document that matches filter and has an
+// embedding in the selected field, preserving the order of docs
+// Documents with chunks score as their best chunk (max aggregation); others
+// use the whole-document vector. query must be L2-normalized.
+// Also returns the number of documents skipped for a dimension mismatch.
+// Fails once ctx is done, without waiting for the rest of the corpus.
+func (i *Index) scoreDocuments(ctx context.Context, docs []*storage.Document, chunks map[string][]*storage.Chunk, query []float32, useQwen bool, filter *Filter) ([]scoredDoc, int, error) {
+	workers := scoreWorkers
+	if len(docs) < parallelScoreThreshold || workers < 2 {
+		return i.scoreShard(ctx, docs, chunks, query, useQwen, filter)
+	}
+
+	// Shard contiguously and concatenate in shard order, so the output is
+	// identical to the sequential path
+	shardSize := (len(docs) + workers - 1) / workers
+	shards := make([][]scoredDoc, workers)
+	mismatches := make([]int, workers)
+	errs := make([]error, workers)
+
+	var wg sync.WaitGroup
+	for w := range shards {
+		start := w * shardSize
+		if start >= len(docs) {
+			break
+		}
+		end := min(start+shardSize, len(docs))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			shards[w], mismatches[w], errs[w] = i.scoreShard(ctx, docs[start:end], chunks, query, useQwen, filter)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, 0, err
+		}
 	}
 
 	scores := make([]scoredDoc, 0, len(docs))
-	for _, doc := range docs {
-		// Select which embedding field to use
-		var embeddingData []byte
-		if useQwen {
-			embeddingData = doc.EmbeddingQwen
-		} else {
-			embeddingData = doc.Embedding
+	mismatched := 0
+	for w, shard := range shards {
+		scores = append(scores, shard...)
+		mismatched += mismatches[w]
+	}
+	return scores, mismatched, nil
+}
+
+// scoreShard scores a slice of documents on the calling goroutine
+func (i *Index) scoreShard(ctx context.Context, docs []*storage.Document, chunks map[string][]*storage.Chunk, query []float32, useQwen bool, filter *Filter) ([]scoredDoc, int, error) {
+	scores := make([]scoredDoc, 0, len(docs))
+	mismatched := 0
+	for n, doc := range docs {
+		if n%scoreCheckInterval == 0 {
+			if err := searchCanceled(ctx); err != nil {
+				return nil, 0, err
+			}
+		}
+
+		// Skip documents excluded by the filter
+		if !filter.matches(doc) || !filter.embeddedWith(doc, useQwen) {
+			continue
 		}
 
-		// Skip documents without embeddings
-		if len(embeddingData) == 0 {
+		if docChunks := chunks[doc.ID]; len(docChunks) > 0 {
+			if scored, ok := i.scoreChunks(doc, docChunks, query, useQwen); ok {
+				scores = append(scores, scored)
+			} else {
+				mismatched++
+			}
 			continue
 		}
 
-		docEmbedding := embeddings.DeserializeEmbedding(embeddingData)
+		// Skip documents without embeddings (cached by loadVectors)
+		docEmbedding, _ := i.vectors.lookup(doc.ID, useQwen, versionOf(doc))
 		if docEmbedding == nil {
 			continue
 		}
+		if len(docEmbedding) != len(query) {
+			mismatched++
+			continue
+		}
 
-		score := embeddings.CosineSimilarity(queryEmbedding, docEmbedding)
-		scores = append(scores, scoredDoc{doc: doc, score: score})
+		// Stored vectors are unit length (normalized at ingest), as is the
+		// query, so cosine similarity is the dot product
+		scores = append(scores, scoredDoc{doc: doc, score: embeddings.DotProduct(query, docEmbedding)})
 	}
+	return scores, mismatched, nil
+}
 
-	// 3. Sort by score (descending)
-	sort.Slice(scores, func(i, j int) bool {
-		return scores[i].score > scores[j].score
-	})
+// scoreChunks scores a document as its best-matching chunk
+// Returns false if no chunk matches the query's dimension
+func (i *Index) scoreChunks(doc *storage.Document, chunks []*storage.Chunk, query []float32, useQwen bool) (scoredDoc, bool) {
+	best := scoredDoc{doc: doc}
+	found := false
+	version := versionOf(doc)
+	for _, c := range chunks {
+		vec := i.vectors.getChunk(doc.ID, c.Index, useQwen, version, c.Embedding)
+		if vec == nil || len(vec) != len(query) {
+			continue
+		}
 
-	// 4. Convert to SearchResult and return top N
-	results := make([]*SearchResult, 0, limit)
-	for i := 0; i < len(scores) && i < limit; i++ {
-		doc := scores[i].doc
-		results = append(results, &SearchResult{
-			ID:      doc.ID,
-			Title:   doc.Title,
-			Author:  doc.AuthorName,
-			SlabURL: doc.SlabURL,
-			Score:   float64(scores[i].score),
-		})
+		if score := embeddings.DotProduct(query, vec); !found || score > best.score {
+			best.score = score
+			best.chunk = c.Content
+			found = true
+		}
 	}
+	return best, found
+}
 
-	return results, nil
+// chunkKey identifies a chunk vector in the ANN graph
+func chunkKey(docID string, index int) string {
+	return fmt.Sprintf("%s#%d", docID, index)
 }
 
 // HybridSearch combines keyword search (Bleve) with semantic search (embeddings)
 // keywordWeight: 0.0-1.0, weight for keyword results (e.g., 0.7 = 70% keyword, 30% semantic)
 // useQwen: if true, uses EmbeddingQwen field; otherwise uses Embedding field
-func (i *Index) HybridSearch(query string, queryEmbedding []float32, limit int, keywordWeight float64, useQwen bool) ([]*SearchResult, error) {
+// opts.Filter: applied to both the keyword and semantic candidates
+// TotalHits counts the merged candidates (see hybridCandidates), setting
+// TotalCapped when either search had more matches.
+// The search stops early with an error once ctx is canceled or its deadline passes.
+func (i *Index) HybridSearch(ctx context.Context, query string, queryEmbedding []float32, keywordWeight float64, useQwen bool, opts SearchOptions) (*SearchResults, error) {
 	// Validate weight
 	if keywordWeight < 0 || keywordWeight > 1 {
 		return nil, fmt.Errorf("keywordWeight must be between 0 and 1")
 	}
+	if sortsByDate(opts.Sort) {
+		return i.sortByDate(opts, func(o SearchOptions) (*SearchResults, error) {
+			return i.HybridSearch(ctx, query, queryEmbedding, keywordWeight, useQwen, o)
+		})
+	}
+	if opts.Diversity != 0 {
+		return i.diversify(opts, useQwen, func(o SearchOptions) (*SearchResults, error) {
+			return i.HybridSearch(ctx, query, queryEmbedding, keywordWeight, useQwen, o)
+		})
+	}
 	semanticWeight := 1.0 - keywordWeight
 
 	// 1. Perform both searches (get more candidates for better merging)
-	candidateLimit := limit * 3 // Get 3x more candidates
-
-	keywordResults, err := i.Search(query, candidateLimit)
+	keywordResults, semanticResults, capped, err := i.hybridCandidates(ctx, query, queryEmbedding, useQwen, opts)
 	if err != nil {
-		return nil, fmt.Errorf("keyword search: %w", err)
-	}
-
-	semanticResults, err := i.SemanticSearch(queryEmbedding, candidateLimit, useQwen)
-	if err != nil {
-		return nil, fmt.Errorf("semantic search: %w", err)
+		return nil, err
 	}
 
 	// 2. Normalize scores to 0-1 range for each result set
@@ -102,17 +315,22 @@ func (i *Index) HybridSearch(query string, queryEmbedding []float32, limit int,
 	// Add keyword results
 	for _, result := range keywordResults {
 		scoreMap[result.ID] = result
+		component := weightedExplanation("keyword", result, keywordScores[result.ID], keywordWeight)
 		result.Score = keywordScores[result.ID] * keywordWeight
+		result.Explanation = hybridExplanation(nil, component)
 	}
 
 	// Merge semantic results
 	for _, result := range semanticResults {
+		component := weightedExplanation("semantic", result, semanticScores[result.ID], semanticWeight)
 		if existing, found := scoreMap[result.ID]; found {
 			// Document appears in both - combine scores
 			existing.Score += semanticScores[result.ID] * semanticWeight
+			existing.Explanation = hybridExplanation(existing.Explanation, component)
 		} else {
 			// Document only in semantic results
 			result.Score = semanticScores[result.ID] * semanticWeight
+			result.Explanation = hybridExplanation(nil, component)
 			scoreMap[result.ID] = result
 		}
 	}
@@ -122,17 +340,152 @@ func (i *Index) HybridSearch(query string, queryEmbedding []float32, limit int,
 	for _, result := range scoreMap {
 		combined = append(combined, result)
 	}
+	i.boostTopics(combined)
 
 	sort.Slice(combined, func(i, j int) bool {
 		return combined[i].Score > combined[j].Score
 	})
 
-	// 5. Return top N
-	if len(combined) > limit {
-		combined = combined[:limit]
+	combined = dropBelow(combined, opts.MinScore)
+	if err := searchCanceled(ctx); err != nil {
+		return nil, err
+	}
+	combined, err = rerank(ctx, query, combined, opts)
+	if err != nil {
+		return nil, err
 	}
 
-	return combined, nil
+	// 5. Return the requested page
+	total := uint64(len(combined))
+	start := min(opts.Offset, len(combined))
+	end := min(opts.Offset+opts.Limit, len(combined))
+
+	return &SearchResults{Hits: combined[start:end], TotalHits: total, TotalCapped: capped}, nil
+}
+
+// rrfK dampens the weight of top ranks in reciprocal rank fusion; 60 is the
+// constant from the original RRF paper and works well without tuning
+const rrfK = 60
+
+// HybridSearchRRF combines keyword and semantic search with Reciprocal Rank
+// Fusion: each document scores sum(1/(rrfK+rank)) over the rankings it
+// appears in. Only ranks matter, so unlike HybridSearch it isn't skewed by
+// the very different Bleve and cosine score scales.
+// useQwen: if true, uses EmbeddingQwen field; otherwise uses Embedding field
+// opts.Filter: applied to both the keyword and semantic candidates
+// opts.MinScore isn't supported: RRF scores (at most 2/61) aren't on the
+// scale of the thresholds used for the other modes
+// TotalHits counts the fused candidates (see hybridCandidates), setting
+// TotalCapped when either search had more matches.
+// The search stops early with an error once ctx is canceled or its deadline passes.
+func (i *Index) HybridSearchRRF(ctx context.Context, query string, queryEmbedding []float32, useQwen bool, opts SearchOptions) (*SearchResults, error) {
+	if opts.MinScore > 0 {
+		return nil, fmt.Errorf("a minimum score isn't supported with reciprocal rank fusion")
+	}
+	if sortsByDate(opts.Sort) {
+		return i.sortByDate(opts, func(o SearchOptions) (*SearchResults, error) {
+			return i.HybridSearchRRF(ctx, query, queryEmbedding, useQwen, o)
+		})
+	}
+	if opts.Diversity != 0 {
+		return i.diversify(opts, useQwen, func(o SearchOptions) (*SearchResults, error) {
+			return i.HybridSearchRRF(ctx, query, queryEmbedding, useQwen, o)
+		})
+	}
+
+	keywordResults, semanticResults, capped, err := i.hybridCandidates(ctx, query, queryEmbedding, useQwen, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fuse rankings by document ID; keyword results come first so their
+	// Bleve highlights are kept for documents found by both
+	scoreMap := make(map[string]*SearchResult)
+	rankings := []struct {
+		name    string
+		results []*SearchResult
+	}{{"keyword", keywordResults}, {"semantic", semanticResults}}
+	for _, ranking := range rankings {
+		for rank, result := range ranking.results {
+			contribution := 1.0 / float64(rrfK+rank+1)
+			component := rankExplanation(ranking.name, result, rank, contribution)
+			if existing, found := scoreMap[result.ID]; found {
+				existing.Score += contribution
+				existing.Explanation = hybridExplanation(existing.Explanation, component)
+			} else {
+				result.Score = contribution
+				result.Explanation = hybridExplanation(nil, component)
+				scoreMap[result.ID] = result
+			}
+		}
+	}
+
+	combined := make([]*SearchResult, 0, len(scoreMap))
+	for _, result := range scoreMap {
+		combined = append(combined, result)
+	}
+	i.boostTopics(combined)
+
+	sort.Slice(combined, func(i, j int) bool {
+		return combined[i].Score > combined[j].Score
+	})
+	if err := searchCanceled(ctx); err != nil {
+		return nil, err
+	}
+	combined, err = rerank(ctx, query, combined, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the requested page
+	total := uint64(len(combined))
+	start := min(opts.Offset, len(combined))
+	end := min(opts.Offset+opts.Limit, len(combined))
+
+	return &SearchResults{Hits: combined[start:end], TotalHits: total, TotalCapped: capped}, nil
+}
+
+// hybridCandidates runs the keyword and semantic searches that hybrid
+// strategies merge, fetching 3x the requested depth from each
+// Candidates always start at 0 since paging applies to the merged ranking.
+// capped reports whether either search had more matches than it fetched.
+func (i *Index) hybridCandidates(ctx context.Context, query string, queryEmbedding []float32, useQwen bool, opts SearchOptions) (keyword, semantic []*SearchResult, capped bool, err error) {
+	candidateOpts := SearchOptions{
+		Limit:    (opts.Offset + opts.Limit) * 3, // Get 3x more candidates
+		Filter:   opts.Filter,
+		RawQuery: opts.RawQuery,
+		Field:    opts.Field,
+
+		IncludeArchived: opts.IncludeArchived,
+		HideDuplicates:  opts.HideDuplicates,
+		Explain:         opts.Explain,
+	}
+
+	keywordPage, err := i.Search(ctx, query, candidateOpts)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("keyword search: %w", err)
+	}
+
+	semanticPage, err := i.SemanticSearch(ctx, query, queryEmbedding, useQwen, candidateOpts)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("semantic search: %w", err)
+	}
+
+	capped = keywordPage.TotalHits > uint64(len(keywordPage.Hits)) ||
+		semanticPage.TotalHits > uint64(len(semanticPage.Hits))
+	return keywordPage.Hits, semanticPage.Hits, capped, nil
+}
+
+// dropBelow trims results sorted by descending score to those scoring at
+// least minScore (all of them if minScore is 0)
+func dropBelow(results []*SearchResult, minScore float64) []*SearchResult {
+	if minScore <= 0 {
+		return results
+	}
+	cut := sort.Search(len(results), func(i int) bool {
+		return results[i].Score < minScore
+	})
+	return results[:cut]
 }
 
 // normalizeScores normalizes result scores to 0-1 range
@@ -171,3 +524,142 @@ func normalizeScores(results []*SearchResult) map[string]float64 {
 
 	return normalized
 }
+
+// loadVectors caches the embeddings of docs that aren't cached for their
+// current version, fetching just those from the database; documents with
+// chunks are left out since they're scored by their chunks
+func (i *Index) loadVectors(docs []*storage.Document, chunks map[string][]*storage.Chunk, useQwen bool) error {
+	var missing []string
+	versions := make(map[string]vectorVersion)
+	for _, doc := range docs {
+		if len(chunks[doc.ID]) > 0 {
+			continue
+		}
+		version := versionOf(doc)
+		if _, found := i.vectors.lookup(doc.ID, useQwen, version); !found {
+			missing = append(missing, doc.ID)
+			versions[doc.ID] = version
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	blobs, err := i.db.GetEmbeddings(missing, useQwen)
+	if err != nil {
+		return fmt.Errorf("load embeddings: %w", err)
+	}
+	for _, id := range missing {
+		i.vectors.put(id, useQwen, versions[id], blobs[id])
+	}
+	return nil
+}
+
+// vectorCache holds decoded document and chunk vectors so repeated searches
+// neither load nor deserialize every embedding
+// Stored embeddings are already unit length (see storage's migration 10),
+// so they're used as decoded. Each document's vectors are cached under its
+// version and all replaced when it changes, so chunks a re-embedding dropped
+// go too; IndexDocument and Delete also evict them.
+type vectorCache struct {
+	mu   sync.RWMutex
+	docs map[string]*docVectors // By document ID
+}
+
+// docVectors are the cached vectors of one version of a document
+type docVectors struct {
+	version vectorVersion
+	vecs    map[vectorSlot][]float32 // Unit-length vectors (nil if there's no embedding)
+}
+
+// vectorSlot identifies one of a document's vectors
+type vectorSlot struct {
+	chunk   int // Chunk index, or wholeDocument
+	useQwen bool
+}
+
+// wholeDocument is the vectorSlot chunk of a document's own embedding
+const wholeDocument = -1
+
+// vectorVersion identifies the stored state of a document's vectors, which
+// only change when it's synced or embedded again
+// Embed replaces chunks before updating the document, so chunks cached
+// between the two are cached under the old version.
+type vectorVersion struct {
+	updated, synced, embedded int64 // Unix nanoseconds (embedded is 0 if never)
+}
+
+// versionOf returns the version of doc's vectors
+func versionOf(doc *storage.Document) vectorVersion {
+	v := vectorVersion{updated: doc.UpdatedAt.UnixNano(), synced: doc.SyncedAt.UnixNano()}
+	if doc.EmbeddedAt != nil {
+		v.embedded = doc.EmbeddedAt.UnixNano()
+	}
+	return v
+}
+
+// lookup returns the vector cached for a version of a document (nil if it
+// has no embedding); found is false if none is cached for that version
+func (c *vectorCache) lookup(id string, useQwen bool, version vectorVersion) (vec []float32, found bool) {
+	return c.lookupSlot(id, vectorSlot{chunk: wholeDocument, useQwen: useQwen}, version)
+}
+
+// put decodes raw, a serialized embedding (nil if there's none), and caches
+// the vector for a version of a document
+// Returns nil if raw is empty or can't be decoded.
+func (c *vectorCache) put(id string, useQwen bool, version vectorVersion, raw []byte) []float32 {
+	return c.putSlot(id, vectorSlot{chunk: wholeDocument, useQwen: useQwen}, version, raw)
+}
+
+// get returns the vector cached for a version of a document, decoding and
+// caching raw if there's none
+func (c *vectorCache) get(id string, useQwen bool, version vectorVersion, raw []byte) []float32 {
+	if vec, found := c.lookup(id, useQwen, version); found {
+		return vec
+	}
+	return c.put(id, useQwen, version, raw)
+}
+
+// getChunk is get for one of a document's chunks
+func (c *vectorCache) getChunk(id string, chunk int, useQwen bool, version vectorVersion, raw []byte) []float32 {
+	slot := vectorSlot{chunk: chunk, useQwen: useQwen}
+	if vec, found := c.lookupSlot(id, slot, version); found {
+		return vec
+	}
+	return c.putSlot(id, slot, version, raw)
+}
+
+func (c *vectorCache) lookupSlot(id string, slot vectorSlot, version vectorVersion) (vec []float32, found bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	doc := c.docs[id]
+	if doc == nil || doc.version != version {
+		return nil, false
+	}
+	vec, found = doc.vecs[slot]
+	return vec, found
+}
+
+func (c *vectorCache) putSlot(id string, slot vectorSlot, version vectorVersion, raw []byte) []float32 {
+	vec := embeddings.DeserializeEmbedding(raw)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.docs == nil {
+		c.docs = make(map[string]*docVectors)
+	}
+	doc := c.docs[id]
+	if doc == nil || doc.version != version {
+		doc = &docVectors{version: version, vecs: make(map[vectorSlot][]float32)}
+		c.docs[id] = doc
+	}
+	doc.vecs[slot] = vec
+	return vec
+}
+
+// invalidate drops a document's cached vectors, chunks included
+func (c *vectorCache) invalidate(id string) {
+	c.mu.Lock()
+	delete(c.docs, id)
+	c.mu.Unlock()
+}
@@ -0,0 +1,602 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/renderinc/slab-search/internal/embeddings"
+	"github.com/renderinc/slab-search/internal/storage"
+)
+
+// testDocs make keyword and semantic rankings disagree on "postgres": title
+// matches boost p1 and p2 in keyword search, while p3's content, mostly
+// "postgres", makes its embedding the closest
+var testDocs = []*storage.Document{
+	{ID: "p1", Title: "Postgres backups", Content: "How to restore postgres backups from snapshots."},
+	{ID: "p2", Title: "Postgres tuning", Content: "Tune shared buffers and work mem for postgres."},
+	{ID: "p3", Title: "Database notes", Content: "postgres postgres postgres replicas and failover."},
+	{ID: "k1", Title: "Kubernetes deploys", Content: "Rolling deploys, restore points and snapshots."},
+	{ID: "k2", Title: "Oncall handbook", Content: "Paging, escalation and incident reviews."},
+}
+
+// newTestIndex indexes copies of docs, embedded with a FakeEmbedder, in a
+// MemStore-backed index
+func newTestIndex(t *testing.T, docs []*storage.Document) (*Index, *embeddings.FakeEmbedder) {
+	t.Helper()
+
+	embedder := embeddings.NewFakeEmbedder(64)
+	db := storage.NewMemStore()
+	now := time.Now()
+	for _, doc := range docs {
+		doc := *doc
+		vec, err := embedder.Embed(context.Background(), doc.Title+" "+doc.Content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc.Embedding = embeddings.SerializeEmbedding(vec)
+		doc.EmbeddingModel = embedder.Model()
+		doc.EmbeddedAt = &now
+		doc.UpdatedAt = now
+		if err := db.Upsert(&doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	idx, err := Open(filepath.Join(t.TempDir(), "index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { idx.Close() })
+	idx.SetDB(db)
+	if err := idx.IndexFromStorage(db); err != nil {
+		t.Fatal(err)
+	}
+	return idx, embedder
+}
+
+// embedQuery embeds a test query
+func embedQuery(t *testing.T, embedder *embeddings.FakeEmbedder, query string) []float32 {
+	t.Helper()
+	vec, err := embedder.Embed(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vec
+}
+
+// closeTo reports whether two scores match up to float32 rounding
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+// checkDescending fails unless results are sorted by descending score
+func checkDescending(t *testing.T, results []*SearchResult) {
+	t.Helper()
+	for n := 1; n < len(results); n++ {
+		if results[n].Score > results[n-1].Score {
+			t.Errorf("result %d (%s, %.4f) outscores result %d (%s, %.4f)",
+				n, results[n].ID, results[n].Score, n-1, results[n-1].ID, results[n-1].Score)
+		}
+	}
+}
+
+func TestSemanticSearchScoresCosineSimilarity(t *testing.T) {
+	idx, embedder := newTestIndex(t, testDocs)
+	ctx := context.Background()
+	query := embedQuery(t, embedder, "restore postgres backups")
+
+	results, err := idx.SemanticSearch(ctx, "restore postgres backups", query, false, SearchOptions{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Hits) == 0 || results.Hits[0].ID != "p1" {
+		t.Fatalf("top hit = %v, want p1", ids(results.Hits))
+	}
+	checkDescending(t, results.Hits)
+
+	for _, hit := range results.Hits {
+		doc := docByID(t, hit.ID)
+		want := embeddings.CosineSimilarity(query, embedQuery(t, embedder, doc.Title+" "+doc.Content))
+		if !closeTo(hit.Score, float64(want)) {
+			t.Errorf("%s scored %.6f, want cosine similarity %.6f", hit.ID, hit.Score, want)
+		}
+	}
+}
+
+func TestSemanticSearchDimensionMismatch(t *testing.T) {
+	idx, _ := newTestIndex(t, testDocs)
+	query := embedQuery(t, embeddings.NewFakeEmbedder(8), "postgres")
+
+	_, err := idx.SemanticSearch(context.Background(), "postgres", query, false, SearchOptions{Limit: 10})
+	if !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("err = %v, want ErrDimensionMismatch", err)
+	}
+}
+
+func TestDimensionMismatchWarnedOncePerGeneration(t *testing.T) {
+	idx, embedder := newTestIndex(t, testDocs)
+	ctx := context.Background()
+	query := embedQuery(t, embedder, "postgres")
+
+	// k2 embedded by a model with another dimension
+	doc, err := idx.db.Get("k2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.Embedding = embeddings.SerializeEmbedding(embedQuery(t, embeddings.NewFakeEmbedder(8), "oncall"))
+	doc.UpdatedAt = doc.UpdatedAt.Add(time.Second)
+	if err := idx.db.Upsert(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	warnings := func() int {
+		return strings.Count(logs.String(), "Skipped documents whose embeddings")
+	}
+
+	for n := 0; n < 3; n++ {
+		if _, err := idx.SemanticSearch(ctx, "postgres", query, false, SearchOptions{Limit: 10}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := warnings(); got != 1 {
+		t.Errorf("3 searches logged %d warnings, want 1", got)
+	}
+
+	// Reindexing moves the generation on, so the next search warns again
+	idx.generation.Add(1)
+	if _, err := idx.SemanticSearch(ctx, "postgres", query, false, SearchOptions{Limit: 10}); err != nil {
+		t.Fatal(err)
+	}
+	if got := warnings(); got != 2 {
+		t.Errorf("after a new generation, %d warnings logged, want 2", got)
+	}
+}
+
+func TestHybridSearchBlendsWeightedScores(t *testing.T) {
+	idx, embedder := newTestIndex(t, testDocs)
+	ctx := context.Background()
+	const text = "postgres snapshots"
+	query := embedQuery(t, embedder, text)
+	opts := SearchOptions{Limit: 10}
+
+	for _, weight := range []float64{0, 0.3, 0.7, 1} {
+		// Candidates as hybridCandidates fetches them, normalized separately
+		keyword, semantic, _, err := idx.hybridCandidates(ctx, text, query, false, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keywordScores := normalizeScores(keyword)
+		semanticScores := normalizeScores(semantic)
+
+		results, err := idx.HybridSearch(ctx, text, query, weight, false, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results.Hits) == 0 {
+			t.Fatalf("weight %.1f: no results", weight)
+		}
+		checkDescending(t, results.Hits)
+
+		for _, hit := range results.Hits {
+			want := keywordScores[hit.ID]*weight + semanticScores[hit.ID]*(1-weight)
+			if !closeTo(hit.Score, want) {
+				t.Errorf("weight %.1f: %s scored %.6f, want %.6f", weight, hit.ID, hit.Score, want)
+			}
+		}
+	}
+}
+
+func TestHybridSearchWeightExtremesFollowOneRanking(t *testing.T) {
+	idx, embedder := newTestIndex(t, testDocs)
+	ctx := context.Background()
+	const text = "postgres"
+	query := embedQuery(t, embedder, text)
+	opts := SearchOptions{Limit: 1}
+
+	keyword, err := idx.Search(ctx, text, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	semantic, err := idx.SemanticSearch(ctx, text, query, false, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyword.Hits[0].ID == semantic.Hits[0].ID {
+		t.Fatalf("keyword and semantic search agree on %s; the test needs them to differ", keyword.Hits[0].ID)
+	}
+
+	for _, tt := range []struct {
+		weight float64
+		want   string
+	}{
+		{1, keyword.Hits[0].ID},
+		{0, semantic.Hits[0].ID},
+	} {
+		results, err := idx.HybridSearch(ctx, text, query, tt.weight, false, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := results.Hits[0].ID; got != tt.want {
+			t.Errorf("weight %.0f: top hit = %s, want %s", tt.weight, got, tt.want)
+		}
+	}
+}
+
+func TestHybridSearchRejectsBadWeight(t *testing.T) {
+	idx, embedder := newTestIndex(t, testDocs)
+	query := embedQuery(t, embedder, "postgres")
+
+	if _, err := idx.HybridSearch(context.Background(), "postgres", query, 1.5, false, SearchOptions{Limit: 10}); err == nil {
+		t.Error("keyword weight 1.5 was accepted")
+	}
+}
+
+func TestHybridSearchRRFRejectsMinScore(t *testing.T) {
+	idx, embedder := newTestIndex(t, testDocs)
+	ctx := context.Background()
+	query := embedQuery(t, embedder, "postgres")
+
+	// A threshold that suits cosine similarity would drop every fused result
+	opts := SearchOptions{Limit: 10, MinScore: 0.5}
+	if _, err := idx.HybridSearchRRF(ctx, "postgres", query, false, opts); err == nil {
+		t.Error("RRF search accepted a minimum score")
+	}
+	results, err := idx.HybridSearch(ctx, "postgres", query, 0.5, false, opts)
+	if err != nil {
+		t.Fatalf("linear hybrid search with a minimum score: %v", err)
+	}
+	if len(results.Hits) == 0 {
+		t.Error("linear hybrid search kept no results scoring at least 0.5")
+	}
+	for _, hit := range results.Hits {
+		if hit.Score < opts.MinScore {
+			t.Errorf("%s scored %.4f, below the minimum %.1f", hit.ID, hit.Score, opts.MinScore)
+		}
+	}
+}
+
+func TestHybridSearchRRFOrdersByReciprocalRank(t *testing.T) {
+	idx, embedder := newTestIndex(t, testDocs)
+	ctx := context.Background()
+	const text = "postgres snapshots"
+	query := embedQuery(t, embedder, text)
+	opts := SearchOptions{Limit: 10}
+
+	keyword, semantic, _, err := idx.hybridCandidates(ctx, text, query, false, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := make(map[string]float64)
+	for _, ranking := range [][]*SearchResult{keyword, semantic} {
+		for rank, result := range ranking {
+			want[result.ID] += 1.0 / float64(rrfK+rank+1)
+		}
+	}
+
+	results, err := idx.HybridSearchRRF(ctx, text, query, false, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Hits) != len(want) {
+		t.Fatalf("got %d results %v, want %d", len(results.Hits), ids(results.Hits), len(want))
+	}
+	checkDescending(t, results.Hits)
+	for _, hit := range results.Hits {
+		if !closeTo(hit.Score, want[hit.ID]) {
+			t.Errorf("%s scored %.6f, want %.6f", hit.ID, hit.Score, want[hit.ID])
+		}
+	}
+
+	// A document near the top of both rankings beats one found by only one
+	top := results.Hits[0].ID
+	if _, ok := want[top]; !ok || !inResults(keyword, top) || !inResults(semantic, top) {
+		t.Errorf("top hit %s isn't in both rankings", top)
+	}
+}
+
+func ids(results []*SearchResult) []string {
+	out := make([]string, len(results))
+	for n, r := range results {
+		out[n] = r.ID
+	}
+	return out
+}
+
+func inResults(results []*SearchResult, id string) bool {
+	for _, r := range results {
+		if r.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func docByID(t *testing.T, id string) *storage.Document {
+	t.Helper()
+	for _, doc := range testDocs {
+		if doc.ID == id {
+			return doc
+		}
+	}
+	t.Fatalf("no test document %s", id)
+	return nil
+}
+
+func TestSemanticSearchRescoresReembeddedDocuments(t *testing.T) {
+	idx, embedder := newTestIndex(t, testDocs)
+	ctx := context.Background()
+	query := embedQuery(t, embedder, "oncall paging")
+
+	results, err := idx.SemanticSearch(ctx, "oncall paging", query, false, SearchOptions{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.Hits[0].ID != "k2" {
+		t.Fatalf("top hit = %s, want k2", results.Hits[0].ID)
+	}
+
+	// Re-embed another document to match the query exactly, as embed does,
+	// without telling the index
+	doc, err := idx.db.Get("p3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	embeddedAt := doc.EmbeddedAt.Add(time.Second)
+	doc.Embedding = embeddings.SerializeEmbedding(query)
+	doc.EmbeddedAt = &embeddedAt
+	if err := idx.db.Upsert(doc); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err = idx.SemanticSearch(ctx, "oncall paging", query, false, SearchOptions{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.Hits[0].ID != "p3" || !closeTo(results.Hits[0].Score, 1) {
+		t.Errorf("top hit = %s (%.4f), want the re-embedded p3 scoring 1", results.Hits[0].ID, results.Hits[0].Score)
+	}
+}
+
+func TestVectorCacheDropsStaleChunks(t *testing.T) {
+	idx, embedder := newTestIndex(t, testDocs)
+	ctx := context.Background()
+	query := embedQuery(t, embedder, "postgres")
+	db := idx.db.(*storage.MemStore)
+
+	// embedChunks re-embeds p1 in n chunks, as embed -chunk-size does
+	embedChunks := func(n int) {
+		t.Helper()
+		doc, err := db.Get("p1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunks := make([]*storage.Chunk, n)
+		for c := range chunks {
+			text := fmt.Sprintf("%s part %d", doc.Content, c)
+			chunks[c] = &storage.Chunk{Index: c, Content: text,
+				Embedding: embeddings.SerializeEmbedding(embedQuery(t, embedder, text))}
+		}
+		if err := db.ReplaceChunks("p1", false, chunks); err != nil {
+			t.Fatal(err)
+		}
+		embeddedAt := doc.EmbeddedAt.Add(time.Second)
+		doc.EmbeddedAt = &embeddedAt
+		if err := db.Upsert(doc); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := idx.SemanticSearch(ctx, "postgres", query, false, SearchOptions{Limit: 10}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cached := func() int {
+		idx.vectors.mu.RLock()
+		defer idx.vectors.mu.RUnlock()
+		if doc := idx.vectors.docs["p1"]; doc != nil {
+			return len(doc.vecs)
+		}
+		return 0
+	}
+
+	embedChunks(3)
+	if n := cached(); n != 3 {
+		t.Errorf("%d vectors cached for p1 in 3 chunks, want 3", n)
+	}
+	embedChunks(1)
+	if n := cached(); n != 1 {
+		t.Errorf("%d vectors cached for p1 after re-embedding it in 1 chunk, want 1", n)
+	}
+	if err := idx.Delete("p1"); err != nil {
+		t.Fatal(err)
+	}
+	if n := cached(); n != 0 {
+		t.Errorf("%d vectors still cached for p1 after deleting it", n)
+	}
+}
+
+// benchmarkDocs is the size of the synthetic corpus for semantic benchmarks
+const benchmarkDocs = 5000
+
+// newBenchmarkIndex builds a MemStore-backed index of benchmarkDocs documents
+// with random 768-dimension embeddings; the Bleve index is left empty since
+// semantic search only reads the store
+func newBenchmarkIndex(b testing.TB) *Index {
+	b.Helper()
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	db := storage.NewMemStore()
+	now := time.Now()
+	for n := 0; n < benchmarkDocs; n++ {
+		vec := make([]float32, 768)
+		for d := range vec {
+			vec[d] = float32(rng.NormFloat64())
+		}
+		doc := &storage.Document{
+			ID:         fmt.Sprintf("doc%d", n),
+			Title:      fmt.Sprintf("Document %d", n),
+			UpdatedAt:  now.Add(-time.Duration(n) * time.Minute),
+			SyncedAt:   now,
+			EmbeddedAt: &now,
+			Embedding:  embeddings.SerializeEmbedding(embeddings.NormalizeEmbedding(vec)),
+		}
+		if err := db.Upsert(doc); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	idx, err := Open(filepath.Join(b.TempDir(), "index"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { idx.Close() })
+	idx.SetDB(db)
+	return idx
+}
+
+// BenchmarkSemanticSearch compares a search that decodes every stored
+// embedding (an empty vector cache, as before it existed) with one served
+// from the cache
+func BenchmarkSemanticSearch(b *testing.B) {
+	idx := newBenchmarkIndex(b)
+	ctx := context.Background()
+	query := make([]float32, 768)
+	query[0] = 1
+
+	b.Run("uncached", func(b *testing.B) {
+		for b.Loop() {
+			idx.vectors = vectorCache{}
+			if _, err := idx.SemanticSearch(ctx, "", query, false, SearchOptions{Limit: 10}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := idx.SemanticSearch(ctx, "", query, false, SearchOptions{Limit: 10}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// scoringInput lists the benchmark corpus and caches its vectors, as
+// SemanticSearch does before scoring
+func scoringInput(tb testing.TB, idx *Index) ([]*storage.Document, []float32) {
+	tb.Helper()
+	docs, err := idx.db.ListWithoutEmbeddings(false)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := idx.loadVectors(docs, nil, false); err != nil {
+		tb.Fatal(err)
+	}
+	query := embeddings.NormalizeEmbedding([]float32{1, 2, 3})
+	query = append(query, make([]float32, 765)...)
+	return docs, query
+}
+
+func TestScoreDocumentsParallelMatchesSerial(t *testing.T) {
+	// Shard even on a single CPU, into uneven shards
+	defer func(workers int) { scoreWorkers = workers }(scoreWorkers)
+	scoreWorkers = 7
+
+	idx := newBenchmarkIndex(t)
+	docs, query := scoringInput(t, idx)
+	if len(docs) < parallelScoreThreshold {
+		t.Fatalf("%d documents won't be scored in parallel", len(docs))
+	}
+	ctx := context.Background()
+
+	parallel, _, err := idx.scoreDocuments(ctx, docs, nil, query, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, _, err := idx.scoreShard(ctx, docs, nil, query, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parallel) != len(serial) {
+		t.Fatalf("parallel scoring returned %d documents, serial %d", len(parallel), len(serial))
+	}
+	for n := range serial {
+		if parallel[n].doc.ID != serial[n].doc.ID || parallel[n].score != serial[n].score {
+			t.Fatalf("document %d: parallel %s (%f), serial %s (%f)",
+				n, parallel[n].doc.ID, parallel[n].score, serial[n].doc.ID, serial[n].score)
+		}
+	}
+}
+
+// BenchmarkScoreDocuments compares scoring the corpus on one goroutine with
+// sharding it across CPU cores
+func BenchmarkScoreDocuments(b *testing.B) {
+	idx := newBenchmarkIndex(b)
+	docs, query := scoringInput(b, idx)
+	ctx := context.Background()
+
+	b.Run("serial", func(b *testing.B) {
+		for b.Loop() {
+			if _, _, err := idx.scoreShard(ctx, docs, nil, query, false, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		for b.Loop() {
+			if _, _, err := idx.scoreDocuments(ctx, docs, nil, query, false, nil); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestHybridTotalCappedByCandidatePool(t *testing.T) {
+	idx, embedder := newTestIndex(t, testDocs)
+	ctx := context.Background()
+	const text = "postgres snapshots"
+	query := embedQuery(t, embedder, text)
+
+	for _, tt := range []struct {
+		limit  int
+		capped bool
+	}{
+		{1, true},   // 3 candidates from each search, which match more
+		{10, false}, // The pools hold every match
+	} {
+		for name, search := range map[string]func(SearchOptions) (*SearchResults, error){
+			"linear": func(opts SearchOptions) (*SearchResults, error) {
+				return idx.HybridSearch(ctx, text, query, 0.5, false, opts)
+			},
+			"rrf": func(opts SearchOptions) (*SearchResults, error) {
+				return idx.HybridSearchRRF(ctx, text, query, false, opts)
+			},
+		} {
+			results, err := search(SearchOptions{Limit: tt.limit})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if results.TotalCapped != tt.capped {
+				t.Errorf("%s, limit %d: TotalCapped = %v (total %d), want %v",
+					name, tt.limit, results.TotalCapped, results.TotalHits, tt.capped)
+			}
+			if text := results.TotalText(); strings.HasSuffix(text, "+") != tt.capped {
+				t.Errorf("%s, limit %d: TotalText() = %q, want a \"+\" suffix only when capped", name, tt.limit, text)
+			}
+			if !tt.capped && results.TotalHits != uint64(len(results.Hits)) {
+				t.Errorf("%s, limit %d: total %d, but %d hits on the only page",
+					name, tt.limit, results.TotalHits, len(results.Hits))
+			}
+		}
+	}
+}
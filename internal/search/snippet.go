@@ -0,0 +1,165 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
+)
+
+// snippetLength is the approximate size of a semantic result snippet,
+// similar to Bleve's default fragment size
+const snippetLength = 200
+
+// stopWords are skipped when matching query terms, like the standard analyzer
+var stopWords = func() analysis.TokenMap {
+	m := analysis.NewTokenMap()
+	_ = m.LoadBytes(en.EnglishStopWords)
+	return m
+}()
+
+// contentSnippet picks the passage of content that shares the most words with
+// the query and returns it HTML-escaped with matches wrapped in <mark>, like
+// Bleve's html highlighter. Falls back to the start of the document when no
+// passage mentions a query term. Returns "" for empty content.
+func contentSnippet(content, query string) string {
+	runes := []rune(content)
+	if len(runes) == 0 {
+		return ""
+	}
+
+	terms := make(map[string]bool)
+	for _, t := range nameTerms(query) {
+		if !stopWords[t] {
+			terms[t] = true
+		}
+	}
+
+	// matches[i] is the query term word i matches ("" if none)
+	words := wordSpans(runes)
+	matches := make([]string, len(words))
+	for i, w := range words {
+		if term := strings.ToLower(string(runes[w.start:w.end])); terms[term] {
+			matches[i] = term
+		}
+	}
+
+	// Slide a window over word starts and keep the one covering the most
+	// distinct query terms (earliest wins ties)
+	bestStart, bestCount := 0, 0
+	for i, w := range words {
+		if matches[i] == "" {
+			continue // The best window always starts on a match
+		}
+		seen := make(map[string]bool)
+		for j := i; j < len(words) && words[j].end-w.start <= snippetLength; j++ {
+			if matches[j] != "" {
+				seen[matches[j]] = true
+			}
+		}
+		if len(seen) > bestCount {
+			bestStart, bestCount = w.start, len(seen)
+		}
+	}
+
+	// Lead in with a little context before the first match
+	if bestCount > 0 && bestStart <= snippetLength/5 {
+		bestStart = 0
+	} else if bestCount > 0 {
+		for _, w := range words {
+			if w.start >= bestStart-snippetLength/5 {
+				bestStart = min(w.start, bestStart)
+				break
+			}
+		}
+	}
+
+	end := min(bestStart+snippetLength, len(runes))
+	// Don't cut the last word in half
+	for end < len(runes) && end > bestStart && !unicode.IsSpace(runes[end-1]) && !unicode.IsSpace(runes[end]) {
+		end--
+	}
+	if end == bestStart {
+		end = min(bestStart+snippetLength, len(runes))
+	}
+
+	var sb strings.Builder
+	if bestStart > 0 {
+		sb.WriteString("…")
+	}
+	pos := bestStart
+	for i, w := range words {
+		if w.start < bestStart || w.end > end || matches[i] == "" {
+			continue
+		}
+		sb.WriteString(html.EscapeString(string(runes[pos:w.start])))
+		sb.WriteString("<mark>")
+		sb.WriteString(html.EscapeString(string(runes[w.start:w.end])))
+		sb.WriteString("</mark>")
+		pos = w.end
+	}
+	sb.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		sb.WriteString("…")
+	}
+
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// snippetFragments wraps a snippet of text as a Content fragment, the shape
+// keyword search uses for highlights (nil if text is empty)
+func snippetFragments(text, query string) map[string][]string {
+	snippet := contentSnippet(text, query)
+	if snippet == "" {
+		return nil
+	}
+	return map[string][]string{"Content": {snippet}}
+}
+
+// wordSpan is the rune range of a word in a text
+type wordSpan struct {
+	start, end int
+}
+
+// wordSpans splits text into letter/digit words, matching nameTerms
+func wordSpans(runes []rune) []wordSpan {
+	var spans []wordSpan
+	start := -1
+	for i, r := range runes {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			spans = append(spans, wordSpan{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, wordSpan{start, len(runes)})
+	}
+	return spans
+}
+
+// markTags removes the <mark> tags from a highlighted fragment
+var markTags = strings.NewReplacer("<mark>", "", "</mark>", "")
+
+// PlainFragment turns a highlighted fragment back into plain text, dropping
+// the <mark> tags and HTML escaping
+func PlainFragment(fragment string) string {
+	return html.UnescapeString(markTags.Replace(fragment))
+}
+
+// HighlightedTitle returns the title as HTML with query matches in <mark>
+// when keyword search matched it, otherwise just HTML-escaped
+// A fragment cut short of the whole title is ignored.
+func (r *SearchResult) HighlightedTitle() string {
+	for _, fragment := range r.Fragments["Title"] {
+		if PlainFragment(fragment) == r.Title {
+			return fragment
+		}
+	}
+	return html.EscapeString(r.Title)
+}
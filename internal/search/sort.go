@@ -0,0 +1,99 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/renderinc/slab-search/internal/storage"
+)
+
+// Result orders for SearchOptions.Sort
+const (
+	SortRelevance = "relevance" // Best match first (the default)
+	SortUpdated   = "updated"   // Most recently updated in Slab first
+	SortPublished = "published" // Most recently published first
+)
+
+// dateSortCandidates is how many of the best semantic or hybrid matches are
+// re-sorted by date; sorting every scored document would surface barely
+// related ones
+const dateSortCandidates = 100
+
+// checkSort validates a SearchOptions.Sort value
+func checkSort(order string) error {
+	switch order {
+	case "", SortRelevance, SortUpdated, SortPublished:
+		return nil
+	}
+	return fmt.Errorf("unknown sort %q (want %s, %s or %s)", order, SortRelevance, SortUpdated, SortPublished)
+}
+
+// sortsByDate reports whether order replaces relevance ranking; unknown
+// orders do too, so sortByDate can reject them
+func sortsByDate(order string) bool {
+	return order != "" && order != SortRelevance
+}
+
+// bleveSort returns the Bleve sort order for keyword search, newest first
+// with ties broken by relevance (nil for relevance order)
+func bleveSort(order string) []string {
+	switch order {
+	case SortUpdated:
+		return []string{"-UpdatedAt", "-_score"}
+	case SortPublished:
+		return []string{"-PublishedAt", "-_score"}
+	}
+	return nil
+}
+
+// sortByDate runs search for the best matches by relevance, then orders
+// them by the date opts.Sort names (newest first) and returns the requested
+// page. Only the top dateSortCandidates (or the requested depth, if deeper)
+// are considered, and TotalHits counts just those (see TotalCapped).
+func (i *Index) sortByDate(opts SearchOptions, search func(SearchOptions) (*SearchResults, error)) (*SearchResults, error) {
+	if err := checkSort(opts.Sort); err != nil {
+		return nil, err
+	}
+
+	candidateOpts := opts
+	candidateOpts.Sort = ""
+	candidateOpts.Offset = 0
+	candidateOpts.Limit = max(dateSortCandidates, opts.Offset+opts.Limit)
+	pool, err := search(candidateOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := pool.Hits
+	dates := make(map[string]time.Time, len(candidates))
+	for _, result := range candidates {
+		doc, err := i.db.Get(result.ID)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue // Indexed but since deleted; sorts as undated
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get document %s: %w", result.ID, err)
+		}
+		dates[result.ID] = documentDate(doc, opts.Sort)
+	}
+
+	// Stable, so documents with the same date stay in relevance order
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return dates[candidates[a].ID].After(dates[candidates[b].ID])
+	})
+
+	start := min(opts.Offset, len(candidates))
+	end := min(opts.Offset+opts.Limit, len(candidates))
+	capped := pool.TotalCapped || pool.TotalHits > uint64(len(candidates))
+	return &SearchResults{Hits: candidates[start:end], TotalHits: uint64(len(candidates)), TotalCapped: capped}, nil
+}
+
+// documentDate returns the date a document sorts by for order
+func documentDate(doc *storage.Document, order string) time.Time {
+	if order == SortPublished {
+		return doc.PublishedAt
+	}
+	return doc.UpdatedAt
+}
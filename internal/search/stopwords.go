@@ -0,0 +1,41 @@
+package search
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// StopwordsFile is the name of the stopword list in the data directory
+// It replaces the built-in English list: one or more words per line, with
+// "#" starting a comment
+const StopwordsFile = "stopwords.txt"
+
+// LoadStopwords reads a stopword list, lowercasing each word
+// A missing file returns nil, meaning the built-in English list is used;
+// an existing but empty file disables stopword removal.
+func LoadStopwords(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open stopwords: %w", err)
+	}
+	defer f.Close()
+
+	words := []string{} // Non-nil even if empty, so an empty file disables stopwords
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		for _, word := range strings.Fields(line) {
+			words = append(words, strings.ToLower(word))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read stopwords %s: %w", path, err)
+	}
+	return words, nil
+}
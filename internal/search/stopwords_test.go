@@ -0,0 +1,112 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/renderinc/slab-search/internal/storage"
+)
+
+func TestLoadStopwords(t *testing.T) {
+	dir := t.TempDir()
+
+	if words, err := LoadStopwords(filepath.Join(dir, "missing.txt")); err != nil || words != nil {
+		t.Errorf("missing file = %v, %v, want nil (the built-in list)", words, err)
+	}
+
+	empty := filepath.Join(dir, "empty.txt")
+	if err := os.WriteFile(empty, []byte("# nothing here\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if words, err := LoadStopwords(empty); err != nil || words == nil || len(words) != 0 {
+		t.Errorf("empty file = %#v, %v, want an empty non-nil list", words, err)
+	}
+
+	custom := filepath.Join(dir, StopwordsFile)
+	if err := os.WriteFile(custom, []byte("# team jargon\nRunbook  FYI\nplease # trailing comment\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	words, err := LoadStopwords(custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"runbook", "fyi", "please"}; !slices.Equal(words, want) {
+		t.Errorf("LoadStopwords = %v, want %v", words, want)
+	}
+}
+
+func TestCustomStopwordsAreNotIndexed(t *testing.T) {
+	idx, _ := newTestIndex(t, []*storage.Document{
+		{ID: "r1", Title: "Deploy runbook", Content: "The runbook for deploys, please read it."},
+		{ID: "r2", Title: "Oncall", Content: "Paging and the escalation policy."},
+	})
+
+	path := filepath.Join(t.TempDir(), StopwordsFile)
+	if err := os.WriteFile(path, []byte("runbook\nplease\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	words, err := LoadStopwords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx.SetStopwords(words)
+	if err := idx.Rebuild(idx.db, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if terms := indexedTerms(t, idx, "Content"); !slices.Contains(terms, "the") {
+		t.Errorf("Content terms %v don't include \"the\", which the custom list doesn't stop", terms)
+	}
+	for _, field := range []string{"Title", "Content"} {
+		for _, term := range indexedTerms(t, idx, field) {
+			if term == "runbook" || term == "pleas" || term == "please" {
+				t.Errorf("stopword %q is indexed in %s", term, field)
+			}
+		}
+	}
+
+	ctx := context.Background()
+	for _, query := range []string{"runbook", "please"} {
+		results, err := idx.Search(ctx, query, SearchOptions{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results.Hits) != 0 {
+			t.Errorf("search for stopword %q found %v", query, ids(results.Hits))
+		}
+	}
+
+	// The custom list replaces the English one, so "the" is indexed now
+	results, err := idx.Search(ctx, "the", SearchOptions{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results.Hits) != 2 {
+		t.Errorf("search for \"the\" found %v, want both documents", ids(results.Hits))
+	}
+}
+
+// indexedTerms lists the terms in a field's dictionary
+func indexedTerms(t *testing.T, idx *Index, field string) []string {
+	t.Helper()
+	index, release := idx.acquire()
+	defer release()
+
+	dict, err := index.FieldDict(field)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dict.Close()
+
+	var terms []string
+	for entry, err := dict.Next(); entry != nil; entry, err = dict.Next() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		terms = append(terms, entry.Term)
+	}
+	return terms
+}
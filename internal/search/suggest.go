@@ -0,0 +1,203 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/blevesearch/bleve/v2"
+	bleveSearch "github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+)
+
+// suggestFields are the term dictionaries searched for spelling corrections
+var suggestFields = []string{"Title", "Content"}
+
+// markPattern extracts the first highlighted word from a Bleve fragment
+var markPattern = regexp.MustCompile(`<mark>([^<]+)</mark>`)
+
+// Suggest proposes a respelling of query using indexed terms, for "did you
+// mean" prompts after a search with no results
+// Each word that isn't in the index is replaced with the closest indexed term
+// (edit distance 1 for short words, 2 otherwise; the more frequent term wins
+// ties). Returns "" if every word is already indexed or nothing is close.
+func (i *Index) Suggest(query string) (string, error) {
+	index, release := i.acquire()
+	defer release()
+
+	// Analyze words as Content is indexed (stemming, custom stopwords)
+	indexMapping := index.Mapping()
+	analyzer := indexMapping.AnalyzerNamed(indexMapping.AnalyzerNameForPath("Content"))
+	if analyzer == nil {
+		return "", fmt.Errorf("content analyzer not available")
+	}
+
+	words := nameTerms(query)
+	changed := false
+	for w, word := range words {
+		// Skip short words and query operators
+		if utf8.RuneCountInString(word) < 3 {
+			continue
+		}
+
+		// Terms are indexed stemmed, so compare stems (stopwords and
+		// synonym-expanded words don't analyze to a single term)
+		tokens := analyzer.Analyze([]byte(word))
+		if len(tokens) != 1 {
+			continue
+		}
+		stem := string(tokens[0].Term)
+
+		correction, err := closestTerm(index, stem, maxEdits(word))
+		if err != nil {
+			return "", err
+		}
+		if correction.Term == "" || correction.Term == stem {
+			continue
+		}
+
+		words[w] = surfaceForm(index, correction)
+		changed = true
+	}
+
+	if !changed {
+		return "", nil
+	}
+	return strings.Join(words, " "), nil
+}
+
+// maxEdits is the largest edit distance tolerated when correcting word
+func maxEdits(word string) int {
+	if utf8.RuneCountInString(word) <= 4 {
+		return 1
+	}
+	return 2
+}
+
+// termMatch is an indexed term close to a misspelled stem
+type termMatch struct {
+	Field    string
+	Term     string
+	Count    uint64
+	Distance int
+}
+
+// closestTerm finds the indexed term nearest to stem within maxDistance
+// An exact match (the stem is already indexed) is returned as-is
+func closestTerm(index bleve.Index, stem string, maxDistance int) (termMatch, error) {
+	var best termMatch
+	for _, field := range suggestFields {
+		dict, err := index.FieldDict(field)
+		if err != nil {
+			return termMatch{}, fmt.Errorf("field dictionary %s: %w", field, err)
+		}
+
+		entry, err := dict.Next()
+		for err == nil && entry != nil {
+			distance, tooFar := bleveSearch.LevenshteinDistanceMax(stem, entry.Term, maxDistance)
+			if !tooFar && distance <= maxDistance {
+				better := best.Term == "" || distance < best.Distance ||
+					(distance == best.Distance && entry.Count > best.Count)
+				if better {
+					best = termMatch{Field: field, Term: entry.Term, Count: entry.Count, Distance: distance}
+				}
+			}
+			entry, err = dict.Next()
+		}
+		dict.Close()
+		if err != nil {
+			return termMatch{}, fmt.Errorf("read field dictionary %s: %w", field, err)
+		}
+
+		if best.Distance == 0 && best.Term != "" {
+			break // Already indexed, nothing to correct
+		}
+	}
+	return best, nil
+}
+
+// surfaceForm turns an indexed (stemmed) term back into a word as it appears
+// in a document, so suggestions read "kubernetes" rather than "kubernet"
+// Falls back to the stem if no highlighted occurrence is found.
+func surfaceForm(index bleve.Index, match termMatch) string {
+	q := bleve.NewTermQuery(match.Term)
+	q.SetField(match.Field)
+
+	req := bleve.NewSearchRequestOptions(q, 1, 0, false)
+	req.Highlight = bleve.NewHighlightWithStyle("html")
+	req.Highlight.AddField(match.Field)
+
+	results, err := index.Search(req)
+	if err != nil || len(results.Hits) == 0 {
+		return match.Term
+	}
+
+	for _, fragment := range results.Hits[0].Fragments[match.Field] {
+		if m := markPattern.FindStringSubmatch(fragment); m != nil {
+			return strings.ToLower(m[1])
+		}
+	}
+	return match.Term
+}
+
+const (
+	titlePrefixField    = "TitlePrefix"
+	titlePrefixAnalyzer = "title_prefix"
+
+	// maxTitlePrefixLength is the longest word prefix indexed for title
+	// suggestions; longer typed words are truncated to match
+	maxTitlePrefixLength = 20
+)
+
+// TitleSuggestion is a document title matching a typed prefix
+type TitleSuggestion struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+// SuggestTitles returns up to limit documents whose title has a word starting
+// with each word of prefix, for search-as-you-type ("postg conf" matches
+// "Postgres Configuration")
+// Returns an error if the index was built before title prefixes were indexed
+// (run reindex to add them).
+func (i *Index) SuggestTitles(prefix string, limit int) ([]*TitleSuggestion, error) {
+	index, release := i.acquire()
+	defer release()
+
+	if index.Mapping().AnalyzerNamed(titlePrefixAnalyzer) == nil {
+		return nil, fmt.Errorf("index has no title prefixes; run reindex to enable suggestions")
+	}
+
+	words := nameTerms(prefix)
+	if len(words) == 0 || limit <= 0 {
+		return nil, nil
+	}
+
+	// Every typed word must prefix some title word; each is a single term
+	// lookup thanks to the edge n-grams, which keeps this fast per keystroke
+	terms := make([]query.Query, 0, len(words))
+	for _, word := range words {
+		if runes := []rune(word); len(runes) > maxTitlePrefixLength {
+			word = string(runes[:maxTitlePrefixLength])
+		}
+		q := bleve.NewTermQuery(word)
+		q.SetField(titlePrefixField)
+		terms = append(terms, q)
+	}
+
+	req := bleve.NewSearchRequestOptions(bleve.NewConjunctionQuery(terms...), limit, 0, false)
+	req.Fields = []string{"Title"}
+
+	results, err := index.Search(req)
+	if err != nil {
+		return nil, fmt.Errorf("suggest titles: %w", err)
+	}
+
+	suggestions := make([]*TitleSuggestion, 0, len(results.Hits))
+	for _, hit := range results.Hits {
+		title, _ := hit.Fields["Title"].(string)
+		suggestions = append(suggestions, &TitleSuggestion{ID: hit.ID, Title: title})
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,147 @@
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/registry"
+)
+
+// SynonymsFile is the name of the synonyms file in the data directory
+// It holds a JSON array of synonym groups, e.g. [["k8s", "kube", "kubernetes"]]
+const SynonymsFile = "synonyms.json"
+
+const (
+	// synonymFilterType is the registered token filter type that expands words
+	// to their synonym group; the groups live in the filter's config, so they
+	// are saved with the index mapping
+	synonymFilterType = "slab_synonyms"
+
+	synonymFilterName = "synonyms"
+)
+
+func init() {
+	if err := registry.RegisterTokenFilter(synonymFilterType, synonymFilterConstructor); err != nil {
+		panic(err)
+	}
+}
+
+// LoadSynonyms reads synonym groups from a JSON file
+// Terms are lowercased and groups with fewer than two terms are dropped.
+// A missing file means no synonyms.
+func LoadSynonyms(path string) ([][]string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read synonyms: %w", err)
+	}
+
+	var groups [][]string
+	if err := json.Unmarshal(data, &groups); err != nil {
+		return nil, fmt.Errorf("parse synonyms %s: %w", path, err)
+	}
+
+	return cleanSynonyms(groups)
+}
+
+// cleanSynonyms lowercases terms and drops empty or single-term groups
+// Multi-word terms are rejected since the filter expands one token at a time
+func cleanSynonyms(groups [][]string) ([][]string, error) {
+	var cleaned [][]string
+	for _, group := range groups {
+		var terms []string
+		for _, term := range group {
+			term = strings.ToLower(strings.TrimSpace(term))
+			if term == "" {
+				continue
+			}
+			if strings.ContainsFunc(term, func(r rune) bool { return r == ' ' || r == '\t' }) {
+				return nil, fmt.Errorf("synonym %q: multi-word synonyms aren't supported", term)
+			}
+			terms = append(terms, term)
+		}
+		if len(terms) > 1 {
+			cleaned = append(cleaned, terms)
+		}
+	}
+	return cleaned, nil
+}
+
+// synonymFilter emits every member of a token's synonym group at the token's
+// position, like a multi-term token at index time
+type synonymFilter struct {
+	groups map[string][]string // Term -> all terms in its group
+}
+
+func synonymFilterConstructor(config map[string]interface{}, cache *registry.Cache) (analysis.TokenFilter, error) {
+	groups, err := synonymGroupsFromConfig(config["synonyms"])
+	if err != nil {
+		return nil, err
+	}
+
+	f := &synonymFilter{groups: make(map[string][]string)}
+	for _, group := range groups {
+		for _, term := range group {
+			f.groups[term] = group
+		}
+	}
+	return f, nil
+}
+
+// synonymGroupsFromConfig accepts groups as built ([][]string) or as decoded
+// from a saved index mapping ([]interface{} of []interface{})
+func synonymGroupsFromConfig(raw interface{}) ([][]string, error) {
+	switch v := raw.(type) {
+	case nil:
+		return nil, nil
+	case [][]string:
+		return v, nil
+	case []interface{}:
+		groups := make([][]string, 0, len(v))
+		for _, rawGroup := range v {
+			items, ok := rawGroup.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("synonym group must be a list, got %T", rawGroup)
+			}
+			group := make([]string, 0, len(items))
+			for _, item := range items {
+				term, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("synonym must be a string, got %T", item)
+				}
+				group = append(group, term)
+			}
+			groups = append(groups, group)
+		}
+		return groups, nil
+	default:
+		return nil, fmt.Errorf("synonyms must be a list of groups, got %T", raw)
+	}
+}
+
+func (f *synonymFilter) Filter(input analysis.TokenStream) analysis.TokenStream {
+	output := make(analysis.TokenStream, 0, len(input))
+	for _, token := range input {
+		output = append(output, token)
+
+		for _, synonym := range f.groups[string(token.Term)] {
+			if synonym == string(token.Term) {
+				continue
+			}
+			output = append(output, &analysis.Token{
+				Term:     []byte(synonym),
+				Start:    token.Start,
+				End:      token.End,
+				Position: token.Position,
+				Type:     token.Type,
+			})
+		}
+	}
+	return output
+}
@@ -0,0 +1,121 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+)
+
+// SetTopicBoosts sets score multipliers for documents in the given topics
+// (exact names), applied to hybrid results after the keyword and semantic
+// rankings are merged. A document in several boosted topics gets the
+// largest boost. Non-positive factors are ignored; nil disables boosting.
+func (i *Index) SetTopicBoosts(boosts map[string]float64) {
+	i.topicBoosts = make(map[string]float64, len(boosts))
+	for topic, factor := range boosts {
+		if factor > 0 {
+			i.topicBoosts[topic] = factor
+		}
+	}
+}
+
+// boostTopics multiplies the score of each result in a boosted topic
+func (i *Index) boostTopics(results []*SearchResult) {
+	if len(i.topicBoosts) == 0 {
+		return
+	}
+	for _, result := range results {
+		boost := 0.0
+		for _, topic := range result.Topics {
+			boost = max(boost, i.topicBoosts[topic])
+		}
+		if boost > 0 {
+			result.Score *= boost
+			explainStep(result, fmt.Sprintf("topic boost ×%g", boost))
+		}
+	}
+}
+
+// storedTopics reads the Topics field of a hit, which Bleve returns as a
+// string for a single topic and a slice for several
+func storedTopics(field any) []string {
+	switch v := field.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		topics := make([]string, 0, len(v))
+		for _, t := range v {
+			if topic, ok := t.(string); ok {
+				topics = append(topics, topic)
+			}
+		}
+		return topics
+	}
+	return nil
+}
+
+// TopicDocument is a document listed by topic
+type TopicDocument struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Author    string    `json:"author"`
+	SlabURL   string    `json:"slab_url"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListTopic returns a page of the documents tagged with a topic (exact name),
+// most recently updated first, and the number of documents in the topic
+func (i *Index) ListTopic(name string, limit, offset int) ([]*TopicDocument, uint64, error) {
+	q := bleve.NewTermQuery(name)
+	q.SetField("Topics")
+
+	search := bleve.NewSearchRequestOptions(q, limit, offset, false)
+	search.SortBy([]string{"-UpdatedAt", "_id"})
+	search.Fields = []string{"Title", "Author", "SlabURL", "UpdatedAt"}
+
+	results, err := i.searchIndex(search)
+	if err != nil {
+		return nil, 0, fmt.Errorf("list topic: %w", err)
+	}
+
+	docs := make([]*TopicDocument, 0, len(results.Hits))
+	for _, hit := range results.Hits {
+		doc := &TopicDocument{ID: hit.ID}
+		if title, ok := hit.Fields["Title"].(string); ok {
+			doc.Title = title
+		}
+		if author, ok := hit.Fields["Author"].(string); ok {
+			doc.Author = author
+		}
+		if url, ok := hit.Fields["SlabURL"].(string); ok {
+			doc.SlabURL = url
+		}
+		// Stored datetimes come back as RFC 3339 strings
+		if updated, ok := hit.Fields["UpdatedAt"].(string); ok {
+			doc.UpdatedAt, _ = time.Parse(time.RFC3339, updated)
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, results.Total, nil
+}
+
+// maxTopicLookup bounds the topics scanned by MatchTopic
+const maxTopicLookup = 1000
+
+// MatchTopic finds the indexed topic whose name equals name ignoring case,
+// for suggesting the exact name ListTopic needs ("" if none)
+func (i *Index) MatchTopic(name string) (string, error) {
+	topics, err := i.TopicCounts(maxTopicLookup)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range topics {
+		if strings.EqualFold(t.Name, name) {
+			return t.Name, nil
+		}
+	}
+	return "", nil
+}
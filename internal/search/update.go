@@ -0,0 +1,121 @@
+package search
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/renderinc/slab-search/internal/storage"
+)
+
+// lastIndexedKey stores when the index was last brought up to date with the
+// database, in Bleve's internal key/value store so it lives and dies with
+// the index
+var lastIndexedKey = []byte("last_indexed")
+
+// updateBatchSize is the number of documents per Bleve batch in Update
+const updateBatchSize = 100
+
+// UpdateStats summarizes an incremental index update
+type UpdateStats struct {
+	Indexed int // Documents added or re-indexed
+	Deleted int // Index entries removed for deleted or archived documents
+}
+
+// LastIndexed returns when Rebuild or Update last finished syncing the index
+// with the database (zero if neither has run on this index)
+func (i *Index) LastIndexed() (time.Time, error) {
+	index, release := i.acquire()
+	defer release()
+
+	data, err := index.GetInternal(lastIndexedKey)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("read last indexed time: %w", err)
+	}
+	if len(data) == 0 {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, string(data))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse last indexed time: %w", err)
+	}
+	return t, nil
+}
+
+// setLastIndexed records t as the time index last matched the database
+func setLastIndexed(index bleve.Index, t time.Time) error {
+	if err := index.SetInternal(lastIndexedKey, []byte(t.Format(time.RFC3339Nano))); err != nil {
+		return fmt.Errorf("record last indexed time: %w", err)
+	}
+	return nil
+}
+
+// Update re-indexes documents changed in the database after since and removes
+// index entries whose documents were deleted or archived, then records the
+// time for LastIndexed. Pass LastIndexed() as since to pick up where the last
+// run left off. Mapping changes (synonyms, stopwords) still need Rebuild.
+func (i *Index) Update(db storage.Store, since time.Time) (*UpdateStats, error) {
+	// Anything written while updating is picked up by the next run
+	started := time.Now()
+
+	changed, err := db.ListChangedSince(since)
+	if err != nil {
+		return nil, fmt.Errorf("list changed documents: %w", err)
+	}
+	index, release := i.acquire()
+	defer release()
+	defer i.generation.Add(1)
+
+	stats := &UpdateStats{}
+	for start := 0; start < len(changed); start += updateBatchSize {
+		end := min(start+updateBatchSize, len(changed))
+
+		batch := index.NewBatch()
+		for _, doc := range changed[start:end] {
+			indexDoc := NewIndexedDocument(doc)
+			if err := batch.Index(indexDoc.ID, indexDoc); err != nil {
+				return nil, fmt.Errorf("batch index %s: %w", doc.ID, err)
+			}
+			i.vectors.invalidate(doc.ID)
+		}
+		if err := index.Batch(batch); err != nil {
+			return nil, fmt.Errorf("commit batch: %w", err)
+		}
+		stats.Indexed += end - start
+	}
+
+	// Deletions leave no row behind to compare timestamps with, so find them
+	// by diffing IDs
+	dbIDs, err := db.ListIDs(false)
+	if err != nil {
+		return nil, fmt.Errorf("list document IDs: %w", err)
+	}
+	indexIDs, err := allIDs(index)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := make(map[string]bool, len(dbIDs))
+	for _, id := range dbIDs {
+		stored[id] = true
+	}
+	batch := index.NewBatch()
+	for _, id := range indexIDs {
+		if !stored[id] {
+			batch.Delete(id)
+			i.vectors.invalidate(id)
+			stats.Deleted++
+		}
+	}
+	if stats.Deleted > 0 {
+		if err := index.Batch(batch); err != nil {
+			return nil, fmt.Errorf("commit deletes: %w", err)
+		}
+	}
+
+	if err := setLastIndexed(index, started); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,263 @@
+package search
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/renderinc/slab-search/internal/embeddings"
+	"github.com/renderinc/slab-search/internal/storage"
+)
+
+// vectorIndex is an in-memory ANN accelerator for semantic search
+// Vectors still live in SQLite; this is rebuilt from there on demand
+type vectorIndex struct {
+	nomic     *hnsw                        // Graph over the Embedding field
+	qwen      *hnsw                        // Graph over the EmbeddingQwen field
+	nomicDocs int                          // Documents with a vector in the nomic graph
+	qwenDocs  int                          // Documents with a vector in the qwen graph
+	docs      map[string]*storage.Document // Metadata for hits (content and vectors stripped)
+	chunks    map[string]chunkRef          // Chunk graph keys to their document and text
+
+	generation uint64 // Index generation it was built at; stale once that moves on
+}
+
+// chunkRef links a chunk vector in a graph back to its document
+type chunkRef struct {
+	docID   string
+	content string
+}
+
+// graph returns the graph for the requested embedding field (may be nil)
+// and the number of documents it covers
+func (v *vectorIndex) graph(useQwen bool) (*hnsw, int) {
+	if useQwen {
+		return v.qwen, v.qwenDocs
+	}
+	return v.nomic, v.nomicDocs
+}
+
+// build adds each document's vectors for one embedding field to a new graph
+// Chunked documents contribute one node per chunk instead of the whole-document vector
+// The graph takes the dimension of the first vector; documents with other
+// dimensions are left out and reported
+func (v *vectorIndex) build(docs []*storage.Document, chunks map[string][]*storage.Chunk, useQwen bool) (*hnsw, int) {
+	var g *hnsw
+	count, mismatched := 0, 0
+	for _, doc := range docs {
+		nodes := 0
+		if docChunks := chunks[doc.ID]; len(docChunks) > 0 {
+			for _, c := range docChunks {
+				key := chunkKey(doc.ID, c.Index)
+				var added bool
+				if g, added = addToGraph(g, key, c.Embedding); added {
+					v.chunks[key] = chunkRef{docID: doc.ID, content: c.Content}
+					nodes++
+				}
+			}
+		} else {
+			data := doc.Embedding
+			if useQwen {
+				data = doc.EmbeddingQwen
+			}
+			var added bool
+			if g, added = addToGraph(g, doc.ID, data); added {
+				nodes++
+			}
+		}
+		if nodes > 0 {
+			count++
+		} else if g != nil && hasVector(doc, chunks[doc.ID], useQwen) {
+			mismatched++
+		}
+	}
+
+	if mismatched > 0 {
+		slog.Warn("Vector index left out documents with a different embedding dimension (see stats)",
+			"documents", mismatched, "dimension", g.dim)
+	}
+	return g, count
+}
+
+// hasVector reports whether a document has any stored vector for a field
+func hasVector(doc *storage.Document, chunks []*storage.Chunk, useQwen bool) bool {
+	if len(chunks) > 0 {
+		return true
+	}
+	if useQwen {
+		return len(doc.EmbeddingQwen) > 0
+	}
+	return len(doc.Embedding) > 0
+}
+
+// BuildVectorIndex builds (or refreshes) the in-memory ANN index from the
+// embeddings stored in the database. Until it is built, SemanticSearch uses
+// the exact brute-force scan. Documents indexed or removed afterwards make
+// it stale, and SemanticSearch falls back to the scan until it's rebuilt.
+// Requires SetDB.
+func (i *Index) BuildVectorIndex() error {
+	if i.db == nil {
+		return fmt.Errorf("database not set")
+	}
+
+	// Taken before reading, so changes made during the build count as stale
+	generation := i.Generation()
+
+	docs, err := i.db.List(false) // Don't include archived
+	if err != nil {
+		return fmt.Errorf("list documents: %w", err)
+	}
+
+	nomicChunks, err := i.db.ListChunks(false)
+	if err != nil {
+		return fmt.Errorf("list chunks: %w", err)
+	}
+	qwenChunks, err := i.db.ListChunks(true)
+	if err != nil {
+		return fmt.Errorf("list chunks: %w", err)
+	}
+
+	v := &vectorIndex{
+		docs:       make(map[string]*storage.Document, len(docs)),
+		chunks:     make(map[string]chunkRef),
+		generation: generation,
+	}
+	v.nomic, v.nomicDocs = v.build(docs, nomicChunks, false)
+	v.qwen, v.qwenDocs = v.build(docs, qwenChunks, true)
+
+	for _, doc := range docs {
+		// Keep only the metadata needed to build results
+		meta := *doc
+		meta.Content = ""
+		meta.Embedding = nil
+		meta.EmbeddingQwen = nil
+		v.docs[doc.ID] = &meta
+	}
+
+	// A new ANN index changes semantic results, so it moves the generation on
+	// (dropping cached results). A build that documents changed under is
+	// already stale and is dropped, keeping any newer index.
+	i.annMu.Lock()
+	defer i.annMu.Unlock()
+	if !i.generation.CompareAndSwap(generation, generation+1) {
+		slog.Debug("Documents changed while building the vector index, dropping it")
+		return nil
+	}
+	v.generation = generation + 1
+	i.ann = v
+
+	return nil
+}
+
+// addToGraph inserts a serialized embedding into g, creating g on first use
+// Vectors whose dimension differs from the graph's are skipped; reports
+// whether the vector was added
+func addToGraph(g *hnsw, id string, data []byte) (*hnsw, bool) {
+	vec := embeddings.NormalizeEmbedding(embeddings.DeserializeEmbedding(data))
+	if vec == nil {
+		return g, false
+	}
+
+	if g == nil {
+		g = newHNSW(len(vec))
+	}
+	if len(vec) != g.dim {
+		return g, false
+	}
+	g.insert(id, vec)
+	return g, true
+}
+
+// annSnippet builds a result snippet from the matching chunk, or from the
+// document content (which the ANN index doesn't keep in memory)
+func (i *Index) annSnippet(docID, chunk, query string) map[string][]string {
+	if chunk != "" {
+		return snippetFragments(chunk, query)
+	}
+
+	doc, err := i.db.Get(docID)
+	if err != nil {
+		return nil // Snippets are best-effort
+	}
+	return snippetFragments(doc.Content, query)
+}
+
+// annSearch answers a semantic query from the ANN index
+// Returns false if the index isn't built, is stale or can't serve this query
+func (i *Index) annSearch(queryText string, queryEmbedding []float32, useQwen bool, opts SearchOptions) (*SearchResults, bool) {
+	i.annMu.RLock()
+	v := i.ann
+	i.annMu.RUnlock()
+
+	// Documents changed since the build would be missing or outdated
+	if v == nil || v.generation != i.Generation() {
+		return nil, false
+	}
+	g, docCount := v.graph(useQwen)
+	if g == nil || g.dim != len(queryEmbedding) {
+		return nil, false
+	}
+
+	query := embeddings.NormalizeEmbedding(queryEmbedding)
+	if query == nil {
+		return nil, false
+	}
+
+	// Chunked documents can occupy several nodes, so over-fetch and keep
+	// each document's best chunk
+	k := opts.Offset + opts.Limit
+	fetch := k
+	if len(v.chunks) > 0 {
+		fetch = k * 4
+	}
+	hits := g.search(query, fetch, max(hnswEfSearch, fetch))
+
+	results := make([]*SearchResult, 0, opts.Limit)
+	seen := make(map[string]bool, k)
+	belowMin := false
+	for _, hit := range hits {
+		// Hits are sorted, so everything after the first weak one is weaker
+		if float64(hit.score) < opts.MinScore {
+			belowMin = true
+			break
+		}
+
+		docID, chunk := hit.id, ""
+		if ref, ok := v.chunks[hit.id]; ok {
+			docID, chunk = ref.docID, ref.content
+		}
+		if seen[docID] {
+			continue
+		}
+		seen[docID] = true
+		if len(seen) <= opts.Offset {
+			continue
+		}
+
+		doc := v.docs[docID]
+		result := &SearchResult{
+			ID:        doc.ID,
+			Title:     doc.Title,
+			Author:    doc.AuthorName,
+			SlabURL:   doc.SlabURL,
+			Topics:    doc.TopicNames(),
+			Score:     float64(hit.score),
+			Fragments: i.annSnippet(docID, chunk, queryText),
+		}
+		if opts.Explain {
+			result.Explanation = cosineExplanation(result.Score, chunk != "")
+		}
+		results = append(results, result)
+		if len(results) == opts.Limit {
+			break
+		}
+	}
+
+	// The graph doesn't score every document, so report the number of
+	// embedded documents as an approximate total, unless the score threshold
+	// cut the ranking short and every document above it was seen
+	total := uint64(docCount)
+	if belowMin {
+		total = uint64(len(seen))
+	}
+	return &SearchResults{Hits: results, TotalHits: total}, true
+}
@@ -4,10 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 // Client is a Slab API client
@@ -16,18 +21,100 @@ type Client struct {
 	baseURL    string
 	token      string
 	httpClient *http.Client
+	limiter    *rate.Limiter // Optional client-side rate limit (nil = unlimited)
+	maxRetries int           // Retries for transient failures
+
+	maxMarkdownSize int64 // Largest markdown export GetMarkdown will read
 }
 
+// DefaultMaxMarkdownSize guards against pathological posts exhausting memory
+// during a concurrent sync
+const DefaultMaxMarkdownSize = 50 << 20 // 50MB
+
+// DefaultBaseURL is the Slab workspace synced unless WithBaseURL says otherwise
+const DefaultBaseURL = "https://slab.render.com"
+
 // NewClient creates a new Slab API client
-func NewClient(token string) *Client {
-	return &Client{
-		graphqlURL: "https://slab.render.com/graphql",
-		baseURL:    "https://slab.render.com",
+// Transient failures are retried by default; see the Option functions
+func NewClient(token string, opts ...Option) *Client {
+	c := &Client{
+		graphqlURL: DefaultBaseURL + "/graphql",
+		baseURL:    DefaultBaseURL,
 		token:      token,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		maxRetries:      defaultMaxRetries,
+		maxMarkdownSize: DefaultMaxMarkdownSize,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ValidateBaseURL checks that raw is an absolute http(s) URL for a Slab
+// workspace (e.g. https://myteam.slab.com) and returns it without a trailing
+// slash, ready for WithBaseURL
+func ValidateBaseURL(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid Slab URL %q: %w", raw, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid Slab URL %q: want http(s)://host, e.g. %s", raw, DefaultBaseURL)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("invalid Slab URL %q: must not have a query or fragment", raw)
+	}
+	return strings.TrimRight(u.String(), "/"), nil
+}
+
+// PostURL returns the web URL of a post in the client's workspace
+func (c *Client) PostURL(postID string) string {
+	return fmt.Sprintf("%s/posts/%s", c.baseURL, postID)
+}
+
+// StatusError is returned when Slab responds with an unexpected HTTP status
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status: %s", e.Status) // Status includes the code
+}
+
+// GraphQLError is an entry in a GraphQL response's errors list
+type GraphQLError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"` // Field the error applies to, if any
+}
+
+// PartialError is returned along with the data of a GraphQL response that
+// carries errors but also usable data, e.g. when a single field failed to
+// resolve. Callers can log it as a warning and use the data, though the fields
+// named in the errors may be missing.
+type PartialError struct {
+	Errors []GraphQLError
+}
+
+func (e *PartialError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, gqlErr := range e.Errors {
+		msgs[i] = gqlErr.Message
+		if len(gqlErr.Path) > 0 {
+			msgs[i] = fmt.Sprintf("%s (at %v)", gqlErr.Message, gqlErr.Path)
+		}
 	}
+	return "partial graphql response: " + strings.Join(msgs, "; ")
+}
+
+// IsPartial reports whether err is or wraps a *PartialError, meaning the
+// accompanying result is usable
+func IsPartial(err error) bool {
+	var partial *PartialError
+	return errors.As(err, &partial)
 }
 
 // graphQLRequest represents a GraphQL request
@@ -39,12 +126,12 @@ type graphQLRequest struct {
 // graphQLResponse represents a GraphQL response
 type graphQLResponse struct {
 	Data   json.RawMessage `json:"data"`
-	Errors []struct {
-		Message string `json:"message"`
-	} `json:"errors,omitempty"`
+	Errors []GraphQLError  `json:"errors,omitempty"`
 }
 
 // doGraphQL performs a GraphQL request
+// A response with errors fails unless it also has data; then the data is
+// unmarshaled into result and the errors are returned as a *PartialError.
 func (c *Client) doGraphQL(ctx context.Context, query string, variables map[string]interface{}, result interface{}) error {
 	req := graphQLRequest{
 		Query:     query,
@@ -64,12 +151,16 @@ func (c *Client) doGraphQL(ctx context.Context, query string, variables map[stri
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+c.token)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := c.do(httpReq)
 	if err != nil {
 		return fmt.Errorf("do request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return fmt.Errorf("read response: %w", err)
@@ -80,16 +171,21 @@ func (c *Client) doGraphQL(ctx context.Context, query string, variables map[stri
 		return fmt.Errorf("unmarshal response: %w", err)
 	}
 
-	if len(gqlResp.Errors) > 0 {
+	hasData := len(gqlResp.Data) > 0 && string(gqlResp.Data) != "null"
+	if len(gqlResp.Errors) > 0 && !hasData {
 		return fmt.Errorf("graphql error: %s", gqlResp.Errors[0].Message)
 	}
 
-	if result != nil {
+	if result != nil && hasData {
 		if err := json.Unmarshal(gqlResp.Data, result); err != nil {
 			return fmt.Errorf("unmarshal data: %w", err)
 		}
 	}
 
+	if len(gqlResp.Errors) > 0 {
+		return &PartialError{Errors: gqlResp.Errors}
+	}
+
 	return nil
 }
 
@@ -116,14 +212,20 @@ func (c *Client) GetTopics(ctx context.Context) ([]Topic, error) {
 		} `json:"currentSession"`
 	}
 
-	if err := c.doGraphQL(ctx, query, nil, &result); err != nil {
+	err := c.doGraphQL(ctx, query, nil, &result)
+	if err != nil && !IsPartial(err) {
 		return nil, fmt.Errorf("get topics: %w", err)
 	}
+	if err != nil {
+		err = fmt.Errorf("get topics: %w", err)
+	}
 
-	return result.CurrentSession.Organization.Topics, nil
+	return result.CurrentSession.Organization.Topics, err
 }
 
 // GetAllSlimPosts fetches all posts via currentSession
+// If some fields fail to resolve, the posts are returned with a wrapped
+// *PartialError; the list may then be incomplete.
 func (c *Client) GetAllSlimPosts(ctx context.Context) ([]SlimPost, error) {
 	query := `
 	{
@@ -137,6 +239,7 @@ func (c *Client) GetAllSlimPosts(ctx context.Context) ([]SlimPost, error) {
 					archivedAt
 					topics {
 						id
+						name
 					}
 				}
 			}
@@ -152,19 +255,36 @@ func (c *Client) GetAllSlimPosts(ctx context.Context) ([]SlimPost, error) {
 		} `json:"currentSession"`
 	}
 
-	if err := c.doGraphQL(ctx, query, nil, &result); err != nil {
+	err := c.doGraphQL(ctx, query, nil, &result)
+	posts := result.CurrentSession.Organization.Posts
+	if err != nil && !IsPartial(err) {
 		return nil, fmt.Errorf("get all posts: %w", err)
 	}
+	if err != nil && len(posts) == 0 {
+		// Not usable even if only partly failed, so don't wrap a *PartialError
+		return nil, fmt.Errorf("get all posts: no posts in response: %v", err)
+	}
+	if err != nil {
+		err = fmt.Errorf("get all posts: %w", err)
+	}
 
-	return result.CurrentSession.Organization.Posts, nil
+	return posts, err
 }
 
-// GetTopicPosts fetches all posts for a given topic
+// topicPostsPageSize is the number of posts GetTopicPosts requests per page
+const topicPostsPageSize = 100
+
+// maxTopicPostPages bounds GetTopicPosts in case the server keeps reporting
+// more pages (100,000 posts at the default page size)
+const maxTopicPostPages = 1000
+
+// GetTopicPosts fetches all posts for a given topic, following pagination
+// cursors until the last page
 func (c *Client) GetTopicPosts(ctx context.Context, topicID string) ([]SlimPost, error) {
 	query := `
-	query GetTopicPosts($topicId: ID!, $first: Int) {
+	query GetTopicPosts($topicId: ID!, $first: Int, $after: String) {
 		topic(id: $topicId) {
-			posts(first: $first) {
+			posts(first: $first, after: $after) {
 				edges {
 					node {
 						id
@@ -178,36 +298,66 @@ func (c *Client) GetTopicPosts(ctx context.Context, topicID string) ([]SlimPost,
 						}
 					}
 				}
+				pageInfo {
+					hasNextPage
+					endCursor
+				}
 			}
 		}
 	}
 	`
 
-	var result struct {
-		Topic struct {
-			Posts struct {
-				Edges []struct {
-					Node SlimPost `json:"node"`
-				} `json:"edges"`
-			} `json:"posts"`
-		} `json:"topic"`
-	}
+	var posts []SlimPost
+	var partialErr error // Last partial response, returned with the posts
+	cursor := ""
+
+	for page := 0; page < maxTopicPostPages; page++ {
+		var result struct {
+			Topic struct {
+				Posts struct {
+					Edges []struct {
+						Node SlimPost `json:"node"`
+					} `json:"edges"`
+					PageInfo struct {
+						HasNextPage bool   `json:"hasNextPage"`
+						EndCursor   string `json:"endCursor"`
+					} `json:"pageInfo"`
+				} `json:"posts"`
+			} `json:"topic"`
+		}
 
-	variables := map[string]interface{}{
-		"topicId": topicID,
-		"first":   100, // Fetch up to 100 posts per topic
-	}
+		variables := map[string]interface{}{
+			"topicId": topicID,
+			"first":   topicPostsPageSize,
+		}
+		if cursor != "" {
+			variables["after"] = cursor
+		}
 
-	if err := c.doGraphQL(ctx, query, variables, &result); err != nil {
-		return nil, fmt.Errorf("get topic posts: %w", err)
-	}
+		err := c.doGraphQL(ctx, query, variables, &result)
+		if err != nil && !IsPartial(err) {
+			return nil, fmt.Errorf("get topic posts (page %d): %w", page+1, err)
+		}
+		if err != nil {
+			partialErr = fmt.Errorf("get topic posts (page %d): %w", page+1, err)
+		}
 
-	var posts []SlimPost
-	for _, edge := range result.Topic.Posts.Edges {
-		posts = append(posts, edge.Node)
+		for _, edge := range result.Topic.Posts.Edges {
+			posts = append(posts, edge.Node)
+		}
+
+		pageInfo := result.Topic.Posts.PageInfo
+		if !pageInfo.HasNextPage {
+			return posts, partialErr
+		}
+		// A missing or repeated cursor would refetch the same page forever
+		if pageInfo.EndCursor == "" || pageInfo.EndCursor == cursor {
+			return nil, fmt.Errorf("get topic posts: page %d has more posts but no new cursor", page+1)
+		}
+		cursor = pageInfo.EndCursor
 	}
 
-	return posts, nil
+	return nil, fmt.Errorf("get topic posts: topic %s has more than %d pages of posts", topicID, maxTopicPostPages)
 }
 
 // GetPost fetches full metadata for a single post
@@ -241,38 +391,169 @@ func (c *Client) GetPost(ctx context.Context, postID string) (*Post, error) {
 		"id": postID,
 	}
 
-	if err := c.doGraphQL(ctx, query, variables, &result); err != nil {
+	err := c.doGraphQL(ctx, query, variables, &result)
+	if err != nil && !IsPartial(err) {
 		return nil, fmt.Errorf("get post: %w", err)
 	}
+	if result.Post == nil {
+		// Not usable even if only partly failed, so don't wrap a *PartialError
+		if err != nil {
+			return nil, fmt.Errorf("get post: post %s missing from response: %v", postID, err)
+		}
+		return nil, fmt.Errorf("get post: post %s not found", postID)
+	}
+	if err != nil {
+		err = fmt.Errorf("get post: %w", err)
+	}
+
+	return result.Post, err
+}
+
+// GetComments fetches the comments on a post, oldest first
+func (c *Client) GetComments(ctx context.Context, postID string) ([]Comment, error) {
+	query := `
+	query GetComments($id: ID!) {
+		post(id: $id) {
+			comments {
+				id
+				content
+				insertedAt
+				author {
+					id
+					name
+				}
+			}
+		}
+	}
+	`
+
+	var result struct {
+		Post *struct {
+			Comments []struct {
+				ID         string          `json:"id"`
+				Content    json.RawMessage `json:"content"`
+				InsertedAt time.Time       `json:"insertedAt"`
+				Author     *User           `json:"author"`
+			} `json:"comments"`
+		} `json:"post"`
+	}
+
+	variables := map[string]interface{}{
+		"id": postID,
+	}
+
+	err := c.doGraphQL(ctx, query, variables, &result)
+	if err != nil && !IsPartial(err) {
+		return nil, fmt.Errorf("get comments: %w", err)
+	}
+	if result.Post == nil {
+		// Not usable even if only partly failed, so don't wrap a *PartialError
+		if err != nil {
+			return nil, fmt.Errorf("get comments: post %s missing from response: %v", postID, err)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		err = fmt.Errorf("get comments: %w", err)
+	}
+
+	comments := make([]Comment, 0, len(result.Post.Comments))
+	for _, raw := range result.Post.Comments {
+		comments = append(comments, Comment{
+			ID:         raw.ID,
+			Body:       commentText(raw.Content),
+			Author:     raw.Author,
+			InsertedAt: raw.InsertedAt,
+		})
+	}
+	return comments, err
+}
+
+// commentText extracts the plain text of comment content, which is either a
+// string or a rich-text (Quill) delta: {"ops": [{"insert": "text"}, ...]}
+// Embeds such as images and mentions are skipped.
+func commentText(content json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return strings.TrimSpace(text)
+	}
+
+	type op struct {
+		Insert json.RawMessage `json:"insert"`
+	}
+	var delta struct {
+		Ops []op `json:"ops"`
+	}
+	if err := json.Unmarshal(content, &delta); err != nil || delta.Ops == nil {
+		// Some deltas are a bare list of ops
+		if err := json.Unmarshal(content, &delta.Ops); err != nil {
+			return ""
+		}
+	}
 
-	return result.Post, nil
+	var sb strings.Builder
+	for _, o := range delta.Ops {
+		var insert string
+		if err := json.Unmarshal(o.Insert, &insert); err == nil {
+			sb.WriteString(insert)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// SizeError is returned when a markdown export exceeds the size limit
+type SizeError struct {
+	PostID string
+	Limit  int64
+}
+
+func (e *SizeError) Error() string {
+	return fmt.Sprintf("markdown export for post %s exceeds the %d byte limit", e.PostID, e.Limit)
 }
 
 // GetMarkdown fetches the markdown content for a post
+// Exports larger than the client's limit fail with a *SizeError
 func (c *Client) GetMarkdown(ctx context.Context, postID string) (string, error) {
+	body, err := c.GetMarkdownReader(ctx, postID)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	// Read one byte past the limit to tell "exactly at the limit" from "over"
+	var sb strings.Builder
+	n, err := io.Copy(&sb, io.LimitReader(body, c.maxMarkdownSize+1))
+	if err != nil {
+		return "", fmt.Errorf("read response: %w", err)
+	}
+	if n > c.maxMarkdownSize {
+		return "", &SizeError{PostID: postID, Limit: c.maxMarkdownSize}
+	}
+
+	return sb.String(), nil
+}
+
+// GetMarkdownReader streams the markdown export for a post
+// The caller must close the returned reader; no size limit is applied
+func (c *Client) GetMarkdownReader(ctx context.Context, postID string) (io.ReadCloser, error) {
 	url := fmt.Sprintf("%s/posts/%s/export/markdown", c.baseURL, postID)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return "", fmt.Errorf("create request: %w", err)
+		return nil, fmt.Errorf("create request: %w", err)
 	}
 
 	req.Header.Set("Authorization", "Bearer "+c.token)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
-		return "", fmt.Errorf("do request: %w", err)
+		return nil, fmt.Errorf("do request: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("unexpected status: %d %s", resp.StatusCode, resp.Status)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("read response: %w", err)
+		resp.Body.Close()
+		return nil, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
 	}
 
-	return string(body), nil
+	return resp.Body, nil
 }
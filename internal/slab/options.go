@@ -0,0 +1,43 @@
+package slab
+
+import (
+	"strings"
+
+	"golang.org/x/time/rate"
+)
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithRateLimit caps outgoing requests to rps per second with the given burst,
+// shared by all concurrent callers of the client
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) {
+		c.limiter = rate.NewLimiter(rate.Limit(rps), max(burst, 1))
+	}
+}
+
+// WithMaxRetries sets how many times a request is retried after a 429, a 5xx
+// gateway error or a network error (0 disables retries)
+func WithMaxRetries(n int) Option {
+	return func(c *Client) {
+		c.maxRetries = max(n, 0)
+	}
+}
+
+// WithMaxMarkdownSize sets the largest markdown export GetMarkdown will read
+func WithMaxMarkdownSize(bytes int64) Option {
+	return func(c *Client) {
+		c.maxMarkdownSize = bytes
+	}
+}
+
+// WithBaseURL points the client at a different Slab workspace or host (e.g., a
+// test server); see ValidateBaseURL for checking user input
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		baseURL = strings.TrimRight(baseURL, "/")
+		c.baseURL = baseURL
+		c.graphqlURL = baseURL + "/graphql"
+	}
+}
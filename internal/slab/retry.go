@@ -0,0 +1,101 @@
+package slab
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Retry tuning
+const (
+	defaultMaxRetries = 4
+	retryBaseDelay    = 500 * time.Millisecond
+	retryMaxDelay     = 30 * time.Second
+)
+
+// do sends an idempotent request, waiting for the rate limiter and retrying
+// transient failures with capped exponential backoff
+// 429 responses honor Retry-After. The request body must be replayable
+// (http.NewRequest sets GetBody for in-memory bodies).
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		if c.limiter != nil {
+			if err := c.limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("rewind request body: %w", err)
+			}
+			req.Body = body
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if attempt >= c.maxRetries || !shouldRetry(resp, err) || ctx.Err() != nil {
+			return resp, err
+		}
+
+		delay := backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = min(retryAfter, retryMaxDelay)
+			}
+			// Drain so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// shouldRetry reports whether a response or error is worth retrying
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		// Network errors are transient; timeouts of the whole request aren't retried
+		var netErr interface{ Timeout() bool }
+		return !(errors.As(err, &netErr) && netErr.Timeout())
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay before retry attempt+1, with jitter
+func backoff(attempt int) time.Duration {
+	delay := min(retryBaseDelay<<attempt, retryMaxDelay)
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// parseRetryAfter parses a Retry-After header (seconds or HTTP date)
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
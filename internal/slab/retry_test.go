@@ -0,0 +1,65 @@
+package slab
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+const topicsResponse = `{"data":{"currentSession":{"organization":{"topics":[{"id":"t1","name":"Runbooks"}]}}}}`
+
+func TestRateLimitedRequestIsRetried(t *testing.T) {
+	var requests atomic.Int32
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(topicsResponse))
+	}))
+	defer srv.Close()
+
+	client := NewClient("token", WithBaseURL(srv.URL))
+	topics, err := client.GetTopics(context.Background())
+	if err != nil {
+		t.Fatalf("GetTopics: %v", err)
+	}
+	if len(topics) != 1 || topics[0].Name != "Runbooks" {
+		t.Errorf("topics = %+v, want Runbooks", topics)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Fatalf("server got %d requests, want 2 (a 429, then a retry)", n)
+	}
+	if bodies[1] != bodies[0] || bodies[1] == "" {
+		t.Errorf("retried body %q doesn't match the original %q", bodies[1], bodies[0])
+	}
+}
+
+func TestRateLimitRetriesGiveUp(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Retry-After", "0")
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	client := NewClient("token", WithBaseURL(srv.URL), WithMaxRetries(2))
+	_, err := client.GetTopics(context.Background())
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("err = %v, want a 429 StatusError", err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("server got %d requests, want 3 (the first and 2 retries)", n)
+	}
+}
@@ -26,6 +26,14 @@ type User struct {
 	Email string `json:"email"`
 }
 
+// Comment is a comment on a post
+type Comment struct {
+	ID         string    `json:"id"`
+	Body       string    `json:"body"` // Plain text
+	Author     *User     `json:"author"`
+	InsertedAt time.Time `json:"insertedAt"`
+}
+
 // SlimPost is the lightweight post format from organization.topics.posts
 type SlimPost struct {
 	ID          string     `json:"id"`
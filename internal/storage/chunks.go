@@ -0,0 +1,78 @@
+package storage
+
+import "fmt"
+
+// Chunk is an embedded section of a document
+// Long documents are split into overlapping chunks so semantic search can
+// match sections deep in a document rather than one diluted vector
+type Chunk struct {
+	DocID     string
+	Qwen      bool // Embedded with the qwen model (mirrors Document.EmbeddingQwen)
+	Index     int  // Position within the document, starting at 0
+	Content   string
+	Embedding []byte // Serialized float32 vector
+}
+
+// ReplaceChunks atomically replaces a document's chunks for one embedding model
+func (d *DB) ReplaceChunks(docID string, qwen bool, chunks []*Chunk) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM chunks WHERE doc_id = ? AND qwen = ?", docID, qwen); err != nil {
+		return fmt.Errorf("delete chunks: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+	INSERT INTO chunks (doc_id, qwen, chunk_index, content, embedding)
+	VALUES (?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, c := range chunks {
+		if _, err := stmt.Exec(docID, qwen, c.Index, c.Content, c.Embedding); err != nil {
+			return fmt.Errorf("insert chunk %d: %w", c.Index, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeleteChunks removes all chunks for a document (e.g., when its content changes)
+func (d *DB) DeleteChunks(docID string) error {
+	_, err := d.db.Exec("DELETE FROM chunks WHERE doc_id = ?", docID)
+	return err
+}
+
+// ListChunks returns all chunks for one embedding model, grouped by document ID
+// and ordered by chunk index
+func (d *DB) ListChunks(qwen bool) (map[string][]*Chunk, error) {
+	query := `
+	SELECT doc_id, chunk_index, content, embedding
+	FROM chunks
+	WHERE qwen = ?
+	ORDER BY doc_id, chunk_index
+	`
+
+	rows, err := d.db.Query(query, qwen)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	chunks := make(map[string][]*Chunk)
+	for rows.Next() {
+		c := &Chunk{Qwen: qwen}
+		if err := rows.Scan(&c.DocID, &c.Index, &c.Content, &c.Embedding); err != nil {
+			return nil, err
+		}
+		chunks[c.DocID] = append(chunks[c.DocID], c)
+	}
+
+	return chunks, rows.Err()
+}
@@ -2,28 +2,33 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/renderinc/slab-search/internal/embeddings"
 )
 
 // DB wraps SQLite database operations
 type DB struct {
-	db *sql.DB
+	db  *sql.DB
+	fts bool // documents_fts is maintained (SQLite built with FTS5)
 }
 
 // Open opens or creates a SQLite database
 func Open(path string) (*DB, error) {
-	db, err := sql.Open("sqlite3", path)
+	// Foreign keys are a per-connection setting, so they're enabled in the
+	// DSN for every pooled connection; deletes rely on them to cascade
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=on")
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
 
-	// Enable foreign keys and WAL mode for better concurrency
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		return nil, fmt.Errorf("enable foreign keys: %w", err)
-	}
+	// WAL mode for better concurrency (persists in the database file)
 	if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
 		return nil, fmt.Errorf("enable WAL: %w", err)
 	}
@@ -66,69 +71,230 @@ func (d *DB) initSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_updated ON documents(updated_at);
 	CREATE INDEX IF NOT EXISTS idx_archived ON documents(archived_at);
 	CREATE INDEX IF NOT EXISTS idx_synced ON documents(synced_at);
+
+	CREATE TABLE IF NOT EXISTS sync_failures (
+		post_id TEXT PRIMARY KEY,
+		title TEXT,
+		status_code INTEGER,
+		error TEXT,
+		attempts INTEGER NOT NULL DEFAULT 1,
+		first_failed_at TIMESTAMP NOT NULL,
+		last_failed_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS chunks (
+		doc_id TEXT NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+		qwen INTEGER NOT NULL,
+		chunk_index INTEGER NOT NULL,
+		content TEXT NOT NULL,
+		embedding BLOB NOT NULL,
+		PRIMARY KEY (doc_id, qwen, chunk_index)
+	);
 	`
 
 	if _, err := d.db.Exec(schema); err != nil {
 		return err
 	}
+	if _, err := d.db.Exec(metaSchema); err != nil {
+		return fmt.Errorf("create meta table: %w", err)
+	}
 
 	// Run migrations
-	return d.runMigrations()
+	if err := d.runMigrations(); err != nil {
+		return err
+	}
+
+	// Optional full-text fallback for when the Bleve index is unusable
+	return d.initFTS()
 }
 
 // runMigrations handles schema migrations for existing databases
 func (d *DB) runMigrations() error {
 	// Migration 1: Add embedding column (Phase 2 - Semantic Search)
-	// Check if embedding column exists
+	if err := d.addColumnIfMissing("embedding", "BLOB"); err != nil {
+		return err
+	}
+
+	// Migration 2: Add embedding_qwen column (for model comparison)
+	if err := d.addColumnIfMissing("embedding_qwen", "BLOB"); err != nil {
+		return err
+	}
+
+	// Migration 3: Add embedded_at column (when an embedding was last generated)
+	if err := d.addColumnIfMissing("embedded_at", "TIMESTAMP"); err != nil {
+		return err
+	}
+
+	// Migration 4: Record which model produced each embedding
+	if err := d.addColumnIfMissing("embedding_model", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := d.addColumnIfMissing("embedding_qwen_model", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+
+	// Migration 5: Post comments (sync -with-comments)
+	if err := d.addColumnIfMissing("comments", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+
+	// Migration 6: Topic and document_topics tables, backfilled from the topics JSON
+	if err := d.migrateTopics(); err != nil {
+		return err
+	}
+
+	// Migration 7: Content length in characters, backfilled for existing rows
+	if err := d.addColumnIfMissing("content_length", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if _, err := d.db.Exec("UPDATE documents SET content_length = length(content) WHERE content_length = 0 AND content != ''"); err != nil {
+		return fmt.Errorf("backfill content_length: %w", err)
+	}
+
+	// Migration 8: Duplicate marks (dedupe -mark)
+	if err := d.addColumnIfMissing("duplicate_of", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+
+	// Migration 9: Drop chunks and topic links left behind by deletes made
+	// while foreign keys were only enabled on one pooled connection
+	for _, table := range []string{"chunks", "document_topics"} {
+		if _, err := d.db.Exec("DELETE FROM " + table + " WHERE doc_id NOT IN (SELECT id FROM documents)"); err != nil {
+			return fmt.Errorf("drop orphaned %s: %w", table, err)
+		}
+	}
+
+	// Migration 10: Normalize embeddings stored before they were normalized at
+	// ingest, so search can score every vector by dot product
+	if err := d.normalizeStoredEmbeddings(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// addColumnIfMissing adds a column to the documents table if it doesn't exist yet
+func (d *DB) addColumnIfMissing(name, columnType string) error {
 	var columnExists bool
 	err := d.db.QueryRow(`
 		SELECT COUNT(*) > 0
 		FROM pragma_table_info('documents')
-		WHERE name='embedding'
-	`).Scan(&columnExists)
+		WHERE name = ?
+	`, name).Scan(&columnExists)
 
 	if err != nil {
-		return fmt.Errorf("check embedding column: %w", err)
+		return fmt.Errorf("check %s column: %w", name, err)
 	}
 
 	if !columnExists {
-		// Add embedding column
-		_, err = d.db.Exec("ALTER TABLE documents ADD COLUMN embedding BLOB")
+		_, err = d.db.Exec(fmt.Sprintf("ALTER TABLE documents ADD COLUMN %s %s", name, columnType))
 		if err != nil {
-			return fmt.Errorf("add embedding column: %w", err)
+			return fmt.Errorf("add %s column: %w", name, err)
 		}
 	}
 
-	// Migration 2: Add embedding_qwen column (for model comparison)
-	var qwenColumnExists bool
-	err = d.db.QueryRow(`
-		SELECT COUNT(*) > 0
-		FROM pragma_table_info('documents')
-		WHERE name='embedding_qwen'
-	`).Scan(&qwenColumnExists)
+	return nil
+}
+
+// normalizeStoredEmbeddings rewrites document and chunk embeddings that
+// aren't unit length, once per database (recorded in the meta table)
+func (d *DB) normalizeStoredEmbeddings() error {
+	done, err := d.getMeta(metaEmbeddingsNormalized)
+	if err != nil || done != "" {
+		return err
+	}
 
+	tx, err := d.db.Begin()
 	if err != nil {
-		return fmt.Errorf("check embedding_qwen column: %w", err)
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, blobs := range []struct{ table, column string }{
+		{"documents", "embedding"},
+		{"documents", "embedding_qwen"},
+		{"chunks", "embedding"},
+	} {
+		if err := normalizeBlobs(tx, blobs.table, blobs.column); err != nil {
+			return fmt.Errorf("normalize %s.%s: %w", blobs.table, blobs.column, err)
+		}
 	}
 
-	if !qwenColumnExists {
-		// Add embedding_qwen column
-		_, err = d.db.Exec("ALTER TABLE documents ADD COLUMN embedding_qwen BLOB")
-		if err != nil {
-			return fmt.Errorf("add embedding_qwen column: %w", err)
+	if _, err := tx.Exec("INSERT INTO meta (key, value) VALUES (?, ?)", metaEmbeddingsNormalized, "1"); err != nil {
+		return fmt.Errorf("record normalized embeddings: %w", err)
+	}
+	return tx.Commit()
+}
+
+// normalizeBlobs rewrites the serialized vectors in table.column that aren't
+// unit length; empty and all-zero vectors are left alone
+func normalizeBlobs(tx *sql.Tx, table, column string) error {
+	rows, err := tx.Query("SELECT rowid, " + column + " FROM " + table + " WHERE length(" + column + ") > 0")
+	if err != nil {
+		return err
+	}
+
+	// Collected first: the transaction's connection can't update while
+	// the query is still reading
+	updates := make(map[int64][]byte)
+	for rows.Next() {
+		var rowid int64
+		var blob []byte
+		if err := rows.Scan(&rowid, &blob); err != nil {
+			rows.Close()
+			return err
+		}
+		vec := embeddings.DeserializeEmbedding(blob)
+		unit := embeddings.NormalizeEmbedding(vec)
+		if unit != nil && &unit[0] != &vec[0] { // NormalizeEmbedding returns unit vectors as is
+			updates[rowid] = embeddings.SerializeEmbedding(unit)
 		}
 	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
 
+	for rowid, blob := range updates {
+		if _, err := tx.Exec("UPDATE "+table+" SET "+column+" = ? WHERE rowid = ?", blob, rowid); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// Upsert inserts or updates a document
+// documentColumns lists the document columns in the order scanDocument expects
+const documentColumns = `id, title, content, author_name, author_email,
+	       slab_url, topics, published_at, updated_at, archived_at, synced_at,
+	       embedding, embedding_qwen, embedded_at, embedding_model, embedding_qwen_model, comments,
+	       content_length, duplicate_of`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanDocument scans a row selected with documentColumns
+func scanDocument(row rowScanner) (*Document, error) {
+	doc := &Document{}
+	err := row.Scan(
+		&doc.ID, &doc.Title, &doc.Content, &doc.AuthorName, &doc.AuthorEmail,
+		&doc.SlabURL, &doc.Topics, &doc.PublishedAt, &doc.UpdatedAt, &doc.ArchivedAt, &doc.SyncedAt,
+		&doc.Embedding, &doc.EmbeddingQwen, &doc.EmbeddedAt, &doc.EmbeddingModel, &doc.EmbeddingQwenModel, &doc.Comments,
+		&doc.ContentLength, &doc.DuplicateOf,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Upsert inserts or updates a document and relinks its topics
+// An existing document keeps its duplicate mark (see MarkDuplicates).
 func (d *DB) Upsert(doc *Document) error {
 	query := `
-	INSERT INTO documents (
-		id, title, content, author_name, author_email,
-		slab_url, topics, published_at, updated_at, archived_at, synced_at, embedding, embedding_qwen
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	INSERT INTO documents (` + documentColumns + `)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	ON CONFLICT(id) DO UPDATE SET
 		title = excluded.title,
 		content = excluded.content,
@@ -141,33 +307,46 @@ func (d *DB) Upsert(doc *Document) error {
 		archived_at = excluded.archived_at,
 		synced_at = excluded.synced_at,
 		embedding = excluded.embedding,
-		embedding_qwen = excluded.embedding_qwen
+		embedding_qwen = excluded.embedding_qwen,
+		embedded_at = excluded.embedded_at,
+		embedding_model = excluded.embedding_model,
+		embedding_qwen_model = excluded.embedding_qwen_model,
+		comments = excluded.comments,
+		content_length = excluded.content_length
 	`
 
-	_, err := d.db.Exec(query,
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(query,
 		doc.ID, doc.Title, doc.Content, doc.AuthorName, doc.AuthorEmail,
-		doc.SlabURL, doc.Topics, doc.PublishedAt, doc.UpdatedAt, doc.ArchivedAt, doc.SyncedAt, doc.Embedding, doc.EmbeddingQwen,
+		doc.SlabURL, doc.Topics, doc.PublishedAt, doc.UpdatedAt, doc.ArchivedAt, doc.SyncedAt,
+		doc.Embedding, doc.EmbeddingQwen, doc.EmbeddedAt, doc.EmbeddingModel, doc.EmbeddingQwenModel, doc.Comments,
+		doc.ContentLength, doc.DuplicateOf,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if err := replaceTopics(tx, doc.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
+// ErrNotFound is returned by Get when no document has the ID
+var ErrNotFound = errors.New("document not found")
+
 // Get retrieves a document by ID
+// Returns an error wrapping ErrNotFound if there's no such document.
 func (d *DB) Get(id string) (*Document, error) {
-	doc := &Document{}
-	query := `
-	SELECT id, title, content, author_name, author_email,
-	       slab_url, topics, published_at, updated_at, archived_at, synced_at, embedding, embedding_qwen
-	FROM documents
-	WHERE id = ?
-	`
-
-	err := d.db.QueryRow(query, id).Scan(
-		&doc.ID, &doc.Title, &doc.Content, &doc.AuthorName, &doc.AuthorEmail,
-		&doc.SlabURL, &doc.Topics, &doc.PublishedAt, &doc.UpdatedAt, &doc.ArchivedAt, &doc.SyncedAt, &doc.Embedding, &doc.EmbeddingQwen,
-	)
+	query := `SELECT ` + documentColumns + ` FROM documents WHERE id = ?`
 
+	doc, err := scanDocument(d.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
-		return nil, nil
+		return nil, fmt.Errorf("%s: %w", id, ErrNotFound)
 	}
 	if err != nil {
 		return nil, err
@@ -176,13 +355,82 @@ func (d *DB) Get(id string) (*Document, error) {
 	return doc, nil
 }
 
+// getManyBatch is how many IDs GetMany binds per query, within SQLite's
+// limit on query parameters
+const getManyBatch = 500
+
+// GetMany retrieves the documents with the given IDs, keyed by ID
+// IDs with no document are left out of the map.
+func (d *DB) GetMany(ids []string) (map[string]*Document, error) {
+	docs := make(map[string]*Document, len(ids))
+	for start := 0; start < len(ids); start += getManyBatch {
+		batch := ids[start:min(start+getManyBatch, len(ids))]
+
+		args := make([]any, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+		query := `SELECT ` + documentColumns + ` FROM documents WHERE id IN (?` +
+			strings.Repeat(", ?", len(batch)-1) + `)`
+
+		rows, err := d.db.Query(query, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			doc, err := scanDocument(rows)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			docs[doc.ID] = doc
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return docs, nil
+}
+
 // List retrieves all documents (non-archived by default)
 func (d *DB) List(includeArchived bool) ([]*Document, error) {
-	query := `
-	SELECT id, title, content, author_name, author_email,
-	       slab_url, topics, published_at, updated_at, archived_at, synced_at, embedding, embedding_qwen
-	FROM documents
-	`
+	query := `SELECT ` + documentColumns + ` FROM documents`
+	if !includeArchived {
+		query += " WHERE archived_at IS NULL"
+	}
+	query += " ORDER BY updated_at DESC"
+
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []*Document
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, rows.Err()
+}
+
+// documentColumnsWithoutEmbeddings selects documentColumns with NULL for
+// the embedding blobs, which scan as nil
+var documentColumnsWithoutEmbeddings = strings.Replace(documentColumns,
+	"embedding, embedding_qwen,", "NULL, NULL,", 1)
+
+// ListWithoutEmbeddings is List without the embedding blobs (Embedding and
+// EmbeddingQwen are nil), for semantic search, which caches decoded vectors
+// and fetches only new ones with GetEmbeddings
+func (d *DB) ListWithoutEmbeddings(includeArchived bool) ([]*Document, error) {
+	query := `SELECT ` + documentColumnsWithoutEmbeddings + ` FROM documents`
 	if !includeArchived {
 		query += " WHERE archived_at IS NULL"
 	}
@@ -196,11 +444,162 @@ func (d *DB) List(includeArchived bool) ([]*Document, error) {
 
 	var docs []*Document
 	for rows.Next() {
-		doc := &Document{}
-		err := rows.Scan(
-			&doc.ID, &doc.Title, &doc.Content, &doc.AuthorName, &doc.AuthorEmail,
-			&doc.SlabURL, &doc.Topics, &doc.PublishedAt, &doc.UpdatedAt, &doc.ArchivedAt, &doc.SyncedAt, &doc.Embedding, &doc.EmbeddingQwen,
-		)
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, rows.Err()
+}
+
+// GetEmbeddings returns the serialized embedding in one field (embedding_qwen
+// if qwen) of each given document, keyed by ID
+// Documents without one in that field are left out of the map.
+func (d *DB) GetEmbeddings(ids []string, qwen bool) (map[string][]byte, error) {
+	column := "embedding"
+	if qwen {
+		column = "embedding_qwen"
+	}
+
+	blobs := make(map[string][]byte, len(ids))
+	for start := 0; start < len(ids); start += getManyBatch {
+		batch := ids[start:min(start+getManyBatch, len(ids))]
+
+		args := make([]any, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+		query := `SELECT id, ` + column + ` FROM documents WHERE length(` + column + `) > 0 AND id IN (?` +
+			strings.Repeat(", ?", len(batch)-1) + `)`
+
+		rows, err := d.db.Query(query, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var id string
+			var blob []byte
+			if err := rows.Scan(&id, &blob); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			blobs[id] = blob
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return blobs, nil
+}
+
+// Delete removes a document from the database, along with its chunks and
+// topic links (by foreign key cascade)
+// Deleting a document that doesn't exist is not an error
+func (d *DB) Delete(id string) error {
+	_, err := d.db.Exec("DELETE FROM documents WHERE id = ?", id)
+	return err
+}
+
+// ListIDs returns the IDs of all documents (non-archived unless includeArchived)
+func (d *DB) ListIDs(includeArchived bool) ([]string, error) {
+	query := "SELECT id FROM documents"
+	if !includeArchived {
+		query += " WHERE archived_at IS NULL"
+	}
+
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// ListRecent retrieves the limit most recently synced non-archived documents,
+// newest first (most recently updated first among those synced together)
+func (d *DB) ListRecent(limit int) ([]*Document, error) {
+	query := `SELECT ` + documentColumns + ` FROM documents
+	WHERE archived_at IS NULL
+	ORDER BY julianday(synced_at) DESC, julianday(updated_at) DESC
+	LIMIT ?`
+
+	rows, err := d.db.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []*Document
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, rows.Err()
+}
+
+// ListPage retrieves a page of non-archived documents, most recently
+// updated first, for browsing the corpus without a query
+func (d *DB) ListPage(limit, offset int) ([]*Document, error) {
+	query := `SELECT ` + documentColumns + ` FROM documents
+	WHERE archived_at IS NULL
+	ORDER BY julianday(updated_at) DESC, id
+	LIMIT ? OFFSET ?`
+
+	rows, err := d.db.Query(query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []*Document
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, rows.Err()
+}
+
+// ListChangedSince retrieves non-archived documents updated in Slab or synced
+// after since. Newly synced documents can carry an older updated_at, so
+// synced_at is checked too.
+func (d *DB) ListChangedSince(since time.Time) ([]*Document, error) {
+	// julianday compares instants; the stored strings may differ in zone offset
+	query := `SELECT ` + documentColumns + ` FROM documents
+	WHERE archived_at IS NULL
+	  AND (julianday(updated_at) > julianday(?) OR julianday(synced_at) > julianday(?))
+	ORDER BY updated_at DESC`
+
+	rows, err := d.db.Query(query, since, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []*Document
+	for rows.Next() {
+		doc, err := scanDocument(rows)
 		if err != nil {
 			return nil, err
 		}
@@ -217,6 +616,104 @@ func (d *DB) Count() (int, error) {
 	return count, err
 }
 
+// EmbeddingStat counts documents sharing an embedding field, model and dimension
+type EmbeddingStat struct {
+	Field      string // "embedding" or "embedding_qwen"
+	Model      string // "" if not recorded
+	Dimensions int
+	Count      int
+}
+
+// EmbeddingStats reports the distribution of embedding models and dimensions
+// across non-archived documents, to diagnose corpora embedded with mixed models
+func (d *DB) EmbeddingStats() ([]*EmbeddingStat, error) {
+	query := `
+	SELECT 'embedding', embedding_model, length(embedding) / 4, COUNT(*)
+	FROM documents
+	WHERE archived_at IS NULL AND length(embedding) > 0
+	GROUP BY 2, 3
+	UNION ALL
+	SELECT 'embedding_qwen', embedding_qwen_model, length(embedding_qwen) / 4, COUNT(*)
+	FROM documents
+	WHERE archived_at IS NULL AND length(embedding_qwen) > 0
+	GROUP BY 2, 3
+	ORDER BY 1, 4 DESC
+	`
+
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []*EmbeddingStat
+	for rows.Next() {
+		st := &EmbeddingStat{}
+		if err := rows.Scan(&st.Field, &st.Model, &st.Dimensions, &st.Count); err != nil {
+			return nil, err
+		}
+		stats = append(stats, st)
+	}
+
+	return stats, rows.Err()
+}
+
+// ContentStats summarizes the content length of non-archived documents
+type ContentStats struct {
+	Documents   int
+	TotalLength int    // Characters across all documents
+	MaxLength   int    // Characters in the longest document
+	Longest     string // Title of the longest document
+	Over        int    // Documents longer than the threshold given to ContentStats
+}
+
+// ContentStats reports content length totals across non-archived documents,
+// counting those longer than threshold characters
+func (d *DB) ContentStats(threshold int) (*ContentStats, error) {
+	st := &ContentStats{}
+	err := d.db.QueryRow(`
+		SELECT COUNT(*), COALESCE(SUM(content_length), 0), COALESCE(MAX(content_length), 0),
+		       COUNT(CASE WHEN content_length > ? THEN 1 END)
+		FROM documents
+		WHERE archived_at IS NULL
+	`, threshold).Scan(&st.Documents, &st.TotalLength, &st.MaxLength, &st.Over)
+	if err != nil {
+		return nil, err
+	}
+	if st.Documents == 0 {
+		return st, nil
+	}
+
+	err = d.db.QueryRow(`
+		SELECT title FROM documents
+		WHERE archived_at IS NULL
+		ORDER BY content_length DESC, id
+		LIMIT 1
+	`).Scan(&st.Longest)
+	if err != nil {
+		return nil, fmt.Errorf("find longest document: %w", err)
+	}
+	return st, nil
+}
+
+// GetComments returns a document's stored comments ("" if none or the
+// document doesn't exist)
+func (d *DB) GetComments(id string) (string, error) {
+	var comments string
+	err := d.db.QueryRow("SELECT comments FROM documents WHERE id = ?", id).Scan(&comments)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return comments, err
+}
+
+// SetComments replaces a document's comments and marks it synced now, so
+// incremental reindexing picks up the change
+func (d *DB) SetComments(id, comments string) error {
+	_, err := d.db.Exec("UPDATE documents SET comments = ?, synced_at = ? WHERE id = ?", comments, time.Now(), id)
+	return err
+}
+
 // GetUpdatedAt retrieves just the updated_at timestamp for a document
 // Returns zero time if document doesn't exist
 func (d *DB) GetUpdatedAt(id string) (time.Time, error) {
@@ -227,3 +724,23 @@ func (d *DB) GetUpdatedAt(id string) (time.Time, error) {
 	}
 	return updatedAt, err
 }
+
+// ErrBackupExists is returned by BackupTo when the destination file exists
+var ErrBackupExists = errors.New("backup destination already exists")
+
+// BackupTo writes a consistent snapshot of the database to a new file at path
+// with VACUUM INTO, which is safe while other connections (a running server
+// or sync) are writing, unlike copying the file and its WAL. The snapshot is
+// compacted and has no WAL to go with it. Fails if path already exists.
+func (d *DB) BackupTo(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s: %w", path, ErrBackupExists)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("check backup destination: %w", err)
+	}
+
+	if _, err := d.db.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("backup database: %w", err)
+	}
+	return nil
+}
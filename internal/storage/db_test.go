@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/renderinc/slab-search/internal/embeddings"
+)
+
+func TestOpenNormalizesOldEmbeddings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slab.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Embeddings as stored before they were normalized at ingest
+	doc := testDoc("a", 1)
+	doc.Embedding = embeddings.SerializeEmbedding([]float32{3, 4})
+	doc.EmbeddingQwen = embeddings.SerializeEmbedding([]float32{0, 0})
+	upsert(t, db, doc)
+	old := []*Chunk{{Index: 0, Content: "a", Embedding: embeddings.SerializeEmbedding([]float32{0, 2})}}
+	if err := db.ReplaceChunks("a", false, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.db.Exec("DELETE FROM meta WHERE key = ?", metaEmbeddingsNormalized); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	db, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	got, err := db.Get("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkVector(t, "embedding", got.Embedding, []float32{0.6, 0.8})
+	checkVector(t, "all-zero qwen embedding", got.EmbeddingQwen, []float32{0, 0})
+
+	chunks, err := db.ListChunks(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkVector(t, "chunk", chunks["a"][0].Embedding, []float32{0, 1})
+}
+
+// checkVector fails unless blob decodes to want, up to float32 rounding
+func checkVector(t *testing.T, name string, blob []byte, want []float32) {
+	t.Helper()
+	got := embeddings.DeserializeEmbedding(blob)
+	if len(got) != len(want) {
+		t.Fatalf("%s = %v, want %v", name, got, want)
+	}
+	for i := range got {
+		if math.Abs(float64(got[i]-want[i])) > 1e-6 {
+			t.Errorf("%s = %v, want %v", name, got, want)
+			return
+		}
+	}
+}
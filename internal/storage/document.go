@@ -1,6 +1,9 @@
 package storage
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Document represents a document in our search index
 type Document struct {
@@ -10,11 +13,58 @@ type Document struct {
 	AuthorName    string     `db:"author_name"`
 	AuthorEmail   string     `db:"author_email"`
 	SlabURL       string     `db:"slab_url"`
-	Topics        string     `db:"topics"` // JSON array
+	Topics        string     `db:"topics"` // JSON array, deprecated: also in topics/document_topics
 	PublishedAt   time.Time  `db:"published_at"`
 	UpdatedAt     time.Time  `db:"updated_at"`
 	ArchivedAt    *time.Time `db:"archived_at"` // NULL if not archived
 	SyncedAt      time.Time  `db:"synced_at"`   // When we synced
 	Embedding     []byte     `db:"embedding"`   // Vector embedding (BLOB) - nomic-embed-text
 	EmbeddingQwen []byte     `db:"embedding_qwen"` // Qwen3 embedding for comparison
+	EmbeddedAt    *time.Time `db:"embedded_at"`    // When an embedding was last generated (NULL if never)
+
+	// Comments holds the post's comments as "Author: text" paragraphs, when
+	// synced with comments ("" otherwise)
+	Comments string `db:"comments"`
+
+	// Models that produced each embedding ("" if unknown, e.g. embedded
+	// before models were recorded); dimensions are len(blob)/4
+	EmbeddingModel     string `db:"embedding_model"`
+	EmbeddingQwenModel string `db:"embedding_qwen_model"`
+
+	// ContentLength is len(Content) in characters, set when the document is
+	// synced; see EstimateTokens
+	ContentLength int `db:"content_length"`
+
+	// DuplicateOf is the ID of the document this one near-duplicates, as
+	// marked by dedupe -mark ("" if it's canonical or unmarked)
+	DuplicateOf string `db:"duplicate_of"`
+}
+
+// EstimateTokens roughly converts a length in characters to embedding model
+// tokens, at about four characters per token for English text
+func EstimateTokens(chars int) int {
+	return (chars + 3) / 4
+}
+
+// TopicNames decodes the topics JSON into a list of topic names
+// Topics without a name (synced before names were fetched) are skipped
+func (d *Document) TopicNames() []string {
+	if d.Topics == "" {
+		return nil
+	}
+
+	var topics []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(d.Topics), &topics); err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, t := range topics {
+		if t.Name != "" {
+			names = append(names, t.Name)
+		}
+	}
+	return names
 }
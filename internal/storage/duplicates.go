@@ -0,0 +1,61 @@
+package storage
+
+import "fmt"
+
+// ListDuplicates returns the non-archived documents marked as duplicates,
+// mapped to their canonical document's ID
+// Marks pointing at a canonical document that has since been archived or
+// deleted are left out, so hiding duplicates never hides every copy.
+func (d *DB) ListDuplicates() (map[string]string, error) {
+	query := `
+	SELECT dup.id, dup.duplicate_of
+	FROM documents dup
+	JOIN documents canon ON canon.id = dup.duplicate_of
+	WHERE dup.duplicate_of != '' AND dup.archived_at IS NULL AND canon.archived_at IS NULL
+	`
+
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dups := make(map[string]string)
+	for rows.Next() {
+		var id, canonical string
+		if err := rows.Scan(&id, &canonical); err != nil {
+			return nil, err
+		}
+		dups[id] = canonical
+	}
+
+	return dups, rows.Err()
+}
+
+// MarkDuplicates replaces every duplicate mark with dups, which maps each
+// duplicate's ID to its canonical document's ID (nil clears all marks)
+func (d *DB) MarkDuplicates(dups map[string]string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE documents SET duplicate_of = '' WHERE duplicate_of != ''"); err != nil {
+		return fmt.Errorf("clear duplicate marks: %w", err)
+	}
+
+	stmt, err := tx.Prepare("UPDATE documents SET duplicate_of = ? WHERE id = ?")
+	if err != nil {
+		return fmt.Errorf("prepare update: %w", err)
+	}
+	defer stmt.Close()
+
+	for id, canonical := range dups {
+		if _, err := stmt.Exec(canonical, id); err != nil {
+			return fmt.Errorf("mark %s: %w", id, err)
+		}
+	}
+
+	return tx.Commit()
+}
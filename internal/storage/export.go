@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+	"unicode/utf8"
+)
+
+// ExportedDocument is a document as written by the export command and read
+// back by import, one JSON object per line (JSONL). Embeddings are base64
+// encoded and only included when requested.
+type ExportedDocument struct {
+	ID          string          `json:"id"`
+	Title       string          `json:"title"`
+	Content     string          `json:"content"`
+	AuthorName  string          `json:"author_name,omitempty"`
+	AuthorEmail string          `json:"author_email,omitempty"`
+	SlabURL     string          `json:"slab_url"`
+	Topics      json.RawMessage `json:"topics,omitempty"` // [{"id": ..., "name": ...}]
+	Comments    string          `json:"comments,omitempty"`
+	PublishedAt time.Time       `json:"published_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	ArchivedAt  *time.Time      `json:"archived_at,omitempty"`
+	SyncedAt    time.Time       `json:"synced_at"`
+
+	Embedding          []byte     `json:"embedding,omitempty"`
+	EmbeddingModel     string     `json:"embedding_model,omitempty"`
+	EmbeddingQwen      []byte     `json:"embedding_qwen,omitempty"`
+	EmbeddingQwenModel string     `json:"embedding_qwen_model,omitempty"`
+	EmbeddedAt         *time.Time `json:"embedded_at,omitempty"`
+}
+
+// NewExportedDocument converts a stored document for export, leaving out its
+// embeddings unless withEmbeddings is set
+func NewExportedDocument(doc *Document, withEmbeddings bool) *ExportedDocument {
+	exported := &ExportedDocument{
+		ID:          doc.ID,
+		Title:       doc.Title,
+		Content:     doc.Content,
+		AuthorName:  doc.AuthorName,
+		AuthorEmail: doc.AuthorEmail,
+		SlabURL:     doc.SlabURL,
+		Comments:    doc.Comments,
+		PublishedAt: doc.PublishedAt,
+		UpdatedAt:   doc.UpdatedAt,
+		ArchivedAt:  doc.ArchivedAt,
+		SyncedAt:    doc.SyncedAt,
+	}
+	// Topics are stored as JSON; embed them as-is rather than as a string
+	if json.Valid([]byte(doc.Topics)) {
+		exported.Topics = json.RawMessage(doc.Topics)
+	}
+
+	if withEmbeddings {
+		exported.Embedding = doc.Embedding
+		exported.EmbeddingModel = doc.EmbeddingModel
+		exported.EmbeddingQwen = doc.EmbeddingQwen
+		exported.EmbeddingQwenModel = doc.EmbeddingQwenModel
+		exported.EmbeddedAt = doc.EmbeddedAt
+	}
+	return exported
+}
+
+// Document converts an imported record back into a document for Upsert
+func (e *ExportedDocument) Document() (*Document, error) {
+	if e.ID == "" {
+		return nil, errors.New("missing id")
+	}
+	if e.Title == "" {
+		return nil, errors.New("missing title")
+	}
+	if len(e.Embedding)%4 != 0 || len(e.EmbeddingQwen)%4 != 0 {
+		return nil, errors.New("embedding is not a list of float32s")
+	}
+
+	return &Document{
+		ID:                 e.ID,
+		Title:              e.Title,
+		Content:            e.Content,
+		ContentLength:      utf8.RuneCountInString(e.Content),
+		AuthorName:         e.AuthorName,
+		AuthorEmail:        e.AuthorEmail,
+		SlabURL:            e.SlabURL,
+		Topics:             string(e.Topics),
+		Comments:           e.Comments,
+		PublishedAt:        e.PublishedAt,
+		UpdatedAt:          e.UpdatedAt,
+		ArchivedAt:         e.ArchivedAt,
+		SyncedAt:           e.SyncedAt,
+		Embedding:          e.Embedding,
+		EmbeddingModel:     e.EmbeddingModel,
+		EmbeddingQwen:      e.EmbeddingQwen,
+		EmbeddingQwenModel: e.EmbeddingQwenModel,
+		EmbeddedAt:         e.EmbeddedAt,
+	}, nil
+}
+
+// Each calls fn with every document (non-archived unless includeArchived) in
+// ID order, reading them one at a time rather than loading them all like
+// List. Iteration stops at the first error from fn, which is returned.
+func (d *DB) Each(includeArchived bool, fn func(*Document) error) error {
+	query := `SELECT ` + documentColumns + ` FROM documents`
+	if !includeArchived {
+		query += " WHERE archived_at IS NULL"
+	}
+	query += " ORDER BY id"
+
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
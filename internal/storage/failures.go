@@ -0,0 +1,66 @@
+package storage
+
+import "time"
+
+// SyncFailure records a post that could not be exported from Slab
+type SyncFailure struct {
+	PostID        string
+	Title         string
+	StatusCode    int // HTTP status from Slab (0 if no response was received)
+	Error         string
+	Attempts      int // Number of consecutive syncs that failed
+	FirstFailedAt time.Time
+	LastFailedAt  time.Time
+}
+
+// RecordSyncFailure inserts a failure or bumps the attempt count of an existing one
+func (d *DB) RecordSyncFailure(f *SyncFailure) error {
+	query := `
+	INSERT INTO sync_failures (
+		post_id, title, status_code, error, attempts, first_failed_at, last_failed_at
+	) VALUES (?, ?, ?, ?, 1, ?, ?)
+	ON CONFLICT(post_id) DO UPDATE SET
+		title = excluded.title,
+		status_code = excluded.status_code,
+		error = excluded.error,
+		attempts = sync_failures.attempts + 1,
+		last_failed_at = excluded.last_failed_at
+	`
+
+	now := time.Now()
+	_, err := d.db.Exec(query, f.PostID, f.Title, f.StatusCode, f.Error, now, now)
+	return err
+}
+
+// ClearSyncFailure removes the failure record for a post (e.g., after a successful sync)
+func (d *DB) ClearSyncFailure(postID string) error {
+	_, err := d.db.Exec("DELETE FROM sync_failures WHERE post_id = ?", postID)
+	return err
+}
+
+// ListSyncFailures returns all recorded failures, most persistent first
+func (d *DB) ListSyncFailures() ([]*SyncFailure, error) {
+	query := `
+	SELECT post_id, title, status_code, error, attempts, first_failed_at, last_failed_at
+	FROM sync_failures
+	ORDER BY attempts DESC, last_failed_at DESC
+	`
+
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var failures []*SyncFailure
+	for rows.Next() {
+		f := &SyncFailure{}
+		err := rows.Scan(&f.PostID, &f.Title, &f.StatusCode, &f.Error, &f.Attempts, &f.FirstFailedAt, &f.LastFailedAt)
+		if err != nil {
+			return nil, err
+		}
+		failures = append(failures, f)
+	}
+
+	return failures, rows.Err()
+}
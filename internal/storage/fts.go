@@ -0,0 +1,236 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// ErrFTSUnavailable is returned by full-text search when SQLite was built
+// without FTS5
+var ErrFTSUnavailable = errors.New("SQLite full-text search unavailable (build with -tags sqlite_fts5)")
+
+// ftsTriggers keep documents_fts in step with documents. Their presence also
+// marks the table as current: builds without FTS5 drop them (writes would
+// fail otherwise), and the next FTS5 build recreates them and rebuilds.
+var ftsTriggers = []string{"documents_fts_insert", "documents_fts_delete", "documents_fts_update"}
+
+// ftsSchema is an external-content FTS5 table over document titles and
+// content, so text isn't stored twice
+const ftsSchema = `
+CREATE VIRTUAL TABLE IF NOT EXISTS documents_fts USING fts5(
+	title, content,
+	content='documents',
+	tokenize='porter unicode61'
+);
+
+CREATE TRIGGER IF NOT EXISTS documents_fts_insert AFTER INSERT ON documents BEGIN
+	INSERT INTO documents_fts(rowid, title, content) VALUES (new.rowid, new.title, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS documents_fts_delete AFTER DELETE ON documents BEGIN
+	INSERT INTO documents_fts(documents_fts, rowid, title, content) VALUES ('delete', old.rowid, old.title, old.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS documents_fts_update AFTER UPDATE OF title, content ON documents
+WHEN old.title IS NOT new.title OR old.content IS NOT new.content BEGIN
+	INSERT INTO documents_fts(documents_fts, rowid, title, content) VALUES ('delete', old.rowid, old.title, old.content);
+	INSERT INTO documents_fts(rowid, title, content) VALUES (new.rowid, new.title, new.content);
+END;
+`
+
+// ftsTitleWeight ranks title matches above content matches, like the Bleve
+// index's default title boost
+const ftsTitleWeight = 3.0
+
+// Snippet match markers, replaced with <mark> tags after HTML-escaping
+const (
+	ftsMarkStart = "\x02"
+	ftsMarkEnd   = "\x03"
+)
+
+// FTSResult is a full-text match, with the same fields and JSON shape as
+// search.SearchResult
+type FTSResult struct {
+	ID        string              `json:"id"`
+	Title     string              `json:"title"`
+	Author    string              `json:"author"`
+	SlabURL   string              `json:"slab_url"`
+	Topics    []string            `json:"topics,omitempty"`
+	Score     float64             `json:"score"`
+	Fragments map[string][]string `json:"fragments,omitempty"` // Highlighted snippets
+}
+
+// initFTS sets up the full-text table when SQLite has FTS5, backfilling it
+// from documents when it's new or was left stale by a build without FTS5
+func (d *DB) initFTS() error {
+	var available bool
+	if err := d.db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&available); err != nil {
+		return fmt.Errorf("check FTS5: %w", err)
+	}
+
+	if !available {
+		// Triggers from an FTS5 build would make every document write fail
+		for _, trigger := range ftsTriggers {
+			if _, err := d.db.Exec("DROP TRIGGER IF EXISTS " + trigger); err != nil {
+				return fmt.Errorf("drop %s trigger: %w", trigger, err)
+			}
+		}
+		return nil
+	}
+
+	var current bool
+	err := d.db.QueryRow(`
+		SELECT COUNT(*) = ?
+		FROM sqlite_master
+		WHERE type = 'trigger' AND name IN (?, ?, ?)
+	`, len(ftsTriggers), ftsTriggers[0], ftsTriggers[1], ftsTriggers[2]).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("check FTS triggers: %w", err)
+	}
+
+	if !current {
+		tx, err := d.db.Begin()
+		if err != nil {
+			return fmt.Errorf("begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		if _, err := tx.Exec(ftsSchema); err != nil {
+			return fmt.Errorf("create FTS table: %w", err)
+		}
+		if _, err := tx.Exec("INSERT INTO documents_fts(documents_fts) VALUES ('rebuild')"); err != nil {
+			return fmt.Errorf("build FTS table: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit FTS table: %w", err)
+		}
+	}
+
+	d.fts = true
+	return nil
+}
+
+// HasFTS reports whether FTSSearch is available
+func (d *DB) HasFTS() bool {
+	return d.fts
+}
+
+// FTSSearch ranks non-archived documents against a keyword query with SQLite
+// full-text search (BM25), as a fallback when the Bleve index is unusable and
+// a baseline to compare its relevance against. All words must match; quoted
+// phrases match exactly and a trailing * matches prefixes. Other query syntax
+// is ignored.
+func (d *DB) FTSSearch(query string, limit int) ([]*FTSResult, error) {
+	if !d.fts {
+		return nil, ErrFTSUnavailable
+	}
+	match := ftsQuery(query)
+	if match == "" {
+		return nil, nil
+	}
+
+	rows, err := d.db.Query(`
+	SELECT d.id, d.title, COALESCE(d.author_name, ''), d.slab_url, COALESCE(d.topics, ''),
+	       -bm25(documents_fts, ?, 1.0),
+	       snippet(documents_fts, 1, ?, ?, '…', 32)
+	FROM documents_fts
+	JOIN documents d ON d.rowid = documents_fts.rowid
+	WHERE documents_fts MATCH ? AND d.archived_at IS NULL
+	ORDER BY bm25(documents_fts, ?, 1.0)
+	LIMIT ?
+	`, ftsTitleWeight, ftsMarkStart, ftsMarkEnd, match, ftsTitleWeight, limit)
+	if err != nil {
+		return nil, fmt.Errorf("full-text search: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*FTSResult
+	for rows.Next() {
+		r := &FTSResult{}
+		var topics, snippet string
+		if err := rows.Scan(&r.ID, &r.Title, &r.Author, &r.SlabURL, &topics, &r.Score, &snippet); err != nil {
+			return nil, err
+		}
+		r.Topics = (&Document{Topics: topics}).TopicNames()
+		if snippet != "" {
+			r.Fragments = map[string][]string{"Content": {highlightSnippet(snippet)}}
+		}
+		results = append(results, r)
+	}
+
+	return results, rows.Err()
+}
+
+// FTSCount returns the number of non-archived documents matching a query
+// in FTSSearch
+func (d *DB) FTSCount(query string) (int, error) {
+	if !d.fts {
+		return 0, ErrFTSUnavailable
+	}
+	match := ftsQuery(query)
+	if match == "" {
+		return 0, nil
+	}
+
+	var count int
+	err := d.db.QueryRow(`
+	SELECT COUNT(*)
+	FROM documents_fts
+	JOIN documents d ON d.rowid = documents_fts.rowid
+	WHERE documents_fts MATCH ? AND d.archived_at IS NULL
+	`, match).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("full-text count: %w", err)
+	}
+	return count, nil
+}
+
+// ftsQuery converts a user query into FTS5 syntax: each word or quoted phrase
+// becomes a quoted string (so punctuation can't cause syntax errors), and a
+// trailing * is kept as a prefix match
+func ftsQuery(query string) string {
+	var terms []string
+	for i, part := range strings.Split(query, `"`) {
+		words := strings.FieldsFunc(part, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '*'
+		})
+		if i%2 == 1 {
+			// Inside quotes: one phrase
+			var phrase []string
+			for _, w := range words {
+				if w = strings.Trim(w, "*"); w != "" {
+					phrase = append(phrase, w)
+				}
+			}
+			if len(phrase) > 0 {
+				terms = append(terms, `"`+strings.Join(phrase, " ")+`"`)
+			}
+			continue
+		}
+
+		for _, w := range words {
+			prefix := strings.HasSuffix(w, "*")
+			if w = strings.Trim(w, "*"); w == "" {
+				continue
+			}
+			term := `"` + w + `"`
+			if prefix {
+				term += "*"
+			}
+			terms = append(terms, term)
+		}
+	}
+	return strings.Join(terms, " ")
+}
+
+// highlightSnippet HTML-escapes an FTS snippet and turns its match markers
+// into <mark> tags, like Bleve's html highlighter
+func highlightSnippet(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	escaped = strings.ReplaceAll(escaped, ftsMarkStart, "<mark>")
+	escaped = strings.ReplaceAll(escaped, ftsMarkEnd, "</mark>")
+	return strings.Join(strings.Fields(escaped), " ")
+}
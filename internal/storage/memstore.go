@@ -0,0 +1,422 @@
+package storage
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemStore is an in-memory Store, for exercising the sync worker, search
+// index and web server without a SQLite database
+// Documents are copied in and out, so callers can't modify stored ones.
+type MemStore struct {
+	mu       sync.RWMutex
+	docs     map[string]*Document
+	chunks   map[bool]map[string][]*Chunk // By qwen, then document ID
+	failures map[string]*SyncFailure
+	fullSync time.Time // See SetLastFullSync
+	url      string    // See SetWorkspaceURL
+}
+
+// NewMemStore creates an empty in-memory store
+func NewMemStore() *MemStore {
+	return &MemStore{
+		docs:     make(map[string]*Document),
+		chunks:   map[bool]map[string][]*Chunk{false: {}, true: {}},
+		failures: make(map[string]*SyncFailure),
+	}
+}
+
+// Upsert inserts or replaces a document
+// A replaced document keeps its duplicate mark, as with DB.Upsert.
+func (m *MemStore) Upsert(doc *Document) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *doc
+	if existing, ok := m.docs[doc.ID]; ok {
+		stored.DuplicateOf = existing.DuplicateOf
+	}
+	m.docs[doc.ID] = &stored
+	return nil
+}
+
+// Get retrieves a document by ID
+// Returns an error wrapping ErrNotFound if there's no such document.
+func (m *MemStore) Get(id string) (*Document, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	doc, ok := m.docs[id]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", id, ErrNotFound)
+	}
+	found := *doc
+	return &found, nil
+}
+
+// GetMany retrieves the documents with the given IDs, keyed by ID
+// IDs with no document are left out of the map.
+func (m *MemStore) GetMany(ids []string) (map[string]*Document, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	docs := make(map[string]*Document, len(ids))
+	for _, id := range ids {
+		if doc, ok := m.docs[id]; ok {
+			found := *doc
+			docs[id] = &found
+		}
+	}
+	return docs, nil
+}
+
+// List retrieves all documents (non-archived by default), most recently
+// updated first
+func (m *MemStore) List(includeArchived bool) ([]*Document, error) {
+	return m.filter(func(doc *Document) bool {
+		return includeArchived || doc.ArchivedAt == nil
+	}), nil
+}
+
+// ListWithoutEmbeddings is List without the embedding blobs (Embedding and
+// EmbeddingQwen are nil)
+func (m *MemStore) ListWithoutEmbeddings(includeArchived bool) ([]*Document, error) {
+	docs, _ := m.List(includeArchived)
+	for _, doc := range docs {
+		doc.Embedding, doc.EmbeddingQwen = nil, nil
+	}
+	return docs, nil
+}
+
+// GetEmbeddings returns the serialized embedding in one field (EmbeddingQwen
+// if qwen) of each given document that has one, keyed by ID
+func (m *MemStore) GetEmbeddings(ids []string, qwen bool) (map[string][]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	blobs := make(map[string][]byte, len(ids))
+	for _, id := range ids {
+		doc, ok := m.docs[id]
+		if !ok {
+			continue
+		}
+		blob := doc.Embedding
+		if qwen {
+			blob = doc.EmbeddingQwen
+		}
+		if len(blob) > 0 {
+			blobs[id] = slices.Clone(blob)
+		}
+	}
+	return blobs, nil
+}
+
+// ListIDs returns the IDs of all documents (non-archived unless includeArchived)
+func (m *MemStore) ListIDs(includeArchived bool) ([]string, error) {
+	docs, _ := m.List(includeArchived)
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	return ids, nil
+}
+
+// ListPage retrieves a page of non-archived documents, most recently
+// updated first
+func (m *MemStore) ListPage(limit, offset int) ([]*Document, error) {
+	docs, _ := m.List(false)
+	if offset >= len(docs) {
+		return nil, nil
+	}
+	docs = docs[offset:]
+	if limit >= 0 && limit < len(docs) {
+		docs = docs[:limit]
+	}
+	return docs, nil
+}
+
+// ListChangedSince retrieves non-archived documents updated in Slab or
+// synced after since
+func (m *MemStore) ListChangedSince(since time.Time) ([]*Document, error) {
+	return m.filter(func(doc *Document) bool {
+		return doc.ArchivedAt == nil && (doc.UpdatedAt.After(since) || doc.SyncedAt.After(since))
+	}), nil
+}
+
+// filter returns copies of the documents keep accepts, most recently updated
+// first (by ID among documents updated at the same time)
+func (m *MemStore) filter(keep func(*Document) bool) []*Document {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var docs []*Document
+	for _, doc := range m.docs {
+		if keep(doc) {
+			found := *doc
+			docs = append(docs, &found)
+		}
+	}
+	slices.SortFunc(docs, func(a, b *Document) int {
+		if c := b.UpdatedAt.Compare(a.UpdatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return docs
+}
+
+// Count returns the number of non-archived documents
+func (m *MemStore) Count() (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	count := 0
+	for _, doc := range m.docs {
+		if doc.ArchivedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Delete removes a document and its chunks
+// Deleting a document that doesn't exist is not an error
+func (m *MemStore) Delete(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.docs, id)
+	delete(m.chunks[false], id)
+	delete(m.chunks[true], id)
+	return nil
+}
+
+// GetUpdatedAt retrieves just the updated_at timestamp for a document
+// Returns zero time if document doesn't exist
+func (m *MemStore) GetUpdatedAt(id string) (time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if doc, ok := m.docs[id]; ok {
+		return doc.UpdatedAt, nil
+	}
+	return time.Time{}, nil
+}
+
+// EmbeddingStats reports the distribution of embedding models and dimensions
+// across non-archived documents, like DB.EmbeddingStats
+func (m *MemStore) EmbeddingStats() ([]*EmbeddingStat, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	type key struct {
+		field, model string
+		dims         int
+	}
+	counts := make(map[key]*EmbeddingStat)
+	add := func(field, model string, blob []byte) {
+		if len(blob) == 0 {
+			return
+		}
+		k := key{field, model, len(blob) / 4}
+		if counts[k] == nil {
+			counts[k] = &EmbeddingStat{Field: field, Model: model, Dimensions: k.dims}
+		}
+		counts[k].Count++
+	}
+	for _, doc := range m.docs {
+		if doc.ArchivedAt != nil {
+			continue
+		}
+		add("embedding", doc.EmbeddingModel, doc.Embedding)
+		add("embedding_qwen", doc.EmbeddingQwenModel, doc.EmbeddingQwen)
+	}
+
+	var stats []*EmbeddingStat
+	for _, st := range counts {
+		stats = append(stats, st)
+	}
+	slices.SortFunc(stats, func(a, b *EmbeddingStat) int {
+		if c := cmp.Compare(a.Field, b.Field); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Count, a.Count)
+	})
+	return stats, nil
+}
+
+// ListDuplicates returns the non-archived documents marked as duplicates of
+// a non-archived canonical document, mapped to its ID
+func (m *MemStore) ListDuplicates() (map[string]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	dups := make(map[string]string)
+	for id, doc := range m.docs {
+		if doc.DuplicateOf == "" || doc.ArchivedAt != nil {
+			continue
+		}
+		if canonical, ok := m.docs[doc.DuplicateOf]; ok && canonical.ArchivedAt == nil {
+			dups[id] = doc.DuplicateOf
+		}
+	}
+	return dups, nil
+}
+
+// MarkDuplicates replaces every duplicate mark with dups (nil clears all marks)
+func (m *MemStore) MarkDuplicates(dups map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, doc := range m.docs {
+		doc.DuplicateOf = dups[id]
+	}
+	return nil
+}
+
+// GetComments returns a document's stored comments ("" if none or the
+// document doesn't exist)
+func (m *MemStore) GetComments(id string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if doc, ok := m.docs[id]; ok {
+		return doc.Comments, nil
+	}
+	return "", nil
+}
+
+// SetComments replaces a document's comments and marks it synced now
+func (m *MemStore) SetComments(id, comments string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if doc, ok := m.docs[id]; ok {
+		doc.Comments = comments
+		doc.SyncedAt = time.Now()
+	}
+	return nil
+}
+
+// ReplaceChunks replaces a document's chunks for one embedding model
+// Like DB's foreign key, fails if chunks are given for a missing document.
+func (m *MemStore) ReplaceChunks(docID string, qwen bool, chunks []*Chunk) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.docs[docID]; !ok && len(chunks) > 0 {
+		return fmt.Errorf("chunks for %s: %w", docID, ErrNotFound)
+	}
+
+	stored := make([]*Chunk, len(chunks))
+	for i, c := range chunks {
+		chunk := *c
+		chunk.DocID, chunk.Qwen = docID, qwen
+		stored[i] = &chunk
+	}
+	slices.SortFunc(stored, func(a, b *Chunk) int { return cmp.Compare(a.Index, b.Index) })
+	m.chunks[qwen][docID] = stored
+	return nil
+}
+
+// DeleteChunks removes all chunks for a document
+func (m *MemStore) DeleteChunks(docID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.chunks[false], docID)
+	delete(m.chunks[true], docID)
+	return nil
+}
+
+// ListChunks returns all chunks for one embedding model, grouped by document ID
+// and ordered by chunk index
+func (m *MemStore) ListChunks(qwen bool) (map[string][]*Chunk, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	chunks := make(map[string][]*Chunk, len(m.chunks[qwen]))
+	for id, cs := range m.chunks[qwen] {
+		chunks[id] = slices.Clone(cs)
+	}
+	return chunks, nil
+}
+
+// RecordSyncFailure inserts a failure or bumps the attempt count of an existing one
+func (m *MemStore) RecordSyncFailure(f *SyncFailure) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	stored := *f
+	stored.Attempts, stored.FirstFailedAt, stored.LastFailedAt = 1, now, now
+	if prev, ok := m.failures[f.PostID]; ok {
+		stored.Attempts = prev.Attempts + 1
+		stored.FirstFailedAt = prev.FirstFailedAt
+	}
+	m.failures[f.PostID] = &stored
+	return nil
+}
+
+// ClearSyncFailure removes the failure record for a post
+func (m *MemStore) ClearSyncFailure(postID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.failures, postID)
+	return nil
+}
+
+// ListSyncFailures returns all recorded failures, most persistent first
+func (m *MemStore) ListSyncFailures() ([]*SyncFailure, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var failures []*SyncFailure
+	for _, f := range m.failures {
+		found := *f
+		failures = append(failures, &found)
+	}
+	slices.SortFunc(failures, func(a, b *SyncFailure) int {
+		if c := cmp.Compare(b.Attempts, a.Attempts); c != 0 {
+			return c
+		}
+		return b.LastFailedAt.Compare(a.LastFailedAt)
+	})
+	return failures, nil
+}
+
+// LastFullSync returns when the last sync covering every topic started
+// (zero time if none was recorded)
+func (m *MemStore) LastFullSync() (time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.fullSync, nil
+}
+
+// SetLastFullSync records when a sync covering every topic started
+func (m *MemStore) SetLastFullSync(startedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fullSync = startedAt
+	return nil
+}
+
+// WorkspaceURL returns the Slab workspace the store was first synced from
+// ("" if it has never been synced)
+func (m *MemStore) WorkspaceURL() (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.url, nil
+}
+
+// SetWorkspaceURL records the Slab workspace the store is synced from
+func (m *MemStore) SetWorkspaceURL(url string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.url = url
+	return nil
+}
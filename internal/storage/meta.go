@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// metaSchema keeps database-wide values that belong to no single document
+const metaSchema = `
+CREATE TABLE IF NOT EXISTS meta (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+`
+
+// metaLastFullSync is when the last sync of every topic started (RFC 3339)
+const metaLastFullSync = "last_full_sync"
+
+// metaEmbeddingsNormalized is set once stored embeddings have been rewritten
+// unit length (see normalizeStoredEmbeddings)
+const metaEmbeddingsNormalized = "embeddings_normalized"
+
+// metaWorkspaceURL is the Slab workspace the documents were synced from
+const metaWorkspaceURL = "workspace_url"
+
+// getMeta returns the value stored under key ("" if none)
+func (d *DB) getMeta(key string) (string, error) {
+	var value string
+	err := d.db.QueryRow("SELECT value FROM meta WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return value, err
+}
+
+// setMeta stores value under key, replacing any earlier value
+func (d *DB) setMeta(key, value string) error {
+	_, err := d.db.Exec(`
+		INSERT INTO meta (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, key, value)
+	return err
+}
+
+// LastFullSync returns when the last sync covering every topic started
+// Topic syncs don't count, since posts elsewhere may have changed since.
+// Returns zero time if no full sync has been recorded.
+func (d *DB) LastFullSync() (time.Time, error) {
+	value, err := d.getMeta(metaLastFullSync)
+	if err != nil || value == "" {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse %s: %w", metaLastFullSync, err)
+	}
+	return t, nil
+}
+
+// SetLastFullSync records when a sync covering every topic started
+func (d *DB) SetLastFullSync(startedAt time.Time) error {
+	return d.setMeta(metaLastFullSync, startedAt.UTC().Format(time.RFC3339Nano))
+}
+
+// WorkspaceURL returns the Slab workspace the database was first synced
+// from ("" if it has never been synced)
+func (d *DB) WorkspaceURL() (string, error) {
+	return d.getMeta(metaWorkspaceURL)
+}
+
+// SetWorkspaceURL records the Slab workspace the database is synced from
+func (d *DB) SetWorkspaceURL(url string) error {
+	return d.setMeta(metaWorkspaceURL, url)
+}
@@ -0,0 +1,41 @@
+package storage
+
+import "time"
+
+// Store is the document storage the sync worker, search index and web server
+// depend on, implemented by DB (SQLite) and MemStore (in memory, for tests)
+// Commands that need SQLite-only features (full-text search, backups,
+// topics) still take a *DB.
+type Store interface {
+	Upsert(doc *Document) error
+	Get(id string) (*Document, error)
+	List(includeArchived bool) ([]*Document, error)
+	Count() (int, error)
+	GetUpdatedAt(id string) (time.Time, error)
+	Delete(id string) error
+
+	// Bulk reads for search results, semantic scoring, browsing and
+	// incremental reindexing
+	GetMany(ids []string) (map[string]*Document, error)
+	ListIDs(includeArchived bool) ([]string, error)
+	ListWithoutEmbeddings(includeArchived bool) ([]*Document, error)
+	GetEmbeddings(ids []string, qwen bool) (map[string][]byte, error)
+	ListPage(limit, offset int) ([]*Document, error)
+	ListChangedSince(since time.Time) ([]*Document, error)
+	EmbeddingStats() ([]*EmbeddingStat, error)
+	ListDuplicates() (map[string]string, error)
+
+	// Comments, chunks and sync bookkeeping, kept alongside documents
+	GetComments(id string) (string, error)
+	SetComments(id, comments string) error
+	ListChunks(qwen bool) (map[string][]*Chunk, error)
+	DeleteChunks(docID string) error
+	RecordSyncFailure(f *SyncFailure) error
+	ClearSyncFailure(postID string) error
+	SetLastFullSync(startedAt time.Time) error
+}
+
+var (
+	_ Store = (*DB)(nil)
+	_ Store = (*MemStore)(nil)
+)
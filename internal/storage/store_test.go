@@ -0,0 +1,456 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// testStore is Store plus the methods both implementations share beyond it
+type testStore interface {
+	Store
+	ReplaceChunks(docID string, qwen bool, chunks []*Chunk) error
+	MarkDuplicates(dups map[string]string) error
+	ListSyncFailures() ([]*SyncFailure, error)
+	LastFullSync() (time.Time, error)
+	WorkspaceURL() (string, error)
+	SetWorkspaceURL(url string) error
+}
+
+// forEachStore runs test against an empty DB and an empty MemStore, so both
+// are held to the same behavior
+func forEachStore(t *testing.T, test func(t *testing.T, s testStore)) {
+	t.Run("DB", func(t *testing.T) {
+		db, err := Open(filepath.Join(t.TempDir(), "slab.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		test(t, db)
+	})
+	t.Run("MemStore", func(t *testing.T) {
+		test(t, NewMemStore())
+	})
+}
+
+// day returns midnight UTC on a day of January 2026
+func day(n int) time.Time {
+	return time.Date(2026, time.January, n, 0, 0, 0, 0, time.UTC)
+}
+
+// testDoc returns a document updated on the given day
+func testDoc(id string, updated int) *Document {
+	return &Document{
+		ID:          id,
+		Title:       "Title " + id,
+		Content:     "Content of " + id,
+		SlabURL:     "https://slab.example.com/posts/" + id,
+		PublishedAt: day(1),
+		UpdatedAt:   day(updated),
+		SyncedAt:    day(updated),
+	}
+}
+
+// upsert stores docs, failing the test on error
+func upsert(t *testing.T, s Store, docs ...*Document) {
+	t.Helper()
+	for _, doc := range docs {
+		if err := s.Upsert(doc); err != nil {
+			t.Fatalf("upsert %s: %v", doc.ID, err)
+		}
+	}
+}
+
+func docIDs(docs []*Document) []string {
+	ids := make([]string, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	return ids
+}
+
+func TestStoreUpsertAndGet(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		embeddedAt := day(3)
+		want := testDoc("a", 2)
+		want.AuthorName = "Ada"
+		want.AuthorEmail = "ada@example.com"
+		want.Topics = `[{"id":"t1","name":"Runbooks"}]`
+		want.Embedding = []byte{1, 2, 3, 4}
+		want.EmbeddingModel = "nomic-embed-text"
+		want.EmbeddedAt = &embeddedAt
+		want.Comments = "Bob: looks good"
+		want.ContentLength = len(want.Content)
+		upsert(t, s, want)
+
+		got, err := s.Get("a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Title != want.Title || got.Content != want.Content || got.AuthorName != want.AuthorName ||
+			got.AuthorEmail != want.AuthorEmail || got.SlabURL != want.SlabURL || got.Comments != want.Comments ||
+			got.EmbeddingModel != want.EmbeddingModel || got.ContentLength != want.ContentLength {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+		if !slices.Equal(got.TopicNames(), []string{"Runbooks"}) {
+			t.Errorf("topics = %v, want [Runbooks]", got.TopicNames())
+		}
+		if !slices.Equal(got.Embedding, want.Embedding) {
+			t.Errorf("embedding = %v, want %v", got.Embedding, want.Embedding)
+		}
+		if !got.UpdatedAt.Equal(want.UpdatedAt) || !got.PublishedAt.Equal(want.PublishedAt) ||
+			got.EmbeddedAt == nil || !got.EmbeddedAt.Equal(embeddedAt) || got.ArchivedAt != nil {
+			t.Errorf("times = %v/%v/%v/%v, want %v/%v/%v/nil",
+				got.PublishedAt, got.UpdatedAt, got.EmbeddedAt, got.ArchivedAt,
+				want.PublishedAt, want.UpdatedAt, embeddedAt)
+		}
+
+		// Stored documents are copies
+		got.Title = "changed"
+		if again, _ := s.Get("a"); again.Title != want.Title {
+			t.Errorf("modifying a fetched document changed the stored title to %q", again.Title)
+		}
+
+		if _, err := s.Get("missing"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+		}
+		if updated, err := s.GetUpdatedAt("missing"); err != nil || !updated.IsZero() {
+			t.Errorf("GetUpdatedAt(missing) = %v, %v, want zero time", updated, err)
+		}
+		if updated, err := s.GetUpdatedAt("a"); err != nil || !updated.Equal(want.UpdatedAt) {
+			t.Errorf("GetUpdatedAt(a) = %v, %v, want %v", updated, err, want.UpdatedAt)
+		}
+	})
+}
+
+func TestStoreUpsertKeepsDuplicateMark(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		upsert(t, s, testDoc("canon", 1), testDoc("copy", 2))
+		if err := s.MarkDuplicates(map[string]string{"copy": "canon"}); err != nil {
+			t.Fatal(err)
+		}
+
+		// A resync doesn't know about marks and must not clear them
+		resynced := testDoc("copy", 3)
+		resynced.Title = "Updated copy"
+		upsert(t, s, resynced)
+
+		got, err := s.Get("copy")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Title != "Updated copy" || got.DuplicateOf != "canon" {
+			t.Errorf("got title %q, duplicate of %q; want \"Updated copy\", \"canon\"", got.Title, got.DuplicateOf)
+		}
+
+		if err := s.MarkDuplicates(nil); err != nil {
+			t.Fatal(err)
+		}
+		if got, _ := s.Get("copy"); got.DuplicateOf != "" {
+			t.Errorf("MarkDuplicates(nil) left duplicate of %q", got.DuplicateOf)
+		}
+	})
+}
+
+func TestStoreListing(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		archived := testDoc("old", 9)
+		archivedAt := day(10)
+		archived.ArchivedAt = &archivedAt
+		upsert(t, s, testDoc("a", 2), testDoc("b", 5), testDoc("c", 3), archived)
+
+		docs, err := s.List(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := docIDs(docs); !slices.Equal(got, []string{"b", "c", "a"}) {
+			t.Errorf("List(false) = %v, want [b c a]", got)
+		}
+		docs, err = s.List(true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := docIDs(docs); !slices.Equal(got, []string{"old", "b", "c", "a"}) {
+			t.Errorf("List(true) = %v, want [old b c a]", got)
+		}
+
+		ids, err := s.ListIDs(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		slices.Sort(ids)
+		if !slices.Equal(ids, []string{"a", "b", "c"}) {
+			t.Errorf("ListIDs(false) = %v, want [a b c]", ids)
+		}
+
+		page, err := s.ListPage(2, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := docIDs(page); !slices.Equal(got, []string{"c", "a"}) {
+			t.Errorf("ListPage(2, 1) = %v, want [c a]", got)
+		}
+		if page, _ := s.ListPage(10, 5); len(page) != 0 {
+			t.Errorf("ListPage past the end = %v, want none", docIDs(page))
+		}
+
+		if count, err := s.Count(); err != nil || count != 3 {
+			t.Errorf("Count() = %d, %v, want 3", count, err)
+		}
+
+		many, err := s.GetMany([]string{"a", "old", "missing"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(many) != 2 || many["a"] == nil || many["old"] == nil {
+			t.Errorf("GetMany = %v, want a and old", many)
+		}
+	})
+}
+
+func TestStoreListChangedSince(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		// Synced recently, but last updated in Slab long ago
+		backfilled := testDoc("backfilled", 1)
+		backfilled.SyncedAt = day(8)
+		upsert(t, s, testDoc("old", 2), testDoc("new", 7), backfilled)
+
+		docs, err := s.ListChangedSince(day(5))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := docIDs(docs); !slices.Equal(got, []string{"new", "backfilled"}) {
+			t.Errorf("ListChangedSince = %v, want [new backfilled]", got)
+		}
+
+		// New comments count as a change
+		if err := s.SetComments("old", "Ada: outdated?"); err != nil {
+			t.Fatal(err)
+		}
+		docs, err = s.ListChangedSince(day(5))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Contains(docIDs(docs), "old") {
+			t.Errorf("ListChangedSince = %v, want it to include old after SetComments", docIDs(docs))
+		}
+		if comments, err := s.GetComments("old"); err != nil || comments != "Ada: outdated?" {
+			t.Errorf("GetComments(old) = %q, %v", comments, err)
+		}
+		if comments, err := s.GetComments("missing"); err != nil || comments != "" {
+			t.Errorf("GetComments(missing) = %q, %v, want \"\"", comments, err)
+		}
+	})
+}
+
+func TestStoreChunks(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		upsert(t, s, testDoc("a", 1), testDoc("b", 1))
+		chunks := []*Chunk{
+			{Index: 1, Content: "second", Embedding: []byte{2, 0, 0, 0}},
+			{Index: 0, Content: "first", Embedding: []byte{1, 0, 0, 0}},
+		}
+		if err := s.ReplaceChunks("a", false, chunks); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.ReplaceChunks("b", true, chunks[:1]); err != nil {
+			t.Fatal(err)
+		}
+
+		listed, err := s.ListChunks(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(listed) != 1 || len(listed["a"]) != 2 {
+			t.Fatalf("ListChunks(false) = %v, want two chunks of a", listed)
+		}
+		for i, c := range listed["a"] {
+			if c.Index != i || c.DocID != "a" || c.Qwen {
+				t.Errorf("chunk %d = %+v", i, c)
+			}
+		}
+		if listed["a"][0].Content != "first" {
+			t.Errorf("first chunk content = %q, want \"first\"", listed["a"][0].Content)
+		}
+
+		// Deleting a document deletes its chunks
+		if err := s.Delete("b"); err != nil {
+			t.Fatal(err)
+		}
+		if qwen, _ := s.ListChunks(true); len(qwen) != 0 {
+			t.Errorf("chunks of deleted document remain: %v", qwen)
+		}
+		if err := s.Delete("missing"); err != nil {
+			t.Errorf("Delete(missing) = %v, want nil", err)
+		}
+
+		if err := s.DeleteChunks("a"); err != nil {
+			t.Fatal(err)
+		}
+		if listed, _ := s.ListChunks(false); len(listed) != 0 {
+			t.Errorf("DeleteChunks left %v", listed)
+		}
+
+		// Chunks must belong to a stored document
+		if err := s.ReplaceChunks("missing", false, chunks); err == nil {
+			t.Error("ReplaceChunks for a missing document succeeded")
+		}
+	})
+}
+
+func TestStoreEmbeddingStats(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		var docs []*Document
+		for i, model := range []string{"nomic", "nomic", "other"} {
+			doc := testDoc(string(rune('a'+i)), 1)
+			doc.Embedding = make([]byte, 8)
+			doc.EmbeddingModel = model
+			docs = append(docs, doc)
+		}
+		docs[0].EmbeddingQwen = make([]byte, 12)
+		docs[0].EmbeddingQwenModel = "qwen"
+		upsert(t, s, docs...)
+
+		stats, err := s.EmbeddingStats()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []EmbeddingStat{
+			{Field: "embedding", Model: "nomic", Dimensions: 2, Count: 2},
+			{Field: "embedding", Model: "other", Dimensions: 2, Count: 1},
+			{Field: "embedding_qwen", Model: "qwen", Dimensions: 3, Count: 1},
+		}
+		if len(stats) != len(want) {
+			t.Fatalf("got %d stats, want %d", len(stats), len(want))
+		}
+		for i, st := range stats {
+			if *st != want[i] {
+				t.Errorf("stat %d = %+v, want %+v", i, *st, want[i])
+			}
+		}
+	})
+}
+
+func TestStoreListDuplicates(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		archivedCanon := testDoc("archived-canon", 1)
+		archivedAt := day(2)
+		archivedCanon.ArchivedAt = &archivedAt
+		upsert(t, s, testDoc("canon", 1), testDoc("copy", 1), testDoc("orphan", 1), archivedCanon)
+
+		err := s.MarkDuplicates(map[string]string{"copy": "canon", "orphan": "archived-canon"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		dups, err := s.ListDuplicates()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dups) != 1 || dups["copy"] != "canon" {
+			t.Errorf("ListDuplicates = %v, want copy -> canon", dups)
+		}
+	})
+}
+
+func TestStoreSyncFailures(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		for _, f := range []*SyncFailure{
+			{PostID: "p1", Title: "One", StatusCode: 500, Error: "boom"},
+			{PostID: "p2", Title: "Two", StatusCode: 404, Error: "gone"},
+			{PostID: "p1", Title: "One", StatusCode: 502, Error: "bad gateway"},
+		} {
+			if err := s.RecordSyncFailure(f); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		failures, err := s.ListSyncFailures()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(failures) != 2 {
+			t.Fatalf("got %d failures, want 2", len(failures))
+		}
+		if f := failures[0]; f.PostID != "p1" || f.Attempts != 2 || f.StatusCode != 502 || f.Error != "bad gateway" {
+			t.Errorf("most persistent failure = %+v, want p1 with 2 attempts and the latest error", f)
+		}
+		if f := failures[0]; f.FirstFailedAt.After(f.LastFailedAt) {
+			t.Errorf("first failure %v is after the last %v", f.FirstFailedAt, f.LastFailedAt)
+		}
+
+		if err := s.ClearSyncFailure("p1"); err != nil {
+			t.Fatal(err)
+		}
+		failures, _ = s.ListSyncFailures()
+		if len(failures) != 1 || failures[0].PostID != "p2" {
+			t.Errorf("after clearing p1, failures = %v", failures)
+		}
+	})
+}
+
+func TestStoreLastFullSync(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		if last, err := s.LastFullSync(); err != nil || !last.IsZero() {
+			t.Errorf("LastFullSync() before any sync = %v, %v, want zero time", last, err)
+		}
+		started := time.Date(2026, time.March, 4, 5, 6, 7, 8, time.FixedZone("PST", -8*60*60))
+		if err := s.SetLastFullSync(started); err != nil {
+			t.Fatal(err)
+		}
+		if last, err := s.LastFullSync(); err != nil || !last.Equal(started) {
+			t.Errorf("LastFullSync() = %v, %v, want %v", last, err, started)
+		}
+	})
+}
+
+func TestStoreWorkspaceURL(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		if url, err := s.WorkspaceURL(); err != nil || url != "" {
+			t.Errorf("WorkspaceURL() before any sync = %q, %v, want none", url, err)
+		}
+		for _, want := range []string{"https://slab.render.com", "https://myteam.slab.com"} {
+			if err := s.SetWorkspaceURL(want); err != nil {
+				t.Fatal(err)
+			}
+			if url, err := s.WorkspaceURL(); err != nil || url != want {
+				t.Errorf("WorkspaceURL() = %q, %v, want %q", url, err, want)
+			}
+		}
+	})
+}
+
+func TestStoreEmbeddingsListedSeparately(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s testStore) {
+		embedded := testDoc("embedded", 2)
+		embedded.Embedding = []byte{1, 2, 3, 4}
+		embedded.EmbeddingQwen = []byte{5, 6, 7, 8}
+		upsert(t, s, embedded, testDoc("plain", 1))
+
+		docs, err := s.ListWithoutEmbeddings(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := docIDs(docs); !slices.Equal(got, []string{"embedded", "plain"}) {
+			t.Fatalf("ListWithoutEmbeddings = %v, want [embedded plain]", got)
+		}
+		if docs[0].Embedding != nil || docs[0].EmbeddingQwen != nil || docs[0].Title != embedded.Title {
+			t.Errorf("ListWithoutEmbeddings returned %+v", docs[0])
+		}
+
+		for _, qwen := range []bool{false, true} {
+			blobs, err := s.GetEmbeddings([]string{"embedded", "plain", "missing"}, qwen)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := embedded.Embedding
+			if qwen {
+				want = embedded.EmbeddingQwen
+			}
+			if len(blobs) != 1 || !slices.Equal(blobs["embedded"], want) {
+				t.Errorf("GetEmbeddings(qwen=%v) = %v, want only embedded: %v", qwen, blobs, want)
+			}
+		}
+	})
+}
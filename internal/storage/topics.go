@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// topicsSchema normalizes the topics JSON on each document into a topic table
+// and a join table, so documents can be listed and counted per topic without
+// decoding every row. Upsert keeps both in step with the JSON column.
+const topicsSchema = `
+CREATE TABLE IF NOT EXISTS topics (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_topic_name ON topics(name);
+
+CREATE TABLE IF NOT EXISTS document_topics (
+	doc_id TEXT NOT NULL REFERENCES documents(id) ON DELETE CASCADE,
+	topic_id TEXT NOT NULL REFERENCES topics(id),
+	PRIMARY KEY (doc_id, topic_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_document_topics_topic ON document_topics(topic_id);
+`
+
+// documentTopics expands the topics JSON of the documents matching %s into
+// one row per topic. Malformed JSON is treated as no topics rather than
+// failing the statement.
+const documentTopics = `
+FROM documents d, json_each(CASE WHEN json_valid(d.topics) AND json_type(d.topics) = 'array' THEN d.topics ELSE '[]' END) t
+WHERE %s AND json_extract(t.value, '$.id') IS NOT NULL
+`
+
+// insertTopics adds the topics of the matching documents, keeping known names
+// when a document only has topic IDs
+var insertTopics = `
+INSERT INTO topics (id, name)
+SELECT json_extract(t.value, '$.id'), COALESCE(json_extract(t.value, '$.name'), '')
+` + documentTopics + `
+ON CONFLICT(id) DO UPDATE SET name = excluded.name WHERE excluded.name != ''
+`
+
+// insertDocumentTopics links the matching documents to their topics
+var insertDocumentTopics = `
+INSERT OR IGNORE INTO document_topics (doc_id, topic_id)
+SELECT d.id, json_extract(t.value, '$.id')
+` + documentTopics
+
+// migrateTopics creates the topic tables, backfilling them from the topics
+// JSON of existing documents when they're new
+func (d *DB) migrateTopics() error {
+	var exists bool
+	err := d.db.QueryRow(`
+		SELECT COUNT(*) > 0
+		FROM sqlite_master
+		WHERE type = 'table' AND name = 'document_topics'
+	`).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("check topic tables: %w", err)
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(topicsSchema); err != nil {
+		return fmt.Errorf("create topic tables: %w", err)
+	}
+	if !exists {
+		if _, err := tx.Exec(fmt.Sprintf(insertTopics, "1")); err != nil {
+			return fmt.Errorf("backfill topics: %w", err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf(insertDocumentTopics, "1")); err != nil {
+			return fmt.Errorf("backfill document topics: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// replaceTopics relinks a document to the topics in its stored topics JSON
+func replaceTopics(tx *sql.Tx, docID string) error {
+	if _, err := tx.Exec("DELETE FROM document_topics WHERE doc_id = ?", docID); err != nil {
+		return fmt.Errorf("unlink topics: %w", err)
+	}
+	if _, err := tx.Exec(fmt.Sprintf(insertTopics, "d.id = ?"), docID); err != nil {
+		return fmt.Errorf("insert topics: %w", err)
+	}
+	if _, err := tx.Exec(fmt.Sprintf(insertDocumentTopics, "d.id = ?"), docID); err != nil {
+		return fmt.Errorf("link topics: %w", err)
+	}
+	return nil
+}
+
+// ListByTopic retrieves the non-archived documents in the topic with the
+// given name (exact match), most recently updated first
+func (d *DB) ListByTopic(name string) ([]*Document, error) {
+	query := `SELECT ` + documentColumns + ` FROM documents
+	WHERE archived_at IS NULL AND id IN (
+		SELECT dt.doc_id
+		FROM document_topics dt
+		JOIN topics t ON t.id = dt.topic_id
+		WHERE t.name = ?
+	)
+	ORDER BY updated_at DESC`
+
+	rows, err := d.db.Query(query, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var docs []*Document
+	for rows.Next() {
+		doc, err := scanDocument(rows)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	return docs, rows.Err()
+}
+
+// TopicCount is the number of non-archived documents in a topic
+type TopicCount struct {
+	ID    string
+	Name  string
+	Count int
+}
+
+// TopicCounts returns every named topic with at least one non-archived
+// document, largest first
+func (d *DB) TopicCounts() ([]*TopicCount, error) {
+	query := `
+	SELECT t.id, t.name, COUNT(*)
+	FROM topics t
+	JOIN document_topics dt ON dt.topic_id = t.id
+	JOIN documents d ON d.id = dt.doc_id
+	WHERE d.archived_at IS NULL AND t.name != ''
+	GROUP BY t.id
+	ORDER BY 3 DESC, t.name
+	`
+
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []*TopicCount
+	for rows.Next() {
+		tc := &TopicCount{}
+		if err := rows.Scan(&tc.ID, &tc.Name, &tc.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, tc)
+	}
+
+	return counts, rows.Err()
+}
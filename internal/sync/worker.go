@@ -3,10 +3,13 @@ package sync
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/renderinc/slab-search/internal/embeddings"
 	"github.com/renderinc/slab-search/internal/search"
@@ -17,15 +20,25 @@ import (
 // Worker handles syncing posts from Slab
 type Worker struct {
 	slabClient     *slab.Client
-	db             *storage.DB
+	db             storage.Store
 	index          *search.Index
-	embedder       *embeddings.Client // Optional: nil if embeddings disabled
+	embedder       embeddings.Embedder // Optional: nil if embeddings disabled
 	maxPosts       int                // Limit for testing (0 = unlimited)
 	enableEmbeddings bool             // Whether to generate embeddings
+	embeddingsDisabled bool           // Turned off by DisableEmbeddings rather than for lack of an embedder
+	updatedSince   time.Time          // Incremental sync: skip posts updated before this (zero = full sync)
+	concurrency    int                // Number of posts synced in parallel
+	dryRun         bool               // Report what would change without fetching or writing
+	withComments   bool               // Fetch and index post comments
+	topic          string             // Sync only this topic's posts, by ID or name ("" = all posts)
+	progressFn     func(Stats)        // Optional: called with progress snapshots and the final stats
 }
 
+// DefaultConcurrency is the number of posts synced in parallel unless overridden
+const DefaultConcurrency = 20
+
 // NewWorker creates a new sync worker
-func NewWorker(slabClient *slab.Client, db *storage.DB, index *search.Index, embedder *embeddings.Client, maxPosts int) *Worker {
+func NewWorker(slabClient *slab.Client, db storage.Store, index *search.Index, embedder embeddings.Embedder, maxPosts int) *Worker {
 	return &Worker{
 		slabClient:       slabClient,
 		db:               db,
@@ -33,20 +46,102 @@ func NewWorker(slabClient *slab.Client, db *storage.DB, index *search.Index, emb
 		embedder:         embedder,
 		maxPosts:         maxPosts,
 		enableEmbeddings: embedder != nil,
+		concurrency:      DefaultConcurrency,
 	}
 }
 
+// SetConcurrency sets the number of posts synced in parallel (minimum 1)
+// Lower values ease load on the Slab API at the cost of a slower sync
+func (w *Worker) SetConcurrency(n int) {
+	w.concurrency = max(n, 1)
+}
+
+// SetUpdatedSince limits the sync to posts updated at or after t, skipping the
+// markdown and metadata round-trips for everything else. The full post list
+// is still fetched, so archived and deleted posts are handled as usual.
+//
+// A post restored from the archive without being edited keeps its old
+// UpdatedAt and is skipped; run a full sync or reindex to restore it to search.
+func (w *Worker) SetUpdatedSince(t time.Time) {
+	w.updatedSince = t
+}
+
+// SetDryRun makes Sync only report what it would do: posts are compared with
+// the database by UpdatedAt, but no markdown is fetched and nothing is written
+// to the database or index (which may be nil)
+func (w *Worker) SetDryRun(dryRun bool) {
+	w.dryRun = dryRun
+}
+
+// SetWithComments makes Sync fetch each post's comments and index them with
+// the post. This costs an extra API request per post, unchanged posts
+// included, since new comments don't change a post's UpdatedAt.
+func (w *Worker) SetWithComments(withComments bool) {
+	w.withComments = withComments
+}
+
+// SetTopic limits the sync to the posts in one topic, given by ID or name
+// (case-insensitive), for a quick refresh of a collection known to have
+// changed. Documents outside the topic are left untouched; in particular
+// nothing is purged as deleted, since the topic's posts aren't the full list.
+func (w *Worker) SetTopic(topic string) {
+	w.topic = topic
+}
+
+// DisableEmbeddings stops Sync generating embeddings even with an embedder,
+// for a quicker keyword-only sync; run embed -missing later to catch up
+func (w *Worker) DisableEmbeddings() {
+	w.enableEmbeddings = false
+	w.embeddingsDisabled = true
+}
+
+// SetProgressFn sets a function Sync calls with a snapshot of its stats every
+// few seconds while posts are syncing, and once more with the final stats
+// when it completes (Processed == TotalPosts). Dry runs don't call it.
+// It runs on a separate goroutine from the workers, so it mustn't block long.
+func (w *Worker) SetProgressFn(fn func(Stats)) {
+	w.progressFn = fn
+}
+
 // Stats holds sync statistics
+// In a dry run, the post counts are what a real sync would do and
+// ArchivedRemoved counts archived posts still stored locally.
+// Stats marshal to JSON with snake_case keys and the duration in seconds.
 type Stats struct {
-	TotalPosts       int
-	NewPosts         int
-	UpdatedPosts     int
-	SkippedPosts     int
-	ArchivedRemoved  int // Number of archived posts removed from search
-	EmbeddingsGen    int // Number of embeddings generated
-	EmbeddingsFailed int // Number of embedding failures
-	Errors           int
-	Duration         time.Duration
+	Topic             string        `json:"topic,omitempty"` // Name of the topic synced, with SetTopic
+	TotalPosts        int           `json:"total_posts"`
+	Processed         int           `json:"processed"` // Posts synced so far, including skipped and failed ones
+	NewPosts          int           `json:"new_posts"`
+	UpdatedPosts      int           `json:"updated_posts"`
+	SkippedPosts      int           `json:"skipped_posts"`
+	ArchivedRemoved   int           `json:"archived_removed"`             // Number of archived posts removed from search
+	DeletedPosts      int           `json:"deleted_posts"`                // Number of posts deleted in Slab and purged locally
+	CommentsUpdated   int           `json:"comments_updated"`             // Unchanged posts re-indexed for new or edited comments
+	EmbeddingsGen     int           `json:"embeddings_generated"`         // Number of embeddings generated
+	EmbeddingsFailed  int           `json:"embeddings_failed"`            // Number of embedding failures
+	EmbeddingsSkipped string        `json:"embeddings_skipped,omitempty"` // Why none were generated: EmbeddingsDisabled or EmbeddingsUnavailable
+	Errors            int           `json:"errors"`
+	Duration          time.Duration `json:"-"` // Marshaled as duration_seconds
+
+	// ReindexDuration is how long rebuilding the keyword index after the sync
+	// took, for callers that do (sync -reindex); Sync leaves it 0
+	ReindexDuration time.Duration `json:"-"` // Marshaled as reindex_seconds
+}
+
+// Reasons a sync generated no embeddings, for Stats.EmbeddingsSkipped
+const (
+	EmbeddingsDisabled    = "disabled"    // DisableEmbeddings (sync -no-embeddings)
+	EmbeddingsUnavailable = "unavailable" // No embedder (provider not running)
+)
+
+// MarshalJSON encodes the stats with the durations as fractional seconds
+func (s Stats) MarshalJSON() ([]byte, error) {
+	type plain Stats // Without this method
+	return json.Marshal(struct {
+		plain
+		DurationSeconds float64 `json:"duration_seconds"`
+		ReindexSeconds  float64 `json:"reindex_seconds,omitempty"`
+	}{plain(s), s.Duration.Seconds(), s.ReindexDuration.Seconds()})
 }
 
 // Sync performs a full sync of posts
@@ -54,18 +149,44 @@ func (w *Worker) Sync(ctx context.Context) (*Stats, error) {
 	startTime := time.Now()
 	stats := &Stats{}
 
-	log.Println("Starting sync...")
+	slog.Info("Starting sync")
+	switch {
+	case w.dryRun:
+	case w.embeddingsDisabled:
+		stats.EmbeddingsSkipped = EmbeddingsDisabled
+		slog.Info("Embeddings disabled, syncing keyword search only")
+	case !w.enableEmbeddings:
+		stats.EmbeddingsSkipped = EmbeddingsUnavailable
+	}
 
-	// 1. Fetch all posts via currentSession (much faster than topic iteration)
-	log.Println("Fetching all posts from Slab...")
-	allPostsSlice, err := w.slabClient.GetAllSlimPosts(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("get all posts: %w", err)
+	// 1. Fetch all posts via currentSession (much faster than topic iteration),
+	// or just the topic's posts for a topic sync
+	var allPostsSlice []slab.SlimPost
+	var err error
+	if w.topic != "" {
+		topic, topicErr := w.resolveTopic(ctx)
+		if topicErr != nil {
+			return nil, topicErr
+		}
+		stats.Topic = topic.Name
+		slog.Debug("Fetching topic posts from Slab", "topic", topic.Name, "id", topic.ID)
+		allPostsSlice, err = w.slabClient.GetTopicPosts(ctx, topic.ID)
+	} else {
+		slog.Debug("Fetching all posts from Slab")
+		allPostsSlice, err = w.slabClient.GetAllSlimPosts(ctx)
+	}
+	// A partial post list is still worth syncing, but posts missing from it
+	// mustn't be purged as deleted
+	partialList := slab.IsPartial(err)
+	if partialList {
+		slog.Warn("Partial post list; syncing the posts returned, skipping the deleted-post purge", "error", err)
+	} else if err != nil {
+		return nil, fmt.Errorf("fetch posts: %w", err)
 	}
-	log.Printf("Found %d posts from Slab\n", len(allPostsSlice))
+	slog.Info("Fetched posts from Slab", "posts", len(allPostsSlice))
 
 	// 2. Filter and prepare posts, collect archived post IDs for removal
-	log.Println("Filtering posts...")
+	slog.Debug("Filtering posts")
 	allPosts := make(map[string]*slab.SlimPost)
 	archivedPostIDs := make([]string, 0)
 	postCount := 0
@@ -77,9 +198,15 @@ func (w *Worker) Sync(ctx context.Context) (*Stats, error) {
 			continue
 		}
 
+		// Incremental sync: skip posts that haven't changed since the cutoff
+		if !w.updatedSince.IsZero() && allPostsSlice[i].UpdatedAt.Before(w.updatedSince) {
+			stats.SkippedPosts++
+			continue
+		}
+
 		// Apply maxPosts limit if set (for testing)
 		if w.maxPosts > 0 && postCount >= w.maxPosts {
-			log.Printf("Reached maxPosts limit (%d), stopping\n", w.maxPosts)
+			slog.Info("Reached maxPosts limit, stopping", "max_posts", w.maxPosts)
 			break
 		}
 
@@ -88,10 +215,22 @@ func (w *Worker) Sync(ctx context.Context) (*Stats, error) {
 	}
 
 	stats.TotalPosts = len(allPosts)
-	log.Printf("Total posts to sync: %d (excluding %d archived)\n", stats.TotalPosts, len(allPostsSlice)-len(allPosts))
+	if w.updatedSince.IsZero() {
+		slog.Info("Posts to sync", "posts", stats.TotalPosts, "archived", len(allPostsSlice)-len(allPosts))
+	} else {
+		slog.Info("Posts to sync", "posts", stats.TotalPosts, "updated_since", w.updatedSince.Format(time.RFC3339),
+			"unchanged", stats.SkippedPosts)
+	}
+
+	if w.dryRun {
+		if partialList || w.topic != "" {
+			allPostsSlice = nil // Don't count missing posts as deleted
+		}
+		return w.planSync(allPosts, allPostsSlice, archivedPostIDs, stats, startTime)
+	}
 
 	// 3. Sync each post with concurrency
-	log.Println("Syncing posts...")
+	slog.Debug("Syncing posts", "concurrency", w.concurrency)
 	postChan := make(chan *slab.SlimPost, len(allPosts))
 	for _, post := range allPosts {
 		postChan <- post
@@ -99,96 +238,290 @@ func (w *Worker) Sync(ctx context.Context) (*Stats, error) {
 	close(postChan)
 
 	// Use worker pool for concurrent syncing
-	concurrency := 20 // Increased from 5 for faster syncing
 	var wg sync.WaitGroup
 	var mu sync.Mutex
-	var processed int
+	var earliestFailed time.Time // UpdatedAt of the oldest post that failed, guarded by mu
 
-	// Progress reporting
+	// Progress reporting, stopped once the workers finish so the steps after
+	// them can update stats without the lock
 	totalPosts := len(allPosts)
-	progressTicker := time.NewTicker(5 * time.Second)
-	defer progressTicker.Stop()
+	stopProgress := func() {}
+	if w.progressFn != nil {
+		progressTicker := time.NewTicker(5 * time.Second)
+		done := make(chan struct{})
+		stopped := make(chan struct{})
+		stopProgress = func() {
+			progressTicker.Stop()
+			close(done)
+			<-stopped
+		}
 
-	go func() {
-		for range progressTicker.C {
-			mu.Lock()
-			current := processed
-			newPosts := stats.NewPosts
-			updatedPosts := stats.UpdatedPosts
-			skippedPosts := stats.SkippedPosts
-			errors := stats.Errors
-			embGen := stats.EmbeddingsGen
-			mu.Unlock()
-			if current > 0 && current < totalPosts {
-				percent := float64(current) / float64(totalPosts) * 100
-				if w.enableEmbeddings {
-					log.Printf("Progress: %d/%d (%.1f%%) - %d new, %d updated, %d skipped, %d errors, %d embeddings\n",
-						current, totalPosts, percent, newPosts, updatedPosts, skippedPosts, errors, embGen)
-				} else {
-					log.Printf("Progress: %d/%d (%.1f%%) - %d new, %d updated, %d skipped, %d errors\n",
-						current, totalPosts, percent, newPosts, updatedPosts, skippedPosts, errors)
+		go func() {
+			defer close(stopped)
+			for {
+				select {
+				case <-done:
+					return
+				case <-progressTicker.C:
+				}
+				mu.Lock()
+				snapshot := *stats
+				mu.Unlock()
+				if snapshot.Processed > 0 && snapshot.Processed < totalPosts {
+					snapshot.Duration = time.Since(startTime)
+					w.progressFn(snapshot)
 				}
 			}
-		}
-	}()
+		}()
+	}
 
-	for range concurrency {
+	for range w.concurrency {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
 			for post := range postChan {
+				// Stop picking up posts once the sync is cancelled
+				if ctx.Err() != nil {
+					return
+				}
+
 				if err := w.syncPost(ctx, post, stats, &mu); err != nil {
-					log.Printf("Error syncing post %s (%s): %v\n", post.ID, post.Title, err)
+					slog.Error("Failed to sync post", "id", post.ID, "title", post.Title, "error", err)
 					mu.Lock()
 					stats.Errors++
+					if earliestFailed.IsZero() || post.UpdatedAt.Before(earliestFailed) {
+						earliestFailed = post.UpdatedAt
+					}
 					mu.Unlock()
 				}
 
 				// Update progress counter
 				mu.Lock()
-				processed++
+				stats.Processed++
 				mu.Unlock()
 			}
 		}()
 	}
 
 	wg.Wait()
+	stopProgress()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("sync cancelled after %d of %d posts: %w", stats.Processed, totalPosts, err)
+	}
 
 	// 4. Remove archived posts from search index
 	if len(archivedPostIDs) > 0 {
-		log.Printf("Removing %d archived posts from search index...\n", len(archivedPostIDs))
+		slog.Debug("Removing archived posts from search index", "posts", len(archivedPostIDs))
 		for _, postID := range archivedPostIDs {
 			if err := w.index.Delete(postID); err != nil {
-				log.Printf("Warning: Failed to remove archived post %s from search: %v\n", postID, err)
+				slog.Warn("Failed to remove archived post from search", "id", postID, "error", err)
 			} else {
 				stats.ArchivedRemoved++
 			}
 		}
-		log.Printf("Removed %d archived posts from search\n", stats.ArchivedRemoved)
+		slog.Info("Removed archived posts from search", "posts", stats.ArchivedRemoved)
+	}
+
+	// 5. Purge posts that were deleted in Slab (absent from the full post list)
+	if !partialList && w.topic == "" {
+		if err := w.purgeDeleted(allPostsSlice, stats); err != nil {
+			slog.Warn("Failed to purge deleted posts", "error", err)
+		}
+
+		// Later incremental syncs can start from here; topic syncs and
+		// partial lists leave other posts unchecked, so they don't count.
+		// Posts that failed keep their old UpdatedAt in the database, so the
+		// cutoff goes no later than the oldest of them for the next
+		// incremental sync to retry it.
+		syncedUpTo := startTime
+		if !earliestFailed.IsZero() && earliestFailed.Before(syncedUpTo) {
+			syncedUpTo = earliestFailed
+		}
+		if err := w.db.SetLastFullSync(syncedUpTo); err != nil {
+			slog.Warn("Failed to record the sync time", "error", err)
+		}
 	}
 
 	stats.Duration = time.Since(startTime)
-	if w.enableEmbeddings {
-		log.Printf("Sync complete: %d new, %d updated, %d skipped, %d archived removed, %d errors, %d embeddings generated (%d failed) in %v\n",
-			stats.NewPosts, stats.UpdatedPosts, stats.SkippedPosts, stats.ArchivedRemoved, stats.Errors, stats.EmbeddingsGen, stats.EmbeddingsFailed, stats.Duration)
-	} else {
-		log.Printf("Sync complete: %d new, %d updated, %d skipped, %d archived removed, %d errors in %v\n",
-			stats.NewPosts, stats.UpdatedPosts, stats.SkippedPosts, stats.ArchivedRemoved, stats.Errors, stats.Duration)
+	if w.progressFn != nil {
+		w.progressFn(*stats)
+	}
+
+	return stats, nil
+}
+
+// resolveTopic finds the topic SetTopic named, matching its ID exactly or
+// its name case-insensitively
+func (w *Worker) resolveTopic(ctx context.Context) (slab.Topic, error) {
+	topics, err := w.slabClient.GetTopics(ctx)
+	if err != nil && len(topics) == 0 {
+		return slab.Topic{}, fmt.Errorf("resolve topic %q: %w", w.topic, err)
+	}
+
+	var matches []slab.Topic
+	for _, t := range topics {
+		if t.ID == w.topic {
+			return t, nil
+		}
+		if strings.EqualFold(t.Name, w.topic) {
+			matches = append(matches, t)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return slab.Topic{}, fmt.Errorf("no topic with ID or name %q", w.topic)
+	case 1:
+		return matches[0], nil
+	default:
+		return slab.Topic{}, fmt.Errorf("%d topics are named %q; use the topic ID", len(matches), w.topic)
+	}
+}
+
+// purgeDeleted removes local documents whose posts no longer exist in Slab
+// remotePosts must be the complete post list (archived posts included)
+func (w *Worker) purgeDeleted(remotePosts []slab.SlimPost, stats *Stats) error {
+	// An empty list is far more likely an API hiccup than every post being deleted
+	if len(remotePosts) == 0 {
+		return nil
+	}
+
+	deletedIDs, err := w.deletedIDs(remotePosts)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range deletedIDs {
+		if err := w.db.Delete(id); err != nil {
+			slog.Warn("Failed to delete post from database", "id", id, "error", err)
+			continue
+		}
+		if err := w.index.Delete(id); err != nil {
+			slog.Warn("Failed to delete post from search", "id", id, "error", err)
+		}
+		stats.DeletedPosts++
+	}
+
+	if stats.DeletedPosts > 0 {
+		slog.Info("Purged posts deleted in Slab", "posts", stats.DeletedPosts)
+	}
+	return nil
+}
+
+// deletedIDs returns the local documents whose posts no longer exist in Slab
+// remotePosts must be the complete post list (archived posts included)
+func (w *Worker) deletedIDs(remotePosts []slab.SlimPost) ([]string, error) {
+	remoteIDs := make(map[string]bool, len(remotePosts))
+	for i := range remotePosts {
+		remoteIDs[remotePosts[i].ID] = true
+	}
+
+	localIDs, err := w.db.ListIDs(true)
+	if err != nil {
+		return nil, fmt.Errorf("list local documents: %w", err)
+	}
+
+	var deleted []string
+	for _, id := range localIDs {
+		if !remoteIDs[id] {
+			deleted = append(deleted, id)
+		}
 	}
+	return deleted, nil
+}
+
+// planSync is Sync's dry run: it counts what syncing posts would do and
+// which archived and deleted posts would be removed, without side effects
+func (w *Worker) planSync(posts map[string]*slab.SlimPost, remotePosts []slab.SlimPost, archivedPostIDs []string, stats *Stats, startTime time.Time) (*Stats, error) {
+	slog.Info("Dry run: comparing posts with the database (nothing will be fetched or written)")
+
+	for _, post := range posts {
+		action, err := w.planPost(post)
+		if err != nil {
+			slog.Error("Failed to check post", "id", post.ID, "title", post.Title, "error", err)
+			stats.Errors++
+			continue
+		}
+
+		switch action {
+		case actionNew:
+			stats.NewPosts++
+			slog.Info("Would add", "title", post.Title)
+		case actionUpdate:
+			stats.UpdatedPosts++
+			slog.Info("Would update", "title", post.Title)
+		default:
+			stats.SkippedPosts++
+		}
+	}
+
+	for _, postID := range archivedPostIDs {
+		updatedAt, err := w.db.GetUpdatedAt(postID)
+		if err != nil {
+			return nil, fmt.Errorf("get updated_at: %w", err)
+		}
+		if !updatedAt.IsZero() {
+			stats.ArchivedRemoved++
+		}
+	}
+
+	// Mirror purgeDeleted, which skips an empty post list
+	if len(remotePosts) > 0 {
+		deletedIDs, err := w.deletedIDs(remotePosts)
+		if err != nil {
+			return nil, err
+		}
+		stats.DeletedPosts = len(deletedIDs)
+	}
+
+	stats.Duration = time.Since(startTime)
+	slog.Info("Dry run complete", "new", stats.NewPosts, "updated", stats.UpdatedPosts, "skipped", stats.SkippedPosts,
+		"archived_to_remove", stats.ArchivedRemoved, "deleted", stats.DeletedPosts, "errors", stats.Errors, "duration", stats.Duration)
 
 	return stats, nil
 }
 
+// postAction is what syncing a post does
+type postAction int
+
+const (
+	actionSkip   postAction = iota // Unchanged since the last sync
+	actionNew                      // Not in the database yet
+	actionUpdate                   // Changed since the last sync
+)
+
+// planPost decides what syncing a post would do by comparing its UpdatedAt
+// with the stored copy's, which avoids downloading markdown for unchanged
+// posts. It has no side effects, so dry runs share it with syncPost.
+func (w *Worker) planPost(slimPost *slab.SlimPost) (postAction, error) {
+	existingUpdatedAt, err := w.db.GetUpdatedAt(slimPost.ID)
+	if err != nil {
+		return actionSkip, fmt.Errorf("get updated_at: %w", err)
+	}
+
+	switch {
+	case existingUpdatedAt.IsZero():
+		return actionNew, nil
+	case existingUpdatedAt.Equal(slimPost.UpdatedAt):
+		return actionSkip, nil
+	default:
+		return actionUpdate, nil
+	}
+}
+
 // syncPost syncs a single post
 func (w *Worker) syncPost(ctx context.Context, slimPost *slab.SlimPost, stats *Stats, mu *sync.Mutex) error {
-	// 1. Check if post has been updated since last sync (optimization to avoid downloading markdown)
-	existingUpdatedAt, err := w.db.GetUpdatedAt(slimPost.ID)
+	// 1. Check if post has been updated since last sync
+	action, err := w.planPost(slimPost)
 	if err != nil {
-		return fmt.Errorf("get updated_at: %w", err)
+		return err
 	}
 
 	// If the post exists and hasn't been updated, skip it entirely
-	if !existingUpdatedAt.IsZero() && existingUpdatedAt.Equal(slimPost.UpdatedAt) {
+	if action == actionSkip {
+		if w.withComments {
+			return w.syncComments(ctx, slimPost, stats, mu)
+		}
 		mu.Lock()
 		stats.SkippedPosts++
 		mu.Unlock()
@@ -198,12 +531,15 @@ func (w *Worker) syncPost(ctx context.Context, slimPost *slab.SlimPost, stats *S
 	// 2. Post is new or has been updated - fetch markdown content
 	markdown, err := w.slabClient.GetMarkdown(ctx, slimPost.ID)
 	if err != nil {
+		w.recordFailure(slimPost, err)
 		return fmt.Errorf("get markdown: %w", err)
 	}
 
 	// 3. Fetch full post metadata (for author info)
 	post, err := w.slabClient.GetPost(ctx, slimPost.ID)
-	if err != nil {
+	if slab.IsPartial(err) {
+		slog.Warn("Partial response from Slab", "id", slimPost.ID, "error", err)
+	} else if err != nil {
 		return fmt.Errorf("get post metadata: %w", err)
 	}
 
@@ -215,15 +551,16 @@ func (w *Worker) syncPost(ctx context.Context, slimPost *slab.SlimPost, stats *S
 
 	// 5. Create document
 	doc := &storage.Document{
-		ID:          slimPost.ID,
-		Title:       slimPost.Title,
-		Content:     markdown,
-		SlabURL:     fmt.Sprintf("https://slab.render.com/posts/%s", slimPost.ID),
-		Topics:      string(topicsJSON),
-		PublishedAt: slimPost.PublishedAt,
-		UpdatedAt:   slimPost.UpdatedAt,
-		ArchivedAt:  slimPost.ArchivedAt,
-		SyncedAt:    time.Now(),
+		ID:            slimPost.ID,
+		Title:         slimPost.Title,
+		Content:       markdown,
+		ContentLength: utf8.RuneCountInString(markdown),
+		SlabURL:       w.slabClient.PostURL(slimPost.ID),
+		Topics:        string(topicsJSON),
+		PublishedAt:   slimPost.PublishedAt,
+		UpdatedAt:     slimPost.UpdatedAt,
+		ArchivedAt:    slimPost.ArchivedAt,
+		SyncedAt:      time.Now(),
 	}
 
 	if post.Owner != nil {
@@ -231,20 +568,44 @@ func (w *Worker) syncPost(ctx context.Context, slimPost *slab.SlimPost, stats *S
 		doc.AuthorEmail = post.Owner.Email
 	}
 
+	// Comments: fetch them if asked, otherwise keep any synced earlier
+	if w.withComments {
+		comments, err := w.slabClient.GetComments(ctx, slimPost.ID)
+		if slab.IsPartial(err) {
+			slog.Warn("Partial response from Slab", "id", slimPost.ID, "error", err)
+		} else if err != nil {
+			return fmt.Errorf("get comments: %w", err)
+		}
+		doc.Comments = formatComments(comments)
+	} else if action == actionUpdate {
+		if doc.Comments, err = w.db.GetComments(slimPost.ID); err != nil {
+			return fmt.Errorf("get stored comments: %w", err)
+		}
+	}
+
 	// 5.5. Generate embedding if enabled (optional - graceful degradation)
 	if w.enableEmbeddings {
 		// Combine title and content for embedding
 		textToEmbed := fmt.Sprintf("%s\n\n%s", slimPost.Title, markdown)
 
-		embedding, err := w.embedder.Embed(textToEmbed)
+		embedding, err := w.embedder.Embed(ctx, textToEmbed)
+		if err == nil {
+			// Store unit vectors so similarity is a plain dot product
+			if embedding = embeddings.NormalizeEmbedding(embedding); embedding == nil {
+				err = fmt.Errorf("provider returned an empty or all-zero embedding")
+			}
+		}
 		if err != nil {
-			log.Printf("Warning: Failed to generate embedding for %s: %v", slimPost.ID, err)
+			slog.Warn("Failed to generate embedding", "id", slimPost.ID, "error", err)
 			mu.Lock()
 			stats.EmbeddingsFailed++
 			mu.Unlock()
 			// Continue without embedding - graceful degradation
 		} else {
+			embeddedAt := time.Now()
 			doc.Embedding = embeddings.SerializeEmbedding(embedding)
+			doc.EmbeddingModel = w.embedder.Model()
+			doc.EmbeddedAt = &embeddedAt
 			mu.Lock()
 			stats.EmbeddingsGen++
 			mu.Unlock()
@@ -256,37 +617,112 @@ func (w *Worker) syncPost(ctx context.Context, slimPost *slab.SlimPost, stats *S
 		return fmt.Errorf("upsert document: %w", err)
 	}
 
-	// 7. Index in search
-	var topicNames []string
-	for _, t := range slimPost.Topics {
-		topicNames = append(topicNames, t.Name)
+	// Chunks embedded from the previous content are stale; search falls back
+	// to the document embedding until the embed command re-chunks it
+	if action == actionUpdate {
+		if err := w.db.DeleteChunks(doc.ID); err != nil {
+			slog.Warn("Failed to delete stale chunks", "id", doc.ID, "error", err)
+		}
 	}
 
-	indexDoc := &search.IndexedDocument{
-		ID:          doc.ID,
-		Title:       doc.Title,
-		Content:     doc.Content,
-		Author:      doc.AuthorName,
-		Topics:      topicNames,
-		PublishedAt: doc.PublishedAt,
-		UpdatedAt:   doc.UpdatedAt,
-		SlabURL:     doc.SlabURL,
+	// The post exported successfully, so forget any earlier failure
+	if err := w.db.ClearSyncFailure(slimPost.ID); err != nil {
+		slog.Warn("Failed to clear sync failure", "id", slimPost.ID, "error", err)
 	}
 
+	// 7. Index in search
+	indexDoc := search.NewIndexedDocument(doc)
+
 	if err := w.index.IndexDocument(indexDoc); err != nil {
 		return fmt.Errorf("index document: %w", err)
 	}
 
 	// 8. Update stats
 	mu.Lock()
-	if existingUpdatedAt.IsZero() {
+	if action == actionNew {
 		stats.NewPosts++
-		log.Printf("✓ New: %s\n", slimPost.Title)
+		slog.Info("Added post", "title", slimPost.Title)
 	} else {
 		stats.UpdatedPosts++
-		log.Printf("✓ Updated: %s\n", slimPost.Title)
+		slog.Info("Updated post", "title", slimPost.Title)
 	}
 	mu.Unlock()
 
 	return nil
 }
+
+// syncComments refreshes the comments of a post whose content is unchanged,
+// re-indexing it only if they differ from the stored ones
+func (w *Worker) syncComments(ctx context.Context, slimPost *slab.SlimPost, stats *Stats, mu *sync.Mutex) error {
+	comments, err := w.slabClient.GetComments(ctx, slimPost.ID)
+	if slab.IsPartial(err) {
+		slog.Warn("Partial response from Slab", "id", slimPost.ID, "error", err)
+	} else if err != nil {
+		return fmt.Errorf("get comments: %w", err)
+	}
+	formatted := formatComments(comments)
+
+	stored, err := w.db.GetComments(slimPost.ID)
+	if err != nil {
+		return fmt.Errorf("get stored comments: %w", err)
+	}
+	if formatted == stored {
+		mu.Lock()
+		stats.SkippedPosts++
+		mu.Unlock()
+		return nil
+	}
+
+	if err := w.db.SetComments(slimPost.ID, formatted); err != nil {
+		return fmt.Errorf("store comments: %w", err)
+	}
+	doc, err := w.db.Get(slimPost.ID)
+	if err != nil {
+		return fmt.Errorf("get document: %w", err)
+	}
+	if err := w.index.IndexDocument(search.NewIndexedDocument(doc)); err != nil {
+		return fmt.Errorf("index document: %w", err)
+	}
+
+	mu.Lock()
+	stats.CommentsUpdated++
+	slog.Info("Updated comments", "title", slimPost.Title)
+	mu.Unlock()
+	return nil
+}
+
+// formatComments joins comments into the searchable text stored with a
+// document: one "Author: text" paragraph per comment, empty ones skipped
+func formatComments(comments []slab.Comment) string {
+	var paragraphs []string
+	for _, c := range comments {
+		if c.Body == "" {
+			continue
+		}
+		if c.Author != nil && c.Author.Name != "" {
+			paragraphs = append(paragraphs, c.Author.Name+": "+c.Body)
+		} else {
+			paragraphs = append(paragraphs, c.Body)
+		}
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// recordFailure persists an export failure so persistently-failing posts
+// can be investigated with the failures command
+func (w *Worker) recordFailure(slimPost *slab.SlimPost, exportErr error) {
+	failure := &storage.SyncFailure{
+		PostID: slimPost.ID,
+		Title:  slimPost.Title,
+		Error:  exportErr.Error(),
+	}
+
+	var statusErr *slab.StatusError
+	if errors.As(exportErr, &statusErr) {
+		failure.StatusCode = statusErr.StatusCode
+	}
+
+	if err := w.db.RecordSyncFailure(failure); err != nil {
+		slog.Warn("Failed to record sync failure", "id", slimPost.ID, "error", err)
+	}
+}
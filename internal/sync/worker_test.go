@@ -0,0 +1,120 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/renderinc/slab-search/internal/search"
+	"github.com/renderinc/slab-search/internal/slab"
+	"github.com/renderinc/slab-search/internal/storage"
+)
+
+// fakeSlab serves posts over the GraphQL and markdown export endpoints the
+// worker uses, failing the markdown export of posts in failing
+type fakeSlab struct {
+	posts   []slab.SlimPost
+	failing map[string]*atomic.Bool
+}
+
+func (f *fakeSlab) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if id, ok := strings.CutPrefix(r.URL.Path, "/posts/"); ok {
+		id = strings.TrimSuffix(id, "/export/markdown")
+		if fail := f.failing[id]; fail != nil && fail.Load() {
+			http.Error(w, "export failed", http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, "# Post %s\n\nSome content.", id)
+		return
+	}
+
+	var req struct {
+		Variables map[string]any `json:"variables"`
+	}
+	body, _ := io.ReadAll(r.Body)
+	json.Unmarshal(body, &req)
+	if id, ok := req.Variables["id"].(string); ok && strings.Contains(string(body), "GetPost") {
+		for _, post := range f.posts {
+			if post.ID == id {
+				json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"post": post}})
+				return
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"post": nil}})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+		"currentSession": map[string]any{"organization": map[string]any{"posts": f.posts}},
+	}})
+}
+
+// newTestWorker creates a worker syncing from srv into db and a new index
+func newTestWorker(t *testing.T, srv *httptest.Server, db storage.Store) *Worker {
+	t.Helper()
+	idx, err := search.Open(filepath.Join(t.TempDir(), "index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { idx.Close() })
+	idx.SetDB(db)
+
+	w := NewWorker(slab.NewClient("token", slab.WithBaseURL(srv.URL)), db, idx, nil, 0)
+	w.SetConcurrency(1)
+	return w
+}
+
+func TestIncrementalSyncRetriesFailedPosts(t *testing.T) {
+	day := func(n int) time.Time { return time.Date(2026, time.March, n, 0, 0, 0, 0, time.UTC) }
+	fake := &fakeSlab{
+		posts: []slab.SlimPost{
+			{ID: "ok", Title: "Synced", PublishedAt: day(1), UpdatedAt: day(1)},
+			{ID: "flaky", Title: "Failed once", PublishedAt: day(1), UpdatedAt: day(2)},
+		},
+		failing: map[string]*atomic.Bool{"flaky": new(atomic.Bool)},
+	}
+	fake.failing["flaky"].Store(true)
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	db := storage.NewMemStore()
+	ctx := context.Background()
+
+	stats, err := newTestWorker(t, srv, db).Sync(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Errors != 1 || stats.NewPosts != 1 {
+		t.Fatalf("first sync: %d new, %d errors, want 1 and 1", stats.NewPosts, stats.Errors)
+	}
+	lastSync, err := db.LastFullSync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastSync.After(day(2)) {
+		t.Errorf("last full sync recorded as %v, after the failed post's update at %v", lastSync, day(2))
+	}
+
+	// An incremental sync from the recorded time, as sync -since=1h runs it
+	fake.failing["flaky"].Store(false)
+	w := newTestWorker(t, srv, db)
+	w.SetUpdatedSince(lastSync.Add(-time.Hour))
+	if stats, err = w.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Errors != 0 || stats.NewPosts != 1 {
+		t.Errorf("incremental sync: %d new, %d errors, want the failed post synced", stats.NewPosts, stats.Errors)
+	}
+	if _, err := db.Get("flaky"); err != nil {
+		t.Errorf("failed post still missing after the incremental sync: %v", err)
+	}
+	if lastSync, err = db.LastFullSync(); err != nil || lastSync.Before(day(3)) {
+		t.Errorf("last full sync = %v, %v, want it moved on once nothing failed", lastSync, err)
+	}
+}
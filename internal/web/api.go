@@ -0,0 +1,406 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/renderinc/slab-search/internal/search"
+)
+
+const (
+	// defaultLimit and maxLimit bound the number of results per page
+	defaultLimit = 20
+	maxLimit     = 100
+
+	// defaultHybridWeight is the semantic weight for hybrid mode
+	defaultHybridWeight = 0.3
+
+	// maxExcerptLen caps excerpt_len, about a long document's worth
+	maxExcerptLen = 20000
+
+	// maxRequestBody caps the size of a JSON search request
+	maxRequestBody = 64 * 1024
+
+	// searchTimeout bounds how long one search may run, so slow semantic
+	// scans can't pile up under load
+	searchTimeout = 30 * time.Second
+)
+
+// errEmbeddingsUnavailable is returned for semantic and hybrid searches when
+// the server was started without an embedding provider
+var errEmbeddingsUnavailable = errors.New("embedding provider not running")
+
+// runSearch runs a query in the given mode ("keyword", "semantic" or "hybrid")
+// hybridWeight is the semantic weight; hybridMethod "rrf" merges by rank instead
+// model picks the embeddings semantic and hybrid searches compare against
+// (see resolveModel). The search is abandoned when ctx (the request's, so a
+// client disconnect cancels it) is done or after searchTimeout.
+func (s *Server) runSearch(ctx context.Context, query, mode string, hybridWeight float64, hybridMethod, model string, opts search.SearchOptions) (*search.SearchResults, error) {
+	if mode != "semantic" && mode != "hybrid" {
+		mode = "keyword"
+	}
+	s.countSearch(mode)
+
+	ctx, cancel := context.WithTimeout(ctx, searchTimeout)
+	defer cancel()
+
+	if mode == "keyword" {
+		return s.keywordSearch(ctx, query, opts)
+	}
+
+	// Semantic and hybrid share one embedding per query (see embedQuery), so
+	// switching between them doesn't re-embed it
+	queryEmbedding, err := s.embedQuery(ctx, query, model)
+	if err != nil {
+		return nil, err
+	}
+
+	useQwen := model == modelQwen
+	switch {
+	case mode == "semantic":
+		return s.idx.SemanticSearch(ctx, query, queryEmbedding, useQwen, opts)
+	case hybridMethod == "rrf":
+		return s.idx.HybridSearchRRF(ctx, query, queryEmbedding, useQwen, opts)
+	default:
+		return s.idx.HybridSearch(ctx, query, queryEmbedding, 1-hybridWeight, useQwen, opts)
+	}
+}
+
+// modeLabel capitalizes a search mode for messages
+func modeLabel(mode string) string {
+	switch mode {
+	case "semantic":
+		return "Semantic"
+	case "hybrid":
+		return "Hybrid"
+	default:
+		return "Keyword"
+	}
+}
+
+// handleAPISearch serves /api/v1/search, taking a JSON SearchRequest body and
+// returning a JSON SearchResponse
+func (s *Server) handleAPISearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeSearchError(w, http.StatusMethodNotAllowed, "use POST with a JSON body")
+		return
+	}
+
+	var req SearchRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeSearchError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+
+	if err := normalizeSearchRequest(&req); err != nil {
+		writeSearchError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	opts := search.SearchOptions{
+		Limit:    req.Limit,
+		Offset:   req.Offset,
+		Filter:   &search.Filter{Author: req.Author, AuthorFuzziness: req.AuthorFuzziness, Topics: req.Topics},
+		Sort:     req.Sort,
+		RawQuery: true,
+		Field:    req.Field,
+
+		HideDuplicates: req.HideDuplicates,
+		Explain:        req.Explain,
+	}
+
+	// An unavailable qwen falls back to nomic; the response's model says which ran
+	model, _ := s.resolveModel(req.Model)
+
+	results, err := s.runSearch(r.Context(), req.Query, req.Mode, *req.HybridWeight, req.HybridMethod, model, opts)
+	if errors.Is(err, errEmbeddingsUnavailable) {
+		writeSearchError(w, http.StatusServiceUnavailable,
+			fmt.Sprintf("%s search not available: %v", req.Mode, err))
+		return
+	}
+	if errors.Is(err, search.ErrDimensionMismatch) {
+		writeSearchError(w, http.StatusServiceUnavailable,
+			fmt.Sprintf("%s search not available, re-embed the corpus: %v", req.Mode, err))
+		return
+	}
+	if errors.Is(err, search.ErrQuerySyntax) {
+		writeSearchError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, search.ErrSearchTimeout) {
+		writeSearchError(w, http.StatusGatewayTimeout, err.Error())
+		return
+	}
+	if err != nil {
+		slog.Error("API search failed", "query", req.Query, "error", err)
+		writeSearchError(w, http.StatusInternalServerError, fmt.Sprintf("search failed: %v", err))
+		return
+	}
+
+	hits := results.Hits
+	if hits == nil {
+		hits = []*search.SearchResult{} // Encode as [] rather than null
+	}
+	if req.ExcerptLen > 0 && len(hits) > 0 {
+		hits, err = s.withExcerpts(hits, req.ExcerptLen)
+		if err != nil {
+			slog.Error("Failed to load excerpts", "query", req.Query, "error", err)
+			writeSearchError(w, http.StatusInternalServerError, fmt.Sprintf("loading excerpts failed: %v", err))
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, SearchResponse{
+		Results:     hits,
+		Query:       req.Query,
+		Mode:        req.Mode,
+		Model:       responseModel(req.Mode, model),
+		Count:       len(hits),
+		TotalHits:   results.TotalHits,
+		TotalCapped: results.TotalCapped,
+	})
+}
+
+// withExcerpts returns copies of hits carrying the first n characters of each
+// document's content, loaded for just these hits
+// The hits themselves are left alone, as they may be shared with the result
+// cache.
+func (s *Server) withExcerpts(hits []*search.SearchResult, n int) ([]*search.SearchResult, error) {
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	docs, err := s.db.GetMany(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	withExcerpts := make([]*search.SearchResult, len(hits))
+	for i, hit := range hits {
+		copied := *hit
+		if doc, ok := docs[hit.ID]; ok {
+			copied.Excerpt = contentExcerpt(doc.Content, n)
+		}
+		withExcerpts[i] = &copied
+	}
+	return withExcerpts, nil
+}
+
+// contentExcerpt returns the first n characters of content, cut back to a
+// word boundary and marked with "…" when content is longer
+// Unlike contentPreview the text is left as is (markdown, line breaks and
+// all) for clients to render.
+func contentExcerpt(content string, n int) string {
+	runes := []rune(content)
+	if len(runes) <= n {
+		return content
+	}
+	excerpt := string(runes[:n])
+	if cut := strings.LastIndexAny(excerpt, " \n\t"); cut > len(excerpt)/2 {
+		excerpt = excerpt[:cut]
+	}
+	return strings.TrimRight(excerpt, " \n\t") + "…"
+}
+
+// TopicResponse is a page of documents in a topic from /api/topic
+type TopicResponse struct {
+	Topic     string                  `json:"topic"`
+	Documents []*search.TopicDocument `json:"documents"`
+	Count     int                     `json:"count"` // Documents in this response
+	Total     uint64                  `json:"total"` // Documents in the topic
+	Offset    int                     `json:"offset"`
+	Error     string                  `json:"error,omitempty"`
+}
+
+// handleTopic serves /api/topic, listing the documents tagged with a topic
+// (exact name) as JSON, most recently updated first
+// Query parameters: name (required), limit (default 20, max 100), offset
+func (s *Server) handleTopic(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	name := params.Get("name")
+	if name == "" {
+		writeJSON(w, http.StatusBadRequest, TopicResponse{Documents: []*search.TopicDocument{}, Error: "name is required"})
+		return
+	}
+
+	limit := defaultLimit
+	if limitStr := params.Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 || l > maxLimit {
+			writeJSON(w, http.StatusBadRequest, TopicResponse{Topic: name, Documents: []*search.TopicDocument{},
+				Error: fmt.Sprintf("limit must be between 1 and %d", maxLimit)})
+			return
+		}
+		limit = l
+	}
+	offset := 0
+	if offsetStr := params.Get("offset"); offsetStr != "" {
+		o, err := strconv.Atoi(offsetStr)
+		if err != nil || o < 0 {
+			writeJSON(w, http.StatusBadRequest, TopicResponse{Topic: name, Documents: []*search.TopicDocument{},
+				Error: "offset must be a non-negative integer"})
+			return
+		}
+		offset = o
+	}
+
+	docs, total, err := s.idx.ListTopic(name, limit, offset)
+	if err != nil {
+		slog.Error("Failed to list topic", "topic", name, "error", err)
+		writeJSON(w, http.StatusInternalServerError, TopicResponse{Topic: name, Documents: []*search.TopicDocument{},
+			Error: fmt.Sprintf("listing topic failed: %v", err)})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, TopicResponse{
+		Topic:     name,
+		Documents: docs,
+		Count:     len(docs),
+		Total:     total,
+		Offset:    offset,
+	})
+}
+
+// defaultRelated is the number of related documents /api/related returns
+const defaultRelated = 5
+
+// RelatedResponse lists the documents most similar to one, from /api/related
+type RelatedResponse struct {
+	ID      string                 `json:"id"`
+	Results []*search.SearchResult `json:"results"`
+	Count   int                    `json:"count"`
+	Error   string                 `json:"error,omitempty"`
+}
+
+// handleRelated serves /api/related, returning the documents most similar to
+// the one with the given id (by their stored embeddings) as JSON
+func (s *Server) handleRelated(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	id := params.Get("id")
+	if id == "" {
+		writeJSON(w, http.StatusBadRequest, RelatedResponse{Results: []*search.SearchResult{}, Error: "id is required"})
+		return
+	}
+
+	limit := defaultRelated
+	if limitStr := params.Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 || l > maxLimit {
+			writeJSON(w, http.StatusBadRequest, RelatedResponse{ID: id, Results: []*search.SearchResult{},
+				Error: fmt.Sprintf("limit must be between 1 and %d", maxLimit)})
+			return
+		}
+		limit = l
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), searchTimeout)
+	defer cancel()
+
+	related, err := s.idx.RelatedDocuments(ctx, id, limit)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, search.ErrDocumentNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, search.ErrNoEmbedding):
+			status = http.StatusUnprocessableEntity
+		case errors.Is(err, search.ErrSearchTimeout):
+			status = http.StatusGatewayTimeout
+		default:
+			slog.Error("Failed to find related documents", "id", id, "error", err)
+		}
+		writeJSON(w, status, RelatedResponse{ID: id, Results: []*search.SearchResult{}, Error: err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, RelatedResponse{ID: id, Results: related, Count: len(related)})
+}
+
+// normalizeSearchRequest fills in defaults and rejects invalid fields
+func normalizeSearchRequest(req *SearchRequest) error {
+	if req.Query == "" {
+		return errors.New("query is required")
+	}
+
+	switch req.Mode {
+	case "":
+		req.Mode = "keyword"
+	case "keyword", "semantic", "hybrid":
+	default:
+		return fmt.Errorf("unknown mode %q (want keyword, semantic or hybrid)", req.Mode)
+	}
+
+	switch req.HybridMethod {
+	case "":
+		req.HybridMethod = "linear"
+	case "linear", "rrf":
+	default:
+		return fmt.Errorf("unknown hybrid_method %q (want linear or rrf)", req.HybridMethod)
+	}
+
+	switch req.Model {
+	case "", modelNomic, modelQwen:
+	default:
+		return fmt.Errorf("unknown model %q (want nomic or qwen)", req.Model)
+	}
+
+	switch req.Sort {
+	case "":
+		req.Sort = search.SortRelevance
+	case search.SortRelevance, search.SortUpdated, search.SortPublished:
+	default:
+		return fmt.Errorf("unknown sort %q (want relevance, updated or published)", req.Sort)
+	}
+
+	if req.HybridWeight == nil {
+		weight := defaultHybridWeight
+		req.HybridWeight = &weight
+	} else if *req.HybridWeight < 0 || *req.HybridWeight > 1 {
+		return fmt.Errorf("hybrid_weight must be between 0 and 1, got %g", *req.HybridWeight)
+	}
+
+	if err := search.CheckField(req.Field); err != nil {
+		return err
+	}
+
+	if req.AuthorFuzziness < 0 || req.AuthorFuzziness > search.MaxAuthorFuzziness {
+		return fmt.Errorf("author_fuzziness must be between 0 and %d, got %d", search.MaxAuthorFuzziness, req.AuthorFuzziness)
+	}
+
+	if req.Limit == 0 {
+		req.Limit = defaultLimit
+	} else if req.Limit < 0 || req.Limit > maxLimit {
+		return fmt.Errorf("limit must be between 1 and %d, got %d", maxLimit, req.Limit)
+	}
+	if req.Offset < 0 {
+		return fmt.Errorf("offset must not be negative, got %d", req.Offset)
+	}
+	if req.ExcerptLen < 0 || req.ExcerptLen > maxExcerptLen {
+		return fmt.Errorf("excerpt_len must be between 0 and %d, got %d", maxExcerptLen, req.ExcerptLen)
+	}
+
+	return nil
+}
+
+// writeSearchError sends a SearchResponse carrying only an error message
+func writeSearchError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, SearchResponse{Results: []*search.SearchResult{}, Error: msg})
+}
+
+// writeJSON encodes v as the response body with the given status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to encode JSON response", "error", err)
+	}
+}
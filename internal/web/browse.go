@@ -0,0 +1,85 @@
+package web
+
+import (
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/renderinc/slab-search/internal/search"
+)
+
+// browsePreviewLength is the number of characters of content shown on a
+// browse card, about the size of a search snippet
+const browsePreviewLength = 200
+
+// handleBrowse serves /api/browse, a page of every document, most recently
+// updated first, as the same result cards as /api/search
+// Takes page (or offset) and limit like /api/search.
+func (s *Server) handleBrowse(w http.ResponseWriter, r *http.Request) {
+	sp := parseSearchParams(r.URL.Query())
+	offset, limit := sp.opts.Offset, sp.opts.Limit
+
+	w.Header().Set("Content-Type", "text/html")
+
+	total, err := s.db.Count()
+	if err != nil {
+		slog.Error("Failed to count documents", "error", err)
+		fmt.Fprintf(w, `<div class="error">
+			<strong>Error:</strong> Browsing failed: %s
+		</div>`, template.HTMLEscapeString(err.Error()))
+		return
+	}
+	docs, err := s.db.ListPage(limit, offset)
+	if err != nil {
+		slog.Error("Failed to list documents", "offset", offset, "error", err)
+		fmt.Fprintf(w, `<div class="error">
+			<strong>Error:</strong> Browsing failed: %s
+		</div>`, template.HTMLEscapeString(err.Error()))
+		return
+	}
+
+	if len(docs) == 0 {
+		fmt.Fprint(w, `<div class="no-results">
+			<p>No documents to browse</p>
+			<p class="hint">Run <strong>slab-search sync</strong> to fetch documents from Slab</p>
+		</div>`)
+		return
+	}
+
+	fmt.Fprintf(w, `<div class="results-header">
+		<p>Browsing <strong>%d–%d</strong> of <strong>%d</strong> documents, most recently updated first</p>
+	</div>`, offset+1, offset+len(docs), total)
+
+	for i, doc := range docs {
+		result := &search.SearchResult{
+			ID:      doc.ID,
+			Title:   doc.Title,
+			Author:  doc.AuthorName,
+			SlabURL: doc.SlabURL,
+			Topics:  doc.TopicNames(),
+		}
+		note := ""
+		if !doc.UpdatedAt.IsZero() {
+			note = "Updated " + doc.UpdatedAt.Format("Jan 2, 2006")
+		}
+		renderResultCard(w, offset+i+1, result, contentPreview(doc.Content), note)
+	}
+
+	renderPagination(w, "/api/browse", r.URL.Query(), offset, limit, uint64(total))
+}
+
+// contentPreview returns the start of a document's content as HTML, with
+// whitespace collapsed and cut at a word boundary
+func contentPreview(content string) string {
+	preview := strings.Join(strings.Fields(content), " ")
+	if runes := []rune(preview); len(runes) > browsePreviewLength {
+		preview = string(runes[:browsePreviewLength])
+		if cut := strings.LastIndex(preview, " "); cut > 0 {
+			preview = preview[:cut]
+		}
+		preview += "…"
+	}
+	return template.HTMLEscapeString(preview)
+}
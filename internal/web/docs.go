@@ -0,0 +1,158 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/renderinc/slab-search/internal/search"
+	"github.com/renderinc/slab-search/internal/storage"
+)
+
+// DocumentResponse is one document in a /api/docs response
+type DocumentResponse struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Author      string     `json:"author"`
+	SlabURL     string     `json:"slab_url"`
+	Topics      []string   `json:"topics"`
+	PublishedAt time.Time  `json:"published_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+	Content     string     `json:"content"`
+}
+
+// DocsResponse is the body of /api/docs
+type DocsResponse struct {
+	Documents []*DocumentResponse `json:"documents"` // In the order requested
+	Missing   []string            `json:"missing"`   // Requested IDs with no document
+	Count     int                 `json:"count"`
+	Error     string              `json:"error,omitempty"`
+}
+
+func newDocumentResponse(doc *storage.Document) *DocumentResponse {
+	topics := doc.TopicNames()
+	if topics == nil {
+		topics = []string{}
+	}
+	return &DocumentResponse{
+		ID:          doc.ID,
+		Title:       doc.Title,
+		Author:      doc.AuthorName,
+		SlabURL:     doc.SlabURL,
+		Topics:      topics,
+		PublishedAt: doc.PublishedAt,
+		UpdatedAt:   doc.UpdatedAt,
+		ArchivedAt:  doc.ArchivedAt,
+		Content:     doc.Content,
+	}
+}
+
+// splitIDs parses a comma-separated ID list, dropping blanks and duplicates
+func splitIDs(list string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, id := range strings.Split(list, ",") {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// handleGetDocs serves /api/docs, returning the documents with the given
+// comma-separated ids (at most maxLimit) as JSON in one response
+// Unknown IDs are listed under "missing" rather than failing the request.
+func (s *Server) handleGetDocs(w http.ResponseWriter, r *http.Request) {
+	ids := splitIDs(r.URL.Query().Get("ids"))
+	empty := DocsResponse{Documents: []*DocumentResponse{}, Missing: []string{}}
+	if len(ids) == 0 {
+		empty.Error = "ids is required"
+		writeJSON(w, http.StatusBadRequest, empty)
+		return
+	}
+	if len(ids) > maxLimit {
+		empty.Error = fmt.Sprintf("at most %d ids per request", maxLimit)
+		writeJSON(w, http.StatusBadRequest, empty)
+		return
+	}
+
+	docs, err := s.db.GetMany(ids)
+	if err != nil {
+		slog.Error("Failed to get documents", "ids", len(ids), "error", err)
+		empty.Error = fmt.Sprintf("retrieving documents failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, empty)
+		return
+	}
+
+	resp := empty
+	for _, id := range ids {
+		if doc, ok := docs[id]; ok {
+			resp.Documents = append(resp.Documents, newDocumentResponse(doc))
+		} else {
+			resp.Missing = append(resp.Missing, id)
+		}
+	}
+	resp.Count = len(resp.Documents)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// DocSearchResponse is the body of /api/doc-search
+type DocSearchResponse struct {
+	ID       string            `json:"id"`
+	Title    string            `json:"title"`
+	Query    string            `json:"query"`
+	Passages []*search.Passage `json:"passages"` // In document order
+	Count    int               `json:"count"`    // Passages in this response
+	Total    int               `json:"total"`    // Matching lines in the document
+	Error    string            `json:"error,omitempty"`
+}
+
+// handleDocSearch serves /api/doc-search, finding the lines of one document
+// that mention q, with their line numbers and byte offsets to jump to
+func (s *Server) handleDocSearch(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	resp := DocSearchResponse{ID: params.Get("id"), Query: params.Get("q"), Passages: []*search.Passage{}}
+	if resp.ID == "" || resp.Query == "" {
+		resp.Error = "id and q are required"
+		writeJSON(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	limit := defaultLimit
+	if limitStr := params.Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 || l > maxLimit {
+			resp.Error = fmt.Sprintf("limit must be between 1 and %d", maxLimit)
+			writeJSON(w, http.StatusBadRequest, resp)
+			return
+		}
+		limit = l
+	}
+
+	doc, err := s.db.Get(resp.ID)
+	if errors.Is(err, storage.ErrNotFound) {
+		resp.Error = "document not found"
+		writeJSON(w, http.StatusNotFound, resp)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to get document", "id", resp.ID, "error", err)
+		resp.Error = fmt.Sprintf("retrieving document failed: %v", err)
+		writeJSON(w, http.StatusInternalServerError, resp)
+		return
+	}
+
+	resp.Title = doc.Title
+	if passages, total := search.FindPassages(doc.Content, resp.Query, limit); passages != nil {
+		resp.Passages, resp.Total = passages, total
+	}
+	resp.Count = len(resp.Passages)
+	writeJSON(w, http.StatusOK, resp)
+}
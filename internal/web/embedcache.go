@@ -0,0 +1,133 @@
+package web
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultEmbeddingCacheSize is the number of query embeddings the server
+// keeps by default (see SetEmbeddingCacheSize)
+const DefaultEmbeddingCacheSize = 256
+
+// embeddingCache is an LRU cache of query embeddings, so refining or paging
+// through the same query doesn't re-embed it on every request
+type embeddingCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // Most recently used at the front
+	entries map[embeddingKey]*list.Element
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// embeddingKey identifies a cached embedding; vectors from different models
+// aren't interchangeable
+type embeddingKey struct {
+	model string
+	query string
+}
+
+type embeddingEntry struct {
+	key embeddingKey
+	vec []float32
+}
+
+// newEmbeddingCache creates a cache holding up to size embeddings
+func newEmbeddingCache(size int) *embeddingCache {
+	return &embeddingCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[embeddingKey]*list.Element, size),
+	}
+}
+
+// get returns the cached embedding for key and marks it recently used
+func (c *embeddingCache) get(key embeddingKey) ([]float32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, found := c.entries[key]
+	if !found {
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	c.order.MoveToFront(elem)
+	return elem.Value.(*embeddingEntry).vec, true
+}
+
+// add caches an embedding, evicting the least recently used one when full
+func (c *embeddingCache) add(key embeddingKey, vec []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, found := c.entries[key]; found {
+		elem.Value.(*embeddingEntry).vec = vec
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&embeddingEntry{key: key, vec: vec})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*embeddingEntry).key)
+	}
+}
+
+// len returns the number of cached embeddings
+func (c *embeddingCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// normalizeQuery trims and collapses whitespace, so queries that differ only
+// in spacing share a cache entry
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// SetEmbeddingCacheSize sets how many query embeddings are cached for
+// semantic and hybrid searches (DefaultEmbeddingCacheSize by default);
+// 0 disables the cache. Call before serving requests.
+func (s *Server) SetEmbeddingCacheSize(size int) {
+	if size <= 0 {
+		s.embedCache = nil
+		return
+	}
+	s.embedCache = newEmbeddingCache(size)
+}
+
+// embedQuery returns the embedding of a search query for model's vectors,
+// from the cache when the same query was embedded recently in any mode
+// Returns errEmbeddingsUnavailable if the server has no embedding provider.
+func (s *Server) embedQuery(ctx context.Context, query, model string) ([]float32, error) {
+	embedder := s.embedderFor(model)
+	if embedder == nil {
+		return nil, errEmbeddingsUnavailable
+	}
+
+	query = normalizeQuery(query)
+	var key embeddingKey
+	if s.embedCache != nil {
+		key = embeddingKey{model: embedder.Model(), query: query}
+		if vec, found := s.embedCache.get(key); found {
+			return vec, nil
+		}
+	}
+
+	vec, err := embedder.Embed(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("generate query embedding: %w", err)
+	}
+	if s.embedCache != nil {
+		s.embedCache.add(key, vec)
+	}
+	return vec, nil
+}
@@ -0,0 +1,81 @@
+package web
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/renderinc/slab-search/internal/search"
+)
+
+// exportFilename is the suggested name for downloaded CSV results
+const exportFilename = "slab-search-results.csv"
+
+// handleSearchExport runs a search like /api/search, with the same query
+// parameters, and returns the page of results as a CSV attachment with
+// rank,title,author,score,url rows
+func (s *Server) handleSearchExport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	if query == "" {
+		http.Error(w, "Missing q parameter", http.StatusBadRequest)
+		return
+	}
+
+	sp := parseSearchParams(r.URL.Query())
+	model, _ := s.resolveModel(sp.model)
+	results, err := s.runSearch(r.Context(), query, sp.mode, sp.hybridWeight, sp.hybridMethod, model, sp.opts)
+	if errors.Is(err, errEmbeddingsUnavailable) {
+		http.Error(w, fmt.Sprintf("%s search not available: %v", modeLabel(sp.mode), err), http.StatusServiceUnavailable)
+		return
+	}
+	if errors.Is(err, search.ErrDimensionMismatch) {
+		http.Error(w, fmt.Sprintf("%s search not available, re-embed the corpus: %v", modeLabel(sp.mode), err), http.StatusServiceUnavailable)
+		return
+	}
+	if errors.Is(err, search.ErrQuerySyntax) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, search.ErrSearchTimeout) {
+		http.Error(w, err.Error(), http.StatusGatewayTimeout)
+		return
+	}
+	if err != nil {
+		slog.Error("Export search failed", "query", query, "error", err)
+		http.Error(w, fmt.Sprintf("Search failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", exportFilename))
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"rank", "title", "author", "score", "url"})
+	for i, result := range results.Hits {
+		cw.Write([]string{
+			strconv.Itoa(sp.opts.Offset + i + 1),
+			csvText(result.Title),
+			csvText(result.Author),
+			strconv.FormatFloat(result.Score, 'f', 4, 64),
+			csvText(result.SlabURL),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		slog.Error("Failed to write CSV export", "error", err)
+	}
+}
+
+// csvText escapes a text cell that a spreadsheet would run as a formula
+// (starting with =, +, -, @, tab or carriage return) by prefixing a quote,
+// so a document titled "=HYPERLINK(...)" exports as text
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
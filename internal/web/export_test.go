@@ -0,0 +1,24 @@
+package web
+
+import "testing"
+
+func TestCSVTextEscapesFormulas(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"Postgres backups", "Postgres backups"},
+		{"=HYPERLINK(\"http://evil\")", "'=HYPERLINK(\"http://evil\")"},
+		{"+1 for the runbook", "'+1 for the runbook"},
+		{"-2 days", "'-2 days"},
+		{"@SUM(A1:A2)", "'@SUM(A1:A2)"},
+		{"\t=1", "'\t=1"},
+		{"Q&A: a=b", "Q&A: a=b"},
+	}
+	for _, tt := range tests {
+		if got := csvText(tt.value); got != tt.want {
+			t.Errorf("csvText(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package web
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// minGzipSize is the smallest response worth compressing; below it the gzip
+// header and CPU cost outweigh the savings
+const minGzipSize = 1024
+
+// gzipWriters reuses compressors across responses
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// gzipHandler compresses responses for clients that accept gzip
+// Small responses, responses the handler already encoded, and content types
+// that are compressed already (images, archives, fonts) are sent as is
+func gzipHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		// Byte ranges refer to the uncompressed content
+		if !acceptsGzip(r) || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip reports whether the request's Accept-Encoding allows gzip:
+// named with a nonzero q-value, or covered by "*" when gzip isn't named
+func acceptsGzip(r *http.Request) bool {
+	wildcard := false
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(enc, ";")
+		switch name = strings.TrimSpace(name); {
+		case strings.EqualFold(name, "gzip"):
+			return qValue(params) > 0
+		case name == "*":
+			wildcard = qValue(params) > 0
+		}
+	}
+	return wildcard
+}
+
+// qValue returns the q parameter's weight from an Accept-Encoding entry's
+// parameters (1 when absent; malformed weights count as 0)
+func qValue(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		key, value, _ := strings.Cut(param, "=")
+		if !strings.EqualFold(strings.TrimSpace(key), "q") {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || q < 0 || q > 1 {
+			return 0
+		}
+		return q
+	}
+	return 1
+}
+
+// gzipResponseWriter buffers the start of a response until it knows whether
+// compressing is worthwhile, then either streams through a gzip.Writer or
+// writes the body unchanged
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status  int          // Status passed to WriteHeader, sent once decided
+	buf     []byte       // Body held back until minGzipSize or close
+	gz      *gzip.Writer // Set once compressing
+	decided bool         // Headers have been sent
+}
+
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	if g.decided || g.status != 0 {
+		return
+	}
+	g.status = status
+	// Bodiless responses have nothing to compress
+	if status == http.StatusNoContent || status == http.StatusNotModified {
+		g.sendHeader()
+	}
+}
+
+func (g *gzipResponseWriter) Write(p []byte) (int, error) {
+	if g.decided {
+		if g.gz != nil {
+			return g.gz.Write(p)
+		}
+		return g.ResponseWriter.Write(p)
+	}
+
+	g.buf = append(g.buf, p...)
+	if len(g.buf) >= minGzipSize {
+		if err := g.decide(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// decide sends the headers, choosing gzip if the content type allows it,
+// and writes out the buffered body
+func (g *gzipResponseWriter) decide() error {
+	h := g.Header()
+	// Sniff from the uncompressed bytes, as net/http would otherwise sniff
+	// the gzip stream
+	if h.Get("Content-Type") == "" && len(g.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(g.buf))
+	}
+
+	if len(g.buf) < minGzipSize || h.Get("Content-Encoding") != "" || isCompressedType(h.Get("Content-Type")) {
+		g.sendHeader()
+	} else {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length") // The handler's length is of the uncompressed body
+		g.gz = gzipWriters.Get().(*gzip.Writer)
+		g.gz.Reset(g.ResponseWriter)
+		g.sendHeader()
+	}
+
+	buf := g.buf
+	g.buf = nil
+	_, err := g.Write(buf)
+	return err
+}
+
+// sendHeader sends the headers and status; the body can't be held back after this
+func (g *gzipResponseWriter) sendHeader() {
+	g.decided = true
+	if g.status != 0 {
+		g.ResponseWriter.WriteHeader(g.status)
+	}
+}
+
+// close writes out anything still buffered and finishes the gzip stream
+func (g *gzipResponseWriter) close() {
+	if !g.decided {
+		if g.status == 0 && len(g.buf) == 0 {
+			return // Handler wrote nothing; let net/http send its default response
+		}
+		g.decide()
+	}
+	if g.gz != nil {
+		g.gz.Close()
+		gzipWriters.Put(g.gz)
+		g.gz = nil
+	}
+}
+
+// Flush sends what has been written so far, compressing from here on if the
+// buffered body already qualifies
+func (g *gzipResponseWriter) Flush() {
+	if !g.decided {
+		g.decide()
+	}
+	if g.gz != nil {
+		g.gz.Flush()
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (g *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+// isCompressedType reports whether a content type is already compressed
+func isCompressedType(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	switch {
+	case strings.HasPrefix(mediaType, "image/") && mediaType != "image/svg+xml":
+		return true
+	case strings.HasPrefix(mediaType, "video/"), strings.HasPrefix(mediaType, "audio/"):
+		return true
+	case strings.HasPrefix(mediaType, "font/woff"):
+		return true
+	}
+	switch mediaType {
+	case "application/gzip", "application/x-gzip", "application/zip", "application/zstd", "application/pdf":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package web
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"deflate, GZIP", true},
+		{"gzip;q=0.5, br", true},
+		{"gzip; q=1.0", true},
+		{"gzip;q=0", false},
+		{"gzip; q=0.0", false},
+		{"gzip;q=0.000, deflate", false},
+		{"gzip;q=bogus", false},
+		{"br, deflate", false},
+		{"*", true},
+		{"*;q=0", false},
+		{"gzip;q=0, *", false}, // Named encodings override the wildcard
+		{"*;q=0, gzip;q=0.1", true},
+		{"identity;q=0", false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Encoding", tt.header)
+		if got := acceptsGzip(r); got != tt.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/renderinc/slab-search/internal/embeddings"
+)
+
+const (
+	// embedderHealthTimeout bounds how long /health waits on the model server
+	embedderHealthTimeout = 3 * time.Second
+
+	// embedderHealthTTL is how long an embedder check is reused, so frequent
+	// health polls don't hammer the model server
+	embedderHealthTTL = 15 * time.Second
+)
+
+// embedderHealth caches the result of the last embedder health check
+type embedderHealth struct {
+	mu      sync.Mutex // Held during a check, so concurrent polls share it
+	checked time.Time
+	err     error
+}
+
+// checkEmbedder reports whether the embedder is reachable and serves its
+// model, checking at most once per embedderHealthTTL
+func (s *Server) checkEmbedder() error {
+	h := &s.embedderHealth
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !h.checked.IsZero() && time.Since(h.checked) < embedderHealthTTL {
+		return h.err
+	}
+	h.err = healthWithTimeout(s.embedder, embedderHealthTimeout)
+	h.checked = time.Now()
+	return h.err
+}
+
+// healthWithTimeout runs the embedder's health check, giving up after
+// timeout; Health takes no context, so a hung check finishes in the
+// background
+func healthWithTimeout(e embeddings.Embedder, timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() { done <- e.Health() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("no response within %v", timeout)
+	}
+}
+
+// handleLive reports that the process is up and serving requests
+func (s *Server) handleLive(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReady reports whether the server can answer searches: 200 once the
+// database and index both respond, 503 with their errors otherwise. A down
+// embedder only degrades it, since keyword search still works.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	health := map[string]interface{}{
+		"status":               "ok",
+		"embeddings_available": false,
+	}
+	var errs []string
+
+	if dbCount, err := s.db.Count(); err != nil {
+		errs = append(errs, fmt.Sprintf("database: %v", err))
+	} else {
+		health["documents_in_db"] = dbCount
+	}
+	if indexCount, err := s.idx.Count(); err != nil {
+		errs = append(errs, fmt.Sprintf("search index: %v", err))
+	} else {
+		health["documents_in_index"] = indexCount
+	}
+
+	// Re-check the model server, which may have gone down since startup
+	if s.embedder != nil {
+		health["embedding_provider"] = embeddings.ProviderName(s.embedder)
+		health["embedding_model"] = s.embedder.Model()
+		if err := s.checkEmbedder(); err != nil {
+			health["status"] = "degraded"
+			health["embedding_error"] = err.Error()
+		} else {
+			health["embeddings_available"] = true
+		}
+	}
+
+	status := http.StatusOK
+	if len(errs) > 0 {
+		status = http.StatusServiceUnavailable
+		health["status"] = "unavailable"
+		health["errors"] = errs
+	}
+	writeJSON(w, status, health)
+}
@@ -0,0 +1,75 @@
+package web
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// logRequests logs each request with its status and duration once it has
+// been served: at info level, except static files and health probes, which
+// would drown out the rest and are logged at debug level
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+
+		level := slog.LevelInfo
+		if strings.HasPrefix(r.URL.Path, "/static/") || strings.HasPrefix(r.URL.Path, "/health") {
+			level = slog.LevelDebug
+		}
+		slog.Log(context.Background(), level, "Request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", sw.statusCode(),
+			"bytes", sw.bytes,
+			"duration", time.Since(start).Round(time.Microsecond),
+		)
+	})
+}
+
+// statusWriter records the status and body size a handler writes
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (s *statusWriter) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusWriter) Write(p []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(p)
+	s.bytes += n
+	return n, err
+}
+
+// statusCode is the status sent, 200 if the handler wrote nothing
+func (s *statusWriter) statusCode() int {
+	if s.status == 0 {
+		return http.StatusOK
+	}
+	return s.status
+}
+
+// Flush passes through so streamed responses aren't held back
+func (s *statusWriter) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (s *statusWriter) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
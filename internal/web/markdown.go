@@ -0,0 +1,26 @@
+package web
+
+import (
+	"bytes"
+	"html/template"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// markdown renders GitHub-flavored markdown (tables, task lists, autolinks)
+var markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// htmlPolicy strips scripts, event handlers and other unsafe markup from
+// rendered documents, since their content comes from Slab users
+var htmlPolicy = bluemonday.UGCPolicy()
+
+// renderMarkdown converts markdown to sanitized HTML
+func renderMarkdown(src string) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(src), &buf); err != nil {
+		return "", err
+	}
+	return template.HTML(htmlPolicy.SanitizeBytes(buf.Bytes())), nil
+}
@@ -0,0 +1,152 @@
+package web
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metrics holds the Prometheus collectors exported on /metrics
+type metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec   // Requests by route and status code
+	duration *prometheus.HistogramVec // Request latency by route and status code
+	searches *prometheus.CounterVec   // Searches by mode
+}
+
+// newMetrics registers the server's collectors, along with the standard Go
+// runtime and process collectors, on a fresh registry
+// Document counts and embedder availability are read at scrape time
+func newMetrics(s *Server) *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "slab_search_http_requests_total",
+			Help: "HTTP requests handled, by route and status code.",
+		}, []string{"route", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "slab_search_http_request_duration_seconds",
+			Help:    "HTTP request latency, by route and status code.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "code"}),
+		searches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "slab_search_searches_total",
+			Help: "Searches run, by mode (keyword, semantic or hybrid).",
+		}, []string{"mode"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.duration,
+		m.searches,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "slab_search_embeddings_available",
+			Help: "1 if an embedding provider is configured for semantic and hybrid search, else 0.",
+		}, func() float64 {
+			if s.embedder != nil {
+				return 1
+			}
+			return 0
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "slab_search_db_documents",
+			Help: "Documents stored in the database.",
+		}, func() float64 {
+			count, _ := s.db.Count()
+			return float64(count)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "slab_search_index_documents",
+			Help: "Documents in the keyword search index.",
+		}, func() float64 {
+			count, _ := s.idx.Count()
+			return float64(count)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "slab_search_query_embedding_cache_hits_total",
+			Help: "Semantic and hybrid searches that reused a cached query embedding.",
+		}, func() float64 {
+			if s.embedCache == nil {
+				return 0
+			}
+			return float64(s.embedCache.hits.Load())
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "slab_search_query_embedding_cache_misses_total",
+			Help: "Semantic and hybrid searches that had to embed the query.",
+		}, func() float64 {
+			if s.embedCache == nil {
+				return 0
+			}
+			return float64(s.embedCache.misses.Load())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "slab_search_query_embedding_cache_entries",
+			Help: "Query embeddings currently cached.",
+		}, func() float64 {
+			if s.embedCache == nil {
+				return 0
+			}
+			return float64(s.embedCache.len())
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "slab_search_result_cache_hits_total",
+			Help: "Keyword searches answered from the result cache.",
+		}, func() float64 {
+			if s.resultCache == nil {
+				return 0
+			}
+			return float64(s.resultCache.hits.Load())
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "slab_search_result_cache_misses_total",
+			Help: "Keyword searches that had to query the index.",
+		}, func() float64 {
+			if s.resultCache == nil {
+				return 0
+			}
+			return float64(s.resultCache.misses.Load())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "slab_search_result_cache_entries",
+			Help: "Keyword result pages currently cached.",
+		}, func() float64 {
+			if s.resultCache == nil {
+				return 0
+			}
+			return float64(s.resultCache.len())
+		}),
+	)
+
+	return m
+}
+
+// EnableMetrics exposes Prometheus metrics on /metrics and records request
+// counts and latencies for the API routes. Call before Handler.
+func (s *Server) EnableMetrics() {
+	if s.metrics == nil {
+		s.metrics = newMetrics(s)
+	}
+}
+
+// instrument records the status and duration of requests to a route when
+// metrics are enabled
+func (s *Server) instrument(route string, h http.HandlerFunc) http.Handler {
+	if s.metrics == nil {
+		return h
+	}
+	labels := prometheus.Labels{"route": route}
+	return promhttp.InstrumentHandlerDuration(s.metrics.duration.MustCurryWith(labels),
+		promhttp.InstrumentHandlerCounter(s.metrics.requests.MustCurryWith(labels), h))
+}
+
+// countSearch records a search in the given mode when metrics are enabled
+func (s *Server) countSearch(mode string) {
+	if s.metrics != nil {
+		s.metrics.searches.WithLabelValues(mode).Inc()
+	}
+}
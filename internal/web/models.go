@@ -0,0 +1,61 @@
+package web
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/renderinc/slab-search/internal/embeddings"
+)
+
+// Embedding models selectable per search with the model parameter
+const (
+	modelNomic = "nomic" // Default, the embedding column
+	modelQwen  = "qwen"  // The embedding_qwen column, when EnableQwen succeeded
+)
+
+// EnableQwen lets semantic and hybrid searches pick the qwen embeddings with
+// model=qwen, embedding queries with embedder
+// Returns an error, leaving qwen disabled, if no document has a qwen embedding.
+func (s *Server) EnableQwen(embedder embeddings.Embedder) error {
+	stats, err := s.db.EmbeddingStats()
+	if err != nil {
+		return fmt.Errorf("check qwen embeddings: %w", err)
+	}
+	for _, stat := range stats {
+		if stat.Field == "embedding_qwen" && stat.Count > 0 {
+			s.qwenEmbedder = embedder
+			return nil
+		}
+	}
+	return errors.New("no documents have qwen embeddings (run embed -model=qwen)")
+}
+
+// resolveModel maps a requested model to the one a search will use
+// Anything but "qwen" is nomic; qwen falls back to nomic, with fellBack set,
+// when it isn't enabled.
+func (s *Server) resolveModel(requested string) (model string, fellBack bool) {
+	if requested != modelQwen {
+		return modelNomic, false
+	}
+	if s.qwenEmbedder == nil {
+		return modelNomic, true
+	}
+	return modelQwen, false
+}
+
+// embedderFor returns the embedder for queries against a model's vectors
+func (s *Server) embedderFor(model string) embeddings.Embedder {
+	if model == modelQwen {
+		return s.qwenEmbedder
+	}
+	return s.embedder
+}
+
+// responseModel is the model to report for a search: the one used for
+// semantic and hybrid searches, none for keyword
+func responseModel(mode, model string) string {
+	if mode == "keyword" {
+		return ""
+	}
+	return model
+}
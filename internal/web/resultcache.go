@@ -0,0 +1,157 @@
+package web
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/renderinc/slab-search/internal/search"
+)
+
+const (
+	// DefaultResultCacheSize is the number of keyword result pages the server
+	// keeps by default (see SetResultCache)
+	DefaultResultCacheSize = 512
+
+	// DefaultResultCacheTTL is how long a cached result page is served
+	DefaultResultCacheTTL = 5 * time.Minute
+)
+
+// resultCache is an LRU cache of keyword search results with a TTL
+// Keyword results only change when the index does, so the whole cache is
+// dropped when the index generation moves on.
+type resultCache struct {
+	mu         sync.Mutex
+	size       int
+	ttl        time.Duration
+	generation uint64     // Index generation the entries were computed at
+	order      *list.List // Most recently used at the front
+	entries    map[string]*list.Element
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+type resultEntry struct {
+	key     string
+	results *search.SearchResults
+	expires time.Time
+}
+
+// newResultCache creates a cache holding up to size result pages for ttl each
+func newResultCache(size int, ttl time.Duration) *resultCache {
+	return &resultCache{
+		size:    size,
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+// resultKey identifies a keyword search: the query and every option that
+// changes its results
+func resultKey(query string, opts search.SearchOptions) string {
+	var filter search.Filter
+	if opts.Filter != nil {
+		filter = *opts.Filter
+	}
+	return fmt.Sprintf("%q|%d|%d|%s|%s|%t|%t|%t|%t|%+v",
+		normalizeQuery(query), opts.Limit, opts.Offset, opts.Sort, opts.Field, opts.RawQuery, opts.IncludeArchived, opts.HideDuplicates, opts.Explain, filter)
+}
+
+// get returns the cached results for key at the given index generation and
+// marks them recently used
+func (c *resultCache) get(key string, generation uint64) (*search.SearchResults, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.invalidate(generation)
+	elem, found := c.entries[key]
+	if !found || time.Now().After(elem.Value.(*resultEntry).expires) {
+		c.misses.Add(1)
+		return nil, false
+	}
+	c.hits.Add(1)
+	c.order.MoveToFront(elem)
+	return elem.Value.(*resultEntry).results, true
+}
+
+// add caches results computed at the given index generation, evicting the
+// least recently used entry when full
+func (c *resultCache) add(key string, generation uint64, results *search.SearchResults) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.invalidate(generation)
+	if generation != c.generation {
+		return // Computed before an index change that's already been seen
+	}
+
+	entry := &resultEntry{key: key, results: results, expires: time.Now().Add(c.ttl)}
+	if elem, found := c.entries[key]; found {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*resultEntry).key)
+	}
+}
+
+// invalidate drops every entry once the index has moved past the generation
+// they were computed at; callers hold mu
+func (c *resultCache) invalidate(generation uint64) {
+	if generation <= c.generation {
+		return
+	}
+	c.generation = generation
+	c.order.Init()
+	clear(c.entries)
+}
+
+// len returns the number of cached result pages
+func (c *resultCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// SetResultCache sets how many keyword result pages are cached and for how
+// long (DefaultResultCacheSize and DefaultResultCacheTTL by default); a size
+// or TTL of 0 disables the cache. Call before serving requests.
+func (s *Server) SetResultCache(size int, ttl time.Duration) {
+	if size <= 0 || ttl <= 0 {
+		s.resultCache = nil
+		return
+	}
+	s.resultCache = newResultCache(size, ttl)
+}
+
+// keywordSearch runs a keyword search, serving identical searches from the
+// result cache until the index changes or the entry expires
+// Callers must not modify the returned results.
+func (s *Server) keywordSearch(ctx context.Context, query string, opts search.SearchOptions) (*search.SearchResults, error) {
+	if s.resultCache == nil {
+		return s.idx.Search(ctx, query, opts)
+	}
+
+	key := resultKey(query, opts)
+	generation := s.idx.Generation()
+	if results, found := s.resultCache.get(key, generation); found {
+		return results, nil
+	}
+
+	results, err := s.idx.Search(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.resultCache.add(key, generation, results)
+	return results, nil
+}
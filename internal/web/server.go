@@ -3,12 +3,16 @@ package web
 import (
 	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/renderinc/slab-search/internal/embeddings"
 	"github.com/renderinc/slab-search/internal/search"
 	"github.com/renderinc/slab-search/internal/storage"
@@ -20,29 +24,64 @@ var templatesFS embed.FS
 //go:embed static/*
 var staticFS embed.FS
 
+const (
+	// docChunkSize is the write size used when streaming document content
+	docChunkSize = 32 * 1024
+
+	// maxTopics caps the number of topics shown in the topic sidebar
+	maxTopics = 50
+
+	// defaultSuggestions and maxSuggestions bound /api/suggest results
+	defaultSuggestions = 8
+	maxSuggestions     = 20
+)
+
 type Server struct {
-	db        *storage.DB
+	db        storage.Store
 	idx       *search.Index
-	embedder  *embeddings.Client
+	embedder  embeddings.Embedder
 	templates *template.Template
+	metrics   *metrics // nil unless EnableMetrics was called
+
+	embedCache  *embeddingCache // nil when disabled
+	resultCache *resultCache    // nil when disabled
+	sync        *syncState      // nil unless EnableSync was called
+
+	qwenEmbedder embeddings.Embedder // nil unless EnableQwen was called
+
+	embedderHealth embedderHealth // Last embedder check, for /health
 }
 
 type SearchRequest struct {
-	Query         string  `json:"query"`
-	Mode          string  `json:"mode"`           // "keyword", "semantic", "hybrid"
-	HybridWeight  float64 `json:"hybrid_weight"`  // 0.0-1.0 (semantic weight)
-	Limit         int     `json:"limit"`
+	Query           string   `json:"query"`
+	Mode            string   `json:"mode"`            // "keyword", "semantic", "hybrid"
+	HybridWeight    *float64 `json:"hybrid_weight"`   // 0.0-1.0 (semantic weight), default 0.3
+	HybridMethod    string   `json:"hybrid_method"`   // "linear" (default) or "rrf"
+	Model           string   `json:"model,omitempty"` // "nomic" (default) or "qwen", for semantic and hybrid
+	Limit           int      `json:"limit"`
+	Offset          int      `json:"offset"`
+	Author          string   `json:"author,omitempty"`
+	AuthorFuzziness int      `json:"author_fuzziness,omitempty"` // 0-2 typos per author word
+	Field           string   `json:"field,omitempty"`            // "title", "content", "comments" or "author" (default: all)
+	Topics          []string `json:"topics,omitempty"`
+	Sort            string   `json:"sort,omitempty"`            // "relevance" (default), "updated", "published"
+	ExcerptLen      int      `json:"excerpt_len,omitempty"`     // Include this many characters of each result's content (0: none)
+	HideDuplicates  bool     `json:"hide_duplicates,omitempty"` // Leave out documents marked by dedupe -mark
+	Explain         bool     `json:"explain,omitempty"`         // Include each result's scoring breakdown
 }
 
 type SearchResponse struct {
-	Results []*search.SearchResult `json:"results"`
-	Query   string                 `json:"query"`
-	Mode    string                 `json:"mode"`
-	Count   int                    `json:"count"`
-	Error   string                 `json:"error,omitempty"`
+	Results     []*search.SearchResult `json:"results"`
+	Query       string                 `json:"query"`
+	Mode        string                 `json:"mode"`
+	Model       string                 `json:"model,omitempty"`        // Embedding model used by semantic and hybrid searches
+	Count       int                    `json:"count"`                  // Results in this response
+	TotalHits   uint64                 `json:"total_hits"`             // Matches across all pages
+	TotalCapped bool                   `json:"total_capped,omitempty"` // total_hits only counts a pool of the best matches (hybrid, date sort), so more matched
+	Error       string                 `json:"error,omitempty"`
 }
 
-func NewServer(db *storage.DB, idx *search.Index, embedder *embeddings.Client) (*Server, error) {
+func NewServer(db storage.Store, idx *search.Index, embedder embeddings.Embedder) (*Server, error) {
 	// Parse templates
 	tmpl, err := template.ParseFS(templatesFS, "templates/*.html")
 	if err != nil {
@@ -53,10 +92,12 @@ func NewServer(db *storage.DB, idx *search.Index, embedder *embeddings.Client) (
 	idx.SetDB(db)
 
 	return &Server{
-		db:        db,
-		idx:       idx,
-		embedder:  embedder,
-		templates: tmpl,
+		db:          db,
+		idx:         idx,
+		embedder:    embedder,
+		templates:   tmpl,
+		embedCache:  newEmbeddingCache(DefaultEmbeddingCacheSize),
+		resultCache: newResultCache(DefaultResultCacheSize, DefaultResultCacheTTL),
 	}, nil
 }
 
@@ -68,11 +109,27 @@ func (s *Server) Handler() http.Handler {
 
 	// Routes
 	mux.HandleFunc("/", s.handleIndex)
-	mux.HandleFunc("/api/search", s.handleSearch)
-	mux.HandleFunc("/api/doc", s.handleGetDoc)
-	mux.HandleFunc("/health", s.handleHealth)
+	mux.Handle("/api/search", s.instrument("/api/search", s.handleSearch))
+	mux.Handle("/api/search/export", s.instrument("/api/search/export", s.handleSearchExport))
+	mux.Handle("/api/suggest", s.instrument("/api/suggest", s.handleSuggest))
+	mux.Handle("/api/browse", s.instrument("/api/browse", s.handleBrowse))
+	mux.Handle("/api/v1/search", s.instrument("/api/v1/search", s.handleAPISearch))
+	mux.Handle("/api/doc", s.instrument("/api/doc", s.handleGetDoc))
+	mux.Handle("/api/docs", s.instrument("/api/docs", s.handleGetDocs))
+	mux.Handle("/api/doc-search", s.instrument("/api/doc-search", s.handleDocSearch))
+	mux.Handle("/api/topic", s.instrument("/api/topic", s.handleTopic))
+	mux.Handle("/api/related", s.instrument("/api/related", s.handleRelated))
+	mux.Handle("/api/sync", s.instrument("/api/sync", s.handleSync))
+	mux.Handle("/api/sync/status", s.instrument("/api/sync/status", s.handleSyncStatus))
+	mux.HandleFunc("/health", s.handleReady) // Kept for existing probes
+	mux.HandleFunc("/health/live", s.handleLive)
+	mux.HandleFunc("/health/ready", s.handleReady)
+
+	if s.metrics != nil {
+		mux.Handle("/metrics", promhttp.HandlerFor(s.metrics.registry, promhttp.HandlerOpts{}))
+	}
 
-	return mux
+	return logRequests(gzipHandler(mux))
 }
 
 func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
@@ -81,12 +138,20 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Topic sidebar is best-effort; search still works without it
+	topics, err := s.idx.TopicCounts(maxTopics)
+	if err != nil {
+		slog.Error("Failed to load topic counts", "error", err)
+	}
+
 	data := map[string]interface{}{
 		"HasEmbeddings": s.embedder != nil,
+		"HasQwen":       s.qwenEmbedder != nil,
+		"Topics":        topics,
 	}
 
 	if err := s.templates.ExecuteTemplate(w, "index.html", data); err != nil {
-		log.Printf("Error rendering template: %v", err)
+		slog.Error("Failed to render template", "error", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 	}
 }
@@ -98,6 +163,7 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html")
 		fmt.Fprint(w, `<div class="empty-state">
 			<p>👆 Start typing to search through your Slab documents</p>
+			<p><button class="browse-button" hx-get="/api/browse" hx-target="#results">Browse all documents</button></p>
 			<div class="tips">
 				<h3>Search Tips:</h3>
 				<ul>
@@ -110,76 +176,34 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	mode := r.URL.Query().Get("mode")
-	if mode == "" {
-		mode = "keyword"
-	}
+	sp := parseSearchParams(r.URL.Query())
+	mode, offset, limit := sp.mode, sp.opts.Offset, sp.opts.Limit
 
-	limit := 20
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
-			limit = l
-		}
+	model, fellBack := s.resolveModel(sp.model)
+	results, err := s.runSearch(r.Context(), query, mode, sp.hybridWeight, sp.hybridMethod, model, sp.opts)
+	if errors.Is(err, errEmbeddingsUnavailable) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<div class="error">
+			<strong>Error:</strong> %s search not available (embedding provider not running)
+		</div>`, modeLabel(mode))
+		return
 	}
-
-	hybridWeight := 0.3 // Default semantic weight for hybrid mode
-	if weightStr := r.URL.Query().Get("weight"); weightStr != "" {
-		if w, err := strconv.ParseFloat(weightStr, 64); err == nil && w >= 0 && w <= 1 {
-			hybridWeight = w
-		}
+	if errors.Is(err, search.ErrDimensionMismatch) {
+		slog.Error("Query embedding doesn't match indexed embeddings", "query", query, "error", err)
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<div class="error">
+			<strong>Error:</strong> %s search unavailable: the query model doesn't match the indexed embeddings; re-embed the corpus (slab-search embed) with the server's model
+			<p class="hint">%s</p>
+		</div>`, modeLabel(mode), template.HTMLEscapeString(err.Error()))
+		return
 	}
-
-	var results []*search.SearchResult
-	var err error
-
-	switch mode {
-	case "semantic":
-		if s.embedder == nil {
-			w.Header().Set("Content-Type", "text/html")
-			fmt.Fprint(w, `<div class="error">
-				<strong>Error:</strong> Semantic search not available (Ollama not running)
-			</div>`)
-			return
-		}
-
-		queryEmbedding, err := s.embedder.Embed(query)
-		if err != nil {
-			w.Header().Set("Content-Type", "text/html")
-			fmt.Fprintf(w, `<div class="error">
-				<strong>Error:</strong> Failed to generate embedding: %v
-			</div>`, err)
-			return
-		}
-
-		// For web UI, default to nomic embeddings (useQwen = false)
-		results, err = s.idx.SemanticSearch(queryEmbedding, limit, false)
-
-	case "hybrid":
-		if s.embedder == nil {
-			w.Header().Set("Content-Type", "text/html")
-			fmt.Fprint(w, `<div class="error">
-				<strong>Error:</strong> Hybrid search not available (Ollama not running)
-			</div>`)
-			return
-		}
-
-		queryEmbedding, err := s.embedder.Embed(query)
-		if err != nil {
-			w.Header().Set("Content-Type", "text/html")
-			fmt.Fprintf(w, `<div class="error">
-				<strong>Error:</strong> Failed to generate embedding: %v
-			</div>`, err)
-			return
-		}
-
-		// hybridWeight is semantic weight, so keyword weight = 1 - hybridWeight
-		// For web UI, default to nomic embeddings (useQwen = false)
-		results, err = s.idx.HybridSearch(query, queryEmbedding, limit, 1-hybridWeight, false)
-
-	default: // keyword
-		results, err = s.idx.Search(query, limit)
+	if errors.Is(err, search.ErrQuerySyntax) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<div class="error">
+			<strong>Error:</strong> %s
+		</div>`, template.HTMLEscapeString(err.Error()))
+		return
 	}
-
 	if err != nil {
 		w.Header().Set("Content-Type", "text/html")
 		fmt.Fprintf(w, `<div class="error">
@@ -191,64 +215,250 @@ func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	// Render results as HTML
 	w.Header().Set("Content-Type", "text/html")
 
-	if len(results) == 0 {
+	if fellBack && mode != "keyword" {
+		fmt.Fprint(w, `<div class="notice">qwen embeddings aren't available on this server; showing nomic results</div>`)
+	}
+
+	if len(results.Hits) == 0 {
 		fmt.Fprintf(w, `<div class="no-results">
-			<p>No results found for "<strong>%s</strong>"</p>
+			<p>No results found for "<strong>%s</strong>"</p>`, template.HTMLEscapeString(query))
+
+		// Offer a respelled query; clicking it reruns the search in place
+		if suggestion, err := s.idx.Suggest(query); err != nil {
+			slog.Error("Failed to suggest a respelling", "query", query, "error", err)
+		} else if suggestion != "" {
+			fmt.Fprintf(w, `
+			<p class="suggestion">Did you mean: <a href="#" hx-get="/api/search?q=%s" hx-include="[name='mode'], [name='topic'], [name='model']" hx-target="#results"
+				hx-on::before-request="document.getElementById('searchInput').value = this.textContent">%s</a>?</p>`,
+				url.QueryEscape(suggestion), template.HTMLEscapeString(suggestion))
+		}
+
+		fmt.Fprint(w, `
 			<p class="hint">Try different keywords or use fuzzy search with ~ suffix</p>
-		</div>`, template.HTMLEscapeString(query))
+		</div>`)
 		return
 	}
 
-	// Results header
+	// Results header; semantic and hybrid name the embedding model
+	modeText := mode
+	if m := responseModel(mode, model); m != "" {
+		modeText += " (" + m + ")"
+	}
 	fmt.Fprintf(w, `<div class="results-header">
-		<p>Found <strong>%d</strong> results for "<strong>%s</strong>"</p>
-		<p class="search-mode-indicator">Mode: <strong>%s</strong></p>
-	</div>`, len(results), template.HTMLEscapeString(query), mode)
+		<p>Showing <strong>%d–%d</strong> of <strong>%s</strong> results for "<strong>%s</strong>"</p>
+		<p class="search-mode-indicator">Mode: <strong>%s</strong>
+			· <a href="/api/search/export?%s" class="open-link" download>Download CSV</a></p>
+	</div>`, offset+1, offset+len(results.Hits), results.TotalText(), template.HTMLEscapeString(query), modeText,
+		template.HTMLEscapeString(r.URL.Query().Encode()))
 
 	// Render each result
-	for i, result := range results {
+	for i, result := range results.Hits {
 		// Extract preview from fragments
 		preview := ""
 		if fragments, ok := result.Fragments["Content"]; ok && len(fragments) > 0 {
 			preview = fragments[0]
+		} else if fragments, ok := result.Fragments["Comments"]; ok && len(fragments) > 0 {
+			preview = fragments[0] // Matched only in the discussion
 		}
 
-		fmt.Fprintf(w, `<div class="result-card">
+		renderResultCard(w, offset+i+1, result, preview, fmt.Sprintf("Score: %.3f", result.Score))
+	}
+
+	renderPagination(w, "/api/search", r.URL.Query(), offset, limit, results.TotalHits)
+}
+
+// renderResultCard writes one result card: title link, author, topic tags,
+// preview (already HTML, e.g. highlighted fragments) and a footer note such
+// as the score
+func renderResultCard(w io.Writer, rank int, result *search.SearchResult, preview, note string) {
+	fmt.Fprintf(w, `<div class="result-card">
 			<div class="result-number">%d</div>
 			<div class="result-content">
 				<h3><a href="%s" target="_blank" rel="noopener">%s</a></h3>`,
-			i+1,
-			template.HTMLEscapeString(result.SlabURL),
-			template.HTMLEscapeString(result.Title))
+		rank,
+		template.HTMLEscapeString(result.SlabURL),
+		result.HighlightedTitle()) // Already escaped, with <mark> around matches
 
-		if result.Author != "" {
-			fmt.Fprintf(w, `<p class="result-meta">By %s</p>`, template.HTMLEscapeString(result.Author))
-		}
+	if result.Author != "" {
+		fmt.Fprintf(w, `<p class="result-meta">By %s</p>`, template.HTMLEscapeString(result.Author))
+	}
 
-		if preview != "" {
-			fmt.Fprintf(w, `<p class="result-preview">%s</p>`, template.HTML(preview))
+	if len(result.Topics) > 0 {
+		fmt.Fprint(w, `<p class="result-topics">`)
+		for _, topic := range result.Topics {
+			fmt.Fprintf(w, `<span class="topic-tag">%s</span>`, template.HTMLEscapeString(topic))
 		}
+		fmt.Fprint(w, `</p>`)
+	}
+
+	if preview != "" {
+		fmt.Fprintf(w, `<p class="result-preview">%s</p>`, template.HTML(preview))
+	}
 
-		fmt.Fprintf(w, `<div class="result-footer">
-				<span class="result-score">Score: %.3f</span>
-				<a href="%s" target="_blank" rel="noopener" class="open-link">Open in Slab →</a>
+	fmt.Fprintf(w, `<div class="result-footer">
+				<span class="result-score">%s</span>
+				<span>
+					<a href="/api/doc?id=%s&amp;format=html" target="_blank" rel="noopener" class="open-link preview-link">Preview</a>
+					<a href="%s" target="_blank" rel="noopener" class="open-link">Open in Slab →</a>
+				</span>
 			</div>
 		</div>
-	</div>`, result.Score, template.HTMLEscapeString(result.SlabURL))
+	</div>`, template.HTMLEscapeString(note), url.QueryEscape(result.ID), template.HTMLEscapeString(result.SlabURL))
+}
+
+// searchParams are the search settings shared by /api/search and
+// /api/search/export, parsed from query parameters
+type searchParams struct {
+	mode         string
+	hybridWeight float64
+	hybridMethod string // "rrf" merges hybrid rankings by rank and ignores weight
+	model        string // Requested embedding model, see resolveModel
+	opts         search.SearchOptions
+}
+
+// parseSearchParams reads mode, limit, weight, method, page, offset, author,
+// author_fuzziness, topic and sort, falling back to defaults for missing or invalid values
+func parseSearchParams(params url.Values) searchParams {
+	mode := params.Get("mode")
+	if mode == "" {
+		mode = "keyword"
+	}
+
+	limit := defaultLimit
+	if limitStr := params.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= maxLimit {
+			limit = l
+		}
+	}
+
+	hybridWeight := defaultHybridWeight
+	if weightStr := params.Get("weight"); weightStr != "" {
+		if w, err := strconv.ParseFloat(weightStr, 64); err == nil && w >= 0 && w <= 1 {
+			hybridWeight = w
+		}
+	}
+
+	page := 1
+	if pageStr := params.Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	offset := (page - 1) * limit
+	if offsetStr := params.Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	filter := &search.Filter{
+		Author: params.Get("author"),
+	}
+	if fuzzStr := params.Get("author_fuzziness"); fuzzStr != "" {
+		if f, err := strconv.Atoi(fuzzStr); err == nil && f >= 0 && f <= search.MaxAuthorFuzziness {
+			filter.AuthorFuzziness = f
+		}
+	}
+	if topic := params.Get("topic"); topic != "" {
+		filter.Topics = []string{topic}
+	}
+
+	field := params.Get("field")
+	if search.CheckField(field) != nil {
+		field = ""
+	}
+
+	return searchParams{
+		mode:         mode,
+		hybridWeight: hybridWeight,
+		hybridMethod: params.Get("method"),
+		model:        params.Get("model"),
+		opts: search.SearchOptions{
+			Limit:    limit,
+			Offset:   offset,
+			Filter:   filter,
+			RawQuery: true, // The search tips advertise the query syntax
+			Field:    field,
+			Sort:     params.Get("sort"),
+		},
 	}
 }
 
-func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	dbCount, _ := s.db.Count()
-	indexCount, _ := s.idx.Count()
+// renderPagination writes previous/next controls that re-request path (the
+// current search or browse page) with an adjusted offset
+func renderPagination(w http.ResponseWriter, path string, params url.Values, offset, limit int, total uint64) {
+	hasPrev := offset > 0
+	hasNext := uint64(offset+limit) < total
+	if !hasPrev && !hasNext {
+		return
+	}
+
+	pageURL := func(newOffset int) string {
+		p := url.Values{}
+		for k, v := range params {
+			p[k] = v
+		}
+		p.Del("page")
+		p.Set("offset", strconv.Itoa(newOffset))
+		return path + "?" + p.Encode()
+	}
+
+	fmt.Fprint(w, `<div class="pagination">`)
+	if hasPrev {
+		fmt.Fprintf(w, `<button hx-get="%s" hx-target="#results">← Previous</button>`,
+			template.HTMLEscapeString(pageURL(max(offset-limit, 0))))
+	}
+	if hasNext {
+		fmt.Fprintf(w, `<button hx-get="%s" hx-target="#results">Next →</button>`,
+			template.HTMLEscapeString(pageURL(offset+limit)))
+	}
+	fmt.Fprint(w, `</div>`)
+}
+
+// handleSuggest returns titles matching a typed prefix as a JSON array of
+// {id, title}, for search box autocomplete
+func (s *Server) handleSuggest(w http.ResponseWriter, r *http.Request) {
+	limit := defaultSuggestions
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= maxSuggestions {
+			limit = l
+		}
+	}
+
+	suggestions, err := s.idx.SuggestTitles(r.URL.Query().Get("q"), limit)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error suggesting titles: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if suggestions == nil {
+		suggestions = []*search.TitleSuggestion{} // Encode [] rather than null
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":          "ok",
-		"documents_in_db": dbCount,
-		"documents_in_index": indexCount,
-		"embeddings_available": s.embedder != nil,
-	})
+	json.NewEncoder(w).Encode(suggestions)
+}
+
+// renderDoc writes a document as a standalone HTML page
+func (s *Server) renderDoc(w http.ResponseWriter, doc *storage.Document) {
+	content, err := renderMarkdown(doc.Content)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error rendering document: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Title":   doc.Title,
+		"Author":  doc.AuthorName,
+		"SlabURL": doc.SlabURL,
+		"Content": content,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.ExecuteTemplate(w, "doc.html", data); err != nil {
+		slog.Error("Failed to render template", "error", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
 }
 
 func (s *Server) handleGetDoc(w http.ResponseWriter, r *http.Request) {
@@ -260,17 +470,32 @@ func (s *Server) handleGetDoc(w http.ResponseWriter, r *http.Request) {
 
 	// Retrieve document from database
 	doc, err := s.db.Get(docID)
+	if errors.Is(err, storage.ErrNotFound) {
+		http.Error(w, "Document not found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Error retrieving document: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	if doc == nil {
-		http.Error(w, "Document not found", http.StatusNotFound)
+	// format=html renders a sanitized, readable page; raw markdown is the default
+	if r.URL.Query().Get("format") == "html" {
+		s.renderDoc(w, doc)
 		return
 	}
 
-	// Return markdown content
+	// Stream markdown content in fixed-size chunks rather than converting the
+	// whole document to a []byte, which doubles peak memory for large documents
 	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
-	w.Write([]byte(doc.Content))
+	w.Header().Set("Content-Length", strconv.Itoa(len(doc.Content)))
+
+	content := doc.Content
+	for start := 0; start < len(content); start += docChunkSize {
+		end := min(start+docChunkSize, len(content))
+		if _, err := io.WriteString(w, content[start:end]); err != nil {
+			slog.Error("Failed to stream document", "id", docID, "error", err)
+			return
+		}
+	}
 }
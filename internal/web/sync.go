@@ -0,0 +1,205 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	slabsync "github.com/renderinc/slab-search/internal/sync"
+)
+
+// errSyncRunning is returned by startSync while another sync is in progress
+var errSyncRunning = errors.New("a sync is already running")
+
+// syncDisabled is the error for sync requests to a server without a Slab token
+const syncDisabled = "sync not enabled (start the server with a Slab token)"
+
+// syncAPIDisabled is the error for POST /api/sync on a server without a sync token
+const syncAPIDisabled = "sync API not enabled (start the server with SLAB_SEARCH_SYNC_TOKEN set)"
+
+// syncJob is one server-triggered sync
+type syncJob struct {
+	ID         string          `json:"id"`
+	State      string          `json:"state"`   // "running", "succeeded" or "failed"
+	Trigger    string          `json:"trigger"` // "api" or "schedule"
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Stats      *slabsync.Stats `json:"stats,omitempty"` // Latest progress while running
+	Error      string          `json:"error,omitempty"`
+}
+
+// syncState tracks server-triggered syncs; at most one runs at a time
+type syncState struct {
+	worker    *slabsync.Worker
+	apiToken  string // Bearer token POST /api/sync requires ("" disables it)
+	afterSync func() // Run after each successful sync, before it's reported done
+
+	mu      sync.Mutex
+	current *syncJob // Running or most recent sync, nil before the first
+}
+
+// SyncStatusResponse is the body of /api/sync/status
+type SyncStatusResponse struct {
+	Running bool     `json:"running"`
+	Job     *syncJob `json:"job,omitempty"` // Running or most recent sync
+}
+
+// EnableSync lets the server sync from Slab with worker, on a schedule (see
+// ScheduleSync) and via POST /api/sync, which callers authorize with apiToken
+// as a bearer token. It's a token of its own rather than the Slab token, so
+// starting a sync doesn't need the Slab credential; an empty apiToken leaves
+// the endpoint disabled. The worker must write to the server's database and
+// index. Call before Handler.
+func (s *Server) EnableSync(worker *slabsync.Worker, apiToken string) {
+	s.sync = &syncState{worker: worker, apiToken: apiToken, afterSync: s.refreshAfterSync}
+	worker.SetProgressFn(s.sync.progress)
+}
+
+// startSync starts a sync in the background unless one is already running
+// trigger records what started it ("api" or "schedule").
+func (s *syncState) startSync(trigger string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current != nil && s.current.State == "running" {
+		return errSyncRunning
+	}
+
+	job := &syncJob{ID: newJobID(), State: "running", Trigger: trigger, StartedAt: time.Now()}
+	s.current = job
+	slog.Info("Starting sync job", "job", job.ID, "trigger", trigger)
+
+	go func() {
+		// Not the request's context: the sync outlives the request
+		stats, err := s.worker.Sync(context.Background())
+		if err == nil {
+			s.afterSync()
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		finished := time.Now()
+		job.FinishedAt = &finished
+		if err != nil {
+			job.State = "failed"
+			job.Error = err.Error()
+			slog.Error("Sync failed", "job", job.ID, "error", err)
+			return
+		}
+		job.State = "succeeded"
+		job.Stats = stats
+		slog.Info("Sync complete", "job", job.ID, "new", stats.NewPosts, "updated", stats.UpdatedPosts,
+			"skipped", stats.SkippedPosts, "deleted", stats.DeletedPosts, "errors", stats.Errors, "duration", stats.Duration)
+	}()
+
+	return nil
+}
+
+// refreshAfterSync rebuilds the ANN index from the synced database, so
+// semantic and hybrid search see new, updated and removed documents instead
+// of falling back to brute force (the rebuild also drops cached results)
+func (s *Server) refreshAfterSync() {
+	if s.embedder == nil {
+		return // No semantic search, so the index was never built
+	}
+	start := time.Now()
+	if err := s.idx.BuildVectorIndex(); err != nil {
+		slog.Warn("Failed to rebuild vector index after sync", "error", err)
+		return
+	}
+	slog.Info("Vector index rebuilt", "duration", time.Since(start).Round(time.Millisecond))
+}
+
+// ScheduleSync syncs every interval in the background, skipping a run while
+// the previous (or an API-triggered) sync is still going. Requires EnableSync.
+func (s *Server) ScheduleSync(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.sync.startSync("schedule"); errors.Is(err, errSyncRunning) {
+				slog.Info("Skipping scheduled sync, the previous one is still running")
+			}
+		}
+	}()
+}
+
+// progress records the running sync's latest stats
+func (s *syncState) progress(stats slabsync.Stats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current != nil && s.current.State == "running" {
+		s.current.Stats = &stats
+	}
+}
+
+// status returns a copy of the running or most recent sync, nil before the first
+func (s *syncState) status() *syncJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == nil {
+		return nil
+	}
+	job := *s.current
+	return &job
+}
+
+// authorized reports whether r carries the sync API token as a bearer token
+func (s *syncState) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && s.apiToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(s.apiToken)) == 1
+}
+
+// newJobID returns a random ID for a sync job
+func newJobID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// handleSync serves POST /api/sync, starting a sync in the background and
+// returning its job (202), or the running one (409)
+func (s *Server) handleSync(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "use POST"})
+		return
+	}
+	if s.sync == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": syncDisabled})
+		return
+	}
+	if s.sync.apiToken == "" {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": syncAPIDisabled})
+		return
+	}
+	if !s.sync.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="slab-search"`)
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "missing or wrong bearer token"})
+		return
+	}
+
+	status := http.StatusAccepted
+	if err := s.sync.startSync("api"); errors.Is(err, errSyncRunning) {
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, SyncStatusResponse{Running: true, Job: s.sync.status()})
+}
+
+// handleSyncStatus serves GET /api/sync/status with the running or most
+// recent sync
+func (s *Server) handleSyncStatus(w http.ResponseWriter, r *http.Request) {
+	if s.sync == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": syncDisabled})
+		return
+	}
+	job := s.sync.status()
+	writeJSON(w, http.StatusOK, SyncStatusResponse{Running: job != nil && job.State == "running", Job: job})
+}